@@ -0,0 +1,293 @@
+package alb
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/webbgeorge/lambdah"
+	"github.com/webbgeorge/lambdah/internal/clone"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type Context struct {
+	Context  context.Context
+	Request  events.ALBTargetGroupRequest
+	Response events.ALBTargetGroupResponse
+}
+
+func (c *Context) Bind(v interface{}) error {
+	body := []byte(c.Request.Body)
+	if c.Request.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(c.Request.Body)
+		if err != nil {
+			return err
+		}
+		body = decoded
+	}
+
+	err := json.Unmarshal(body, v)
+	if err != nil {
+		return err
+	}
+
+	return lambdah.Validate(v)
+}
+
+// Header returns the first value of the named request header, whether or not
+// multi value headers are enabled on the target group. Header names are not
+// case sensitive.
+func (c *Context) Header(name string) string {
+	name = strings.ToLower(name)
+	if c.Request.MultiValueHeaders != nil {
+		for k, vs := range c.Request.MultiValueHeaders {
+			if strings.ToLower(k) == name && len(vs) > 0 {
+				return vs[0]
+			}
+		}
+		return ""
+	}
+	for k, v := range c.Request.Headers {
+		if strings.ToLower(k) == name {
+			return v
+		}
+	}
+	return ""
+}
+
+// QueryParam returns the first value of the named query string parameter,
+// whether or not multi value headers are enabled on the target group. ALB does
+// not decode query string parameters, so the value is URL decoded here.
+func (c *Context) QueryParam(name string) string {
+	value := ""
+	if c.Request.MultiValueQueryStringParameters != nil {
+		if vs := c.Request.MultiValueQueryStringParameters[name]; len(vs) > 0 {
+			value = vs[0]
+		}
+	} else {
+		value = c.Request.QueryStringParameters[name]
+	}
+
+	decoded, err := url.QueryUnescape(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+func (c *Context) String(statusCode int, str string) error {
+	c.Response.Body = str
+	c.Response.StatusCode = statusCode
+	return nil
+}
+
+func (c *Context) JSON(statusCode int, body interface{}) error {
+	if body != nil {
+		if c.Response.Headers == nil {
+			c.Response.Headers = make(map[string]string)
+		}
+		c.Response.Headers["Content-Type"] = "application/json"
+
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		c.Response.Body = string(b)
+	}
+	c.Response.StatusCode = statusCode
+	return nil
+}
+
+type HandlerFunc func(c *Context) error
+
+func (hf HandlerFunc) Start() {
+	lambda.Start(hf.ToLambdaHandler())
+}
+
+// Apply middleware to the handler func.
+//
+// Middleware is called in the order it is given to this function.
+func (hf HandlerFunc) Middleware(middleware ...Middleware) HandlerFunc {
+	// apply middleware in reverse order
+	for i := len(middleware) - 1; i >= 0; i-- {
+		hf = middleware[i](hf)
+	}
+	return hf
+}
+
+// Get the AWS Lambda handler of the handler func.
+//
+// Useful if you need to call AWS lambda.Start(...) directly,
+// not required in most cases.
+//
+// The response status description is set from the status code if not already set.
+// If multi value headers are enabled on the target group, the response headers are
+// returned as multi value headers, as ALB ignores single value headers in this mode.
+func (hf HandlerFunc) ToLambdaHandler() func(
+	ctx context.Context,
+	request events.ALBTargetGroupRequest,
+) (events.ALBTargetGroupResponse, error) {
+	return func(
+		ctx context.Context,
+		request events.ALBTargetGroupRequest,
+	) (events.ALBTargetGroupResponse, error) {
+		c := &Context{
+			Context: ctx,
+			Request: request,
+		}
+
+		err := hf(c)
+		if err != nil {
+			// catch any unhandled errors and return default error
+			// if error handler middleware is on, no errors will return here
+			c.Response.StatusCode = http.StatusInternalServerError
+			c.Response.StatusDescription = ""
+			c.Response.Body = "Internal server error"
+
+			if c.Response.Headers == nil {
+				c.Response.Headers = make(map[string]string)
+			}
+			c.Response.Headers["Content-Type"] = "text/html"
+		}
+
+		return finaliseResponse(request, c.Response), nil
+	}
+}
+
+func finaliseResponse(
+	request events.ALBTargetGroupRequest,
+	res events.ALBTargetGroupResponse,
+) events.ALBTargetGroupResponse {
+	if res.StatusDescription == "" {
+		res.StatusDescription = fmt.Sprintf("%d %s", res.StatusCode, http.StatusText(res.StatusCode))
+	}
+
+	if request.MultiValueHeaders != nil {
+		if res.MultiValueHeaders == nil {
+			res.MultiValueHeaders = make(map[string][]string)
+		}
+		for k, v := range res.Headers {
+			res.MultiValueHeaders[k] = append(res.MultiValueHeaders[k], v)
+		}
+		res.Headers = nil
+	}
+
+	return res
+}
+
+// ToHTTPHandler turns the Lambdah handler function into a go http.Handler.
+// This is useful for using go http testing tools with ALB target handlers.
+//
+// multiValueHeaders simulates whether multi value headers are enabled on the
+// target group. If enabled, requests only have multi value headers and query string
+// parameters, and only multi value headers are used from the response. If disabled,
+// only the last value of each header and query string parameter is sent.
+func (hf HandlerFunc) ToHttpHandler(multiValueHeaders bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			panic(err)
+		}
+
+		request := events.ALBTargetGroupRequest{
+			HTTPMethod: r.Method,
+			Path:       r.URL.Path,
+			Body:       string(body),
+			RequestContext: events.ALBTargetGroupRequestContext{
+				ELB: events.ELBContext{
+					TargetGroupArn: "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/lambdah/0123456789abcdef",
+				},
+			},
+		}
+		headers := lowerCaseKeys(r.Header)
+		query := encodeValues(r.URL.Query())
+		if multiValueHeaders {
+			request.MultiValueHeaders = headers
+			request.MultiValueQueryStringParameters = query
+		} else {
+			request.Headers = lastValue(headers)
+			request.QueryStringParameters = lastValue(query)
+		}
+
+		res, err := hf.ToLambdaHandler()(r.Context(), request)
+		if err != nil {
+			// write a generic error, the same as ALB would if an error was returned by handler
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`error`))
+			return
+		}
+
+		writeResponse(w, res, multiValueHeaders)
+	})
+}
+
+func lowerCaseKeys(multiValueMap map[string][]string) map[string][]string {
+	lowerCaseMap := make(map[string][]string)
+	for k, mv := range multiValueMap {
+		lowerCaseMap[strings.ToLower(k)] = mv
+	}
+	return lowerCaseMap
+}
+
+// encodeValues URL encodes values, as ALB passes query string parameters without decoding them.
+func encodeValues(multiValueMap map[string][]string) map[string][]string {
+	encodedMap := make(map[string][]string)
+	for k, mv := range multiValueMap {
+		for _, v := range mv {
+			encodedMap[url.QueryEscape(k)] = append(encodedMap[url.QueryEscape(k)], url.QueryEscape(v))
+		}
+	}
+	return encodedMap
+}
+
+func lastValue(multiValueMap map[string][]string) map[string]string {
+	singleValueMap := make(map[string]string)
+	for k, mv := range multiValueMap {
+		if len(mv) > 0 {
+			singleValueMap[k] = mv[len(mv)-1]
+		}
+	}
+	return singleValueMap
+}
+
+func writeResponse(w http.ResponseWriter, res events.ALBTargetGroupResponse, multiValueHeaders bool) {
+	if multiValueHeaders {
+		for k, vs := range res.MultiValueHeaders {
+			for _, v := range vs {
+				w.Header().Add(k, v)
+			}
+		}
+	} else {
+		for k, v := range res.Headers {
+			w.Header().Add(k, v)
+		}
+	}
+
+	body := []byte(res.Body)
+	if res.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(res.Body)
+		if err == nil {
+			body = decoded
+		}
+	}
+
+	w.WriteHeader(res.StatusCode)
+	_, _ = w.Write(body)
+}
+
+// clone returns a copy of the context which shares no response maps with c, so
+// that a handler left running in the background after a timeout does not race
+// with the response written by earlier middleware.
+func (c *Context) clone() Context {
+	tc := *c
+	tc.Response.Headers = clone.Map(c.Response.Headers)
+	tc.Response.MultiValueHeaders = clone.MultiValueMap(c.Response.MultiValueHeaders)
+	return tc
+}
@@ -0,0 +1,280 @@
+package alb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/steinfletcher/apitest"
+	jsonpath "github.com/steinfletcher/apitest-jsonpath"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestALBHandler_Success(t *testing.T) {
+	h := func(c *Context) error {
+		var data requestData
+		err := c.Bind(&data)
+		if err != nil {
+			return err
+		}
+
+		assert.Equal(t, "hello", data.Message)
+
+		return c.JSON(http.StatusOK, responseData{Status: "all good"})
+	}
+
+	awsHandler := HandlerFunc(h).ToLambdaHandler()
+	res, err := awsHandler(
+		context.Background(),
+		events.ALBTargetGroupRequest{
+			Body: `{"message": "hello"}`,
+		},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "200 OK", res.StatusDescription)
+	assert.Equal(t, "application/json", res.Headers["Content-Type"])
+	assert.Nil(t, res.MultiValueHeaders)
+	assert.Equal(t, `{"status":"all good"}`, res.Body)
+}
+
+func TestALBHandler_MultiValueHeaders(t *testing.T) {
+	h := func(c *Context) error {
+		c.Response.MultiValueHeaders = map[string][]string{
+			"Test-Header": {"valueOne", "valueTwo"},
+		}
+		return c.JSON(http.StatusCreated, responseData{Status: "all good"})
+	}
+
+	awsHandler := HandlerFunc(h).ToLambdaHandler()
+	res, err := awsHandler(
+		context.Background(),
+		events.ALBTargetGroupRequest{
+			MultiValueHeaders: map[string][]string{"accept": {"application/json"}},
+		},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 201, res.StatusCode)
+	assert.Equal(t, "201 Created", res.StatusDescription)
+	assert.Nil(t, res.Headers)
+	assert.Equal(t, map[string][]string{
+		"Content-Type": {"application/json"},
+		"Test-Header":  {"valueOne", "valueTwo"},
+	}, res.MultiValueHeaders)
+}
+
+func TestALBHandler_CustomStatusDescription(t *testing.T) {
+	h := func(c *Context) error {
+		c.Response.StatusDescription = "200 All Good"
+		return c.String(http.StatusOK, "all good")
+	}
+
+	res, err := HandlerFunc(h).ToLambdaHandler()(context.Background(), events.ALBTargetGroupRequest{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "200 All Good", res.StatusDescription)
+}
+
+func TestALBHandler_NoErrorHandler(t *testing.T) {
+	h := func(c *Context) error {
+		return errors.New("an error happened")
+	}
+
+	res, err := HandlerFunc(h).ToLambdaHandler()(context.Background(), events.ALBTargetGroupRequest{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 500, res.StatusCode)
+	assert.Equal(t, "500 Internal Server Error", res.StatusDescription)
+	assert.Equal(t, `Internal server error`, res.Body)
+}
+
+func TestALBHandler_DefaultErrorHandler(t *testing.T) {
+	h := func(c *Context) error {
+		return errors.New("an error happened")
+	}
+
+	awsHandler := HandlerFunc(h).Middleware(ErrorHandlerMiddleware()).ToLambdaHandler()
+	res, err := awsHandler(context.Background(), events.ALBTargetGroupRequest{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 500, res.StatusCode)
+	assert.Equal(t, `{"message":"Internal server error"}`, res.Body)
+}
+
+func TestALBHandler_Middleware(t *testing.T) {
+	callOrder := make([]string, 0)
+
+	h := func(c *Context) error {
+		callOrder = append(callOrder, "handler")
+		return c.String(http.StatusOK, "all good")
+	}
+
+	mw1 := func(h HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			callOrder = append(callOrder, "mw1 in")
+			err := h(c)
+			callOrder = append(callOrder, "mw1 out")
+			return err
+		}
+	}
+
+	mw2 := func(h HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			callOrder = append(callOrder, "mw2 in")
+			err := h(c)
+			callOrder = append(callOrder, "mw2 out")
+			return err
+		}
+	}
+
+	awsHandler := HandlerFunc(h).Middleware(mw1, mw2).ToLambdaHandler()
+	res, err := awsHandler(context.Background(), events.ALBTargetGroupRequest{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, []string{"mw1 in", "mw2 in", "handler", "mw2 out", "mw1 out"}, callOrder)
+}
+
+func TestALBContext_Bind_InvalidJSON(t *testing.T) {
+	c := &Context{
+		Request: events.ALBTargetGroupRequest{
+			Body: `{"messag`,
+		},
+	}
+
+	var data requestData
+	err := c.Bind(&data)
+
+	assert.Error(t, err)
+}
+
+func TestALBContext_Bind_Base64Encoded(t *testing.T) {
+	c := &Context{
+		Request: events.ALBTargetGroupRequest{
+			Body:            "eyJtZXNzYWdlIjogImhlbGxvIn0=",
+			IsBase64Encoded: true,
+		},
+	}
+
+	var data requestData
+	err := c.Bind(&data)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", data.Message)
+}
+
+func TestALBContext_Bind_WithValidationError(t *testing.T) {
+	c := &Context{
+		Request: events.ALBTargetGroupRequest{
+			Body: `{}`,
+		},
+	}
+
+	var data requestData
+	err := c.Bind(&data)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "invalid message", err.Error())
+}
+
+func TestALBContext_HeaderAndQueryParam(t *testing.T) {
+	singleValue := &Context{
+		Request: events.ALBTargetGroupRequest{
+			Headers:               map[string]string{"x-test": "single"},
+			QueryStringParameters: map[string]string{"name": "hello%20world"},
+		},
+	}
+	assert.Equal(t, "single", singleValue.Header("X-Test"))
+	assert.Equal(t, "hello world", singleValue.QueryParam("name"))
+	assert.Equal(t, "", singleValue.Header("X-Missing"))
+
+	multiValue := &Context{
+		Request: events.ALBTargetGroupRequest{
+			MultiValueHeaders:               map[string][]string{"x-test": {"multi1", "multi2"}},
+			MultiValueQueryStringParameters: map[string][]string{"name": {"one", "two"}},
+		},
+	}
+	assert.Equal(t, "multi1", multiValue.Header("X-Test"))
+	assert.Equal(t, "one", multiValue.QueryParam("name"))
+	assert.Equal(t, "", multiValue.QueryParam("missing"))
+}
+
+// test HTTP handler function using steinfletcher/apitest
+func TestHandlerFunc_ToHttpHandler_SingleValueHeaders(t *testing.T) {
+	h := func(c *Context) error {
+		assert.Nil(t, c.Request.MultiValueHeaders)
+		assert.Nil(t, c.Request.MultiValueQueryStringParameters)
+		assert.Equal(t, "val2", c.Request.Headers["multi-header"])
+		assert.Equal(t, "hello world", c.QueryParam("q"))
+
+		return c.JSON(http.StatusOK, responseData{Status: "all good"})
+	}
+
+	apitest.New().
+		Handler(HandlerFunc(h).ToHttpHandler(false)).
+		Get("/animal/dog").
+		Query("q", "hello world").
+		Header("Multi-Header", "val1").
+		Header("Multi-Header", "val2").
+		Expect(t).
+		Status(http.StatusOK).
+		Assert(jsonpath.Equal(`$.status`, "all good")).
+		Header("Content-Type", "application/json").
+		End()
+}
+
+func TestHandlerFunc_ToHttpHandler_MultiValueHeaders(t *testing.T) {
+	h := func(c *Context) error {
+		var data struct {
+			Age int
+		}
+		err := c.Bind(&data)
+		if err != nil {
+			return err
+		}
+
+		assert.Equal(t, 12, data.Age)
+		assert.Nil(t, c.Request.Headers)
+		assert.Equal(t, []string{"val1", "val2"}, c.Request.MultiValueHeaders["multi-header"])
+		assert.Equal(t, []string{"a", "b"}, c.Request.MultiValueQueryStringParameters["q"])
+
+		c.Response.MultiValueHeaders = map[string][]string{
+			"Test-Header": {"valueOne", "valueTwo"},
+		}
+		return c.JSON(http.StatusOK, responseData{Status: "all good"})
+	}
+
+	apitest.New().
+		Handler(HandlerFunc(h).ToHttpHandler(true)).
+		Put("/animal/dog").
+		Query("q", "a").
+		Query("q", "b").
+		Header("Multi-Header", "val1").
+		Header("Multi-Header", "val2").
+		Body(`{"age": 12}`).
+		Expect(t).
+		Status(http.StatusOK).
+		Assert(jsonpath.Equal(`$.status`, "all good")).
+		Header("Content-Type", "application/json").
+		HeaderPresent("Test-Header").
+		End()
+}
+
+type requestData struct {
+	Message string `json:"message"`
+}
+
+func (d *requestData) Validate() error {
+	if d.Message == "" {
+		return errors.New("invalid message")
+	}
+	return nil
+}
+
+type responseData struct {
+	Status string `json:"status"`
+}
@@ -0,0 +1,285 @@
+package api_gateway_proxy
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// maxMultipartMemory is the maximum size of a multipart form held in memory,
+// the rest is stored in temporary files. API Gateway limits payloads to 10MB.
+const maxMultipartMemory = 10 << 20
+
+var errInvalidForm = Error{
+	StatusCode: http.StatusBadRequest,
+	Message:    "Invalid form data",
+}
+
+var (
+	fileHeaderType      = reflect.TypeOf(&multipart.FileHeader{})
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// bindBody decodes the request body into v, using a decoder chosen by the request
+// Content-Type. Requests without a body are not decoded.
+func (c *Context) bindBody(v interface{}) error {
+	body, err := c.Body()
+	if err != nil {
+		return err
+	}
+	if len(body) == 0 {
+		return nil
+	}
+
+	contentType := c.header("Content-Type")
+	mediaType := "application/json"
+	params := map[string]string{}
+	if contentType != "" {
+		mediaType, params, err = mime.ParseMediaType(contentType)
+		if err != nil {
+			return Error{
+				StatusCode: http.StatusUnsupportedMediaType,
+				Message:    "Unsupported media type",
+			}
+		}
+	}
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return json.Unmarshal(body, v)
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return xml.Unmarshal(body, v)
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return errInvalidForm
+		}
+		return bindForm(v, values, nil)
+	case mediaType == "multipart/form-data":
+		form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(maxMultipartMemory)
+		if err != nil {
+			return errInvalidForm
+		}
+		if c.forms == nil {
+			c.forms = &formSet{}
+		}
+		c.forms.add(form)
+		return bindForm(v, form.Value, form.File)
+	default:
+		return Error{
+			StatusCode: http.StatusUnsupportedMediaType,
+			Message:    "Unsupported media type",
+		}
+	}
+}
+
+// bindParams binds path parameters, query string parameters and headers into
+// fields of v tagged `path`, `query` and `header`.
+func (c *Context) bindParams(v interface{}) error {
+	headers := make(map[string][]string)
+	for k, v := range c.Request.Headers {
+		headers[strings.ToLower(k)] = []string{v}
+	}
+	for k, vs := range c.Request.MultiValueHeaders {
+		headers[strings.ToLower(k)] = vs
+	}
+
+	query := make(map[string][]string)
+	for k, v := range c.Request.QueryStringParameters {
+		query[k] = []string{v}
+	}
+	for k, vs := range c.Request.MultiValueQueryStringParameters {
+		query[k] = vs
+	}
+
+	path := make(map[string][]string)
+	for k, v := range c.Request.PathParameters {
+		path[k] = []string{v}
+	}
+
+	return bindStruct(v, func(field reflect.StructField) ([]string, string, bool) {
+		if name, ok := field.Tag.Lookup("path"); ok {
+			values, ok := path[name]
+			return values, fmt.Sprintf("path parameter '%s'", name), ok
+		}
+		if name, ok := field.Tag.Lookup("query"); ok {
+			values, ok := query[name]
+			return values, fmt.Sprintf("query parameter '%s'", name), ok
+		}
+		if name, ok := field.Tag.Lookup("header"); ok {
+			values, ok := headers[strings.ToLower(name)]
+			return values, fmt.Sprintf("header '%s'", name), ok
+		}
+		return nil, "", false
+	})
+}
+
+// header returns the first value of the named request header, ignoring case.
+func (c *Context) header(name string) string {
+	for k, vs := range c.Request.MultiValueHeaders {
+		if strings.EqualFold(k, name) && len(vs) > 0 {
+			return vs[0]
+		}
+	}
+	for k, v := range c.Request.Headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+func bindForm(v interface{}, values map[string][]string, files map[string][]*multipart.FileHeader) error {
+	err := bindStruct(v, func(field reflect.StructField) ([]string, string, bool) {
+		name, ok := field.Tag.Lookup("form")
+		if !ok || field.Type == fileHeaderType || field.Type == fileHeaderSliceType {
+			return nil, "", false
+		}
+		formValues, ok := values[name]
+		return formValues, fmt.Sprintf("form field '%s'", name), ok
+	})
+	if err != nil {
+		return err
+	}
+
+	return walkFields(reflect.ValueOf(v), func(field reflect.StructField, fieldValue reflect.Value) error {
+		name, ok := field.Tag.Lookup("form")
+		if !ok || len(files[name]) == 0 {
+			return nil
+		}
+		switch field.Type {
+		case fileHeaderType:
+			fieldValue.Set(reflect.ValueOf(files[name][0]))
+		case fileHeaderSliceType:
+			fieldValue.Set(reflect.ValueOf(files[name]))
+		}
+		return nil
+	})
+}
+
+// bindStruct sets each field of the struct pointed to by v for which lookup returns
+// values, converting the values to the type of the field.
+func bindStruct(v interface{}, lookup func(field reflect.StructField) ([]string, string, bool)) error {
+	return walkFields(reflect.ValueOf(v), func(field reflect.StructField, fieldValue reflect.Value) error {
+		values, source, ok := lookup(field)
+		if !ok || len(values) == 0 {
+			return nil
+		}
+		err := setField(fieldValue, values)
+		if err != nil {
+			return Error{
+				StatusCode: http.StatusBadRequest,
+				Message:    fmt.Sprintf("Invalid value for %s", source),
+			}
+		}
+		return nil
+	})
+}
+
+// walkFields calls fn for each settable field of the struct pointed to by v,
+// including the fields of embedded structs.
+func walkFields(v reflect.Value, fn func(field reflect.StructField, fieldValue reflect.Value) error) error {
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldValue := v.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			err := walkFields(fieldValue.Addr(), fn)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if !fieldValue.CanSet() {
+			continue
+		}
+
+		err := fn(field, fieldValue)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setField(v reflect.Value, values []string) error {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			err := setValue(slice.Index(i), value)
+			if err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+	return setValue(v, values[0])
+}
+
+func setValue(v reflect.Value, value string) error {
+	if v.Kind() == reflect.Ptr {
+		ptr := reflect.New(v.Type().Elem())
+		err := setValue(ptr.Elem(), value)
+		if err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+
+	if reflect.PtrTo(v.Type()).Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,314 @@
+package api_gateway_proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+type bindData struct {
+	Name  string `json:"name" xml:"name" form:"name"`
+	Count int    `json:"count" xml:"count" form:"count"`
+}
+
+func TestContext_Bind_JSONWithCharset(t *testing.T) {
+	c := &Context{Request: events.APIGatewayProxyRequest{
+		Headers: map[string]string{"content-type": "application/json; charset=utf-8"},
+		Body:    `{"name": "dog", "count": 2}`,
+	}}
+
+	var data bindData
+	err := c.Bind(&data)
+
+	assert.Nil(t, err)
+	assert.Equal(t, bindData{Name: "dog", Count: 2}, data)
+}
+
+func TestContext_Bind_XML(t *testing.T) {
+	c := &Context{Request: events.APIGatewayProxyRequest{
+		Headers: map[string]string{"Content-Type": "application/xml"},
+		Body:    `<data><name>dog</name><count>2</count></data>`,
+	}}
+
+	var data bindData
+	err := c.Bind(&data)
+
+	assert.Nil(t, err)
+	assert.Equal(t, bindData{Name: "dog", Count: 2}, data)
+}
+
+func TestContext_Bind_Form(t *testing.T) {
+	c := &Context{Request: events.APIGatewayProxyRequest{
+		Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		Body:    `name=big+dog&count=2`,
+	}}
+
+	var data bindData
+	err := c.Bind(&data)
+
+	assert.Nil(t, err)
+	assert.Equal(t, bindData{Name: "big dog", Count: 2}, data)
+}
+
+func TestContext_Bind_Form_InvalidValue(t *testing.T) {
+	c := &Context{Request: events.APIGatewayProxyRequest{
+		Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		Body:    `name=dog&count=two`,
+	}}
+
+	var data bindData
+	err := c.Bind(&data)
+
+	assert.Equal(t, Error{StatusCode: 400, Message: "Invalid value for form field 'count'"}, err)
+}
+
+func TestContext_Bind_Multipart(t *testing.T) {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	_ = mw.WriteField("name", "dog")
+	fw, _ := mw.CreateFormFile("photo", "dog.png")
+	_, _ = fw.Write([]byte{0x89, 0x50, 0x4e, 0x47})
+	fw, _ = mw.CreateFormFile("attachments", "a.txt")
+	_, _ = fw.Write([]byte("a"))
+	fw, _ = mw.CreateFormFile("attachments", "b.txt")
+	_, _ = fw.Write([]byte("b"))
+	_ = mw.Close()
+
+	c := &Context{Request: events.APIGatewayProxyRequest{
+		Headers: map[string]string{"Content-Type": mw.FormDataContentType()},
+		Body:    body.String(),
+	}}
+
+	var data struct {
+		Name        string                  `form:"name"`
+		Photo       *multipart.FileHeader   `form:"photo"`
+		Attachments []*multipart.FileHeader `form:"attachments"`
+	}
+	err := c.Bind(&data)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "dog", data.Name)
+	assert.Equal(t, "dog.png", data.Photo.Filename)
+	f, err := data.Photo.Open()
+	assert.Nil(t, err)
+	b, _ := ioutil.ReadAll(f)
+	assert.Equal(t, []byte{0x89, 0x50, 0x4e, 0x47}, b)
+	assert.Len(t, data.Attachments, 2)
+	assert.Equal(t, "b.txt", data.Attachments[1].Filename)
+}
+
+func TestContext_Bind_InvalidForm(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{name: "form", contentType: "application/x-www-form-urlencoded", body: `name=%zz`},
+		{name: "multipart", contentType: "multipart/form-data; boundary=xyz", body: "--xyz\r\nnot a part"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Context{Request: events.APIGatewayProxyRequest{
+				Headers: map[string]string{"Content-Type": tc.contentType},
+				Body:    tc.body,
+			}}
+
+			var data bindData
+			err := c.Bind(&data)
+
+			assert.Equal(t, Error{StatusCode: http.StatusBadRequest, Message: "Invalid form data"}, err)
+		})
+	}
+}
+
+func TestHandlerFunc_RemovesMultipartFiles(t *testing.T) {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	fw, _ := mw.CreateFormFile("photo", "large.bin")
+	// larger than the memory limit, so the file is stored in a temporary file
+	_, _ = fw.Write(bytes.Repeat([]byte("a"), maxMultipartMemory+1))
+	_ = mw.Close()
+
+	var data struct {
+		Photo *multipart.FileHeader `form:"photo"`
+	}
+	h := HandlerFunc(func(c *Context) error {
+		err := c.Bind(&data)
+		if err != nil {
+			return err
+		}
+		f, err := data.Photo.Open()
+		if err != nil {
+			return err
+		}
+		_ = f.Close()
+		return c.String(http.StatusOK, "ok")
+	})
+
+	res, err := h.ToLambdaHandler()(context.Background(), events.APIGatewayProxyRequest{
+		Headers: map[string]string{"Content-Type": mw.FormDataContentType()},
+		Body:    body.String(),
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	_, err = data.Photo.Open()
+	assert.Error(t, err)
+}
+
+func TestHandlerFunc_RemovesMultipartFiles_Timeout(t *testing.T) {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	fw, _ := mw.CreateFormFile("photo", "large.bin")
+	_, _ = fw.Write(bytes.Repeat([]byte("a"), maxMultipartMemory+1))
+	_ = mw.Close()
+
+	bound := make(chan *multipart.FileHeader, 1)
+	h := HandlerFunc(func(c *Context) error {
+		var data struct {
+			Photo *multipart.FileHeader `form:"photo"`
+		}
+		err := c.Bind(&data)
+		if err != nil {
+			return err
+		}
+		bound <- data.Photo
+		<-c.Context.Done()
+		return nil
+	}).Middleware(TimeoutMiddleware(10 * time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	res, err := h.ToLambdaHandler()(ctx, events.APIGatewayProxyRequest{
+		Headers: map[string]string{"Content-Type": mw.FormDataContentType()},
+		Body:    body.String(),
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	_, err = (<-bound).Open()
+	assert.Error(t, err)
+}
+
+func TestContext_Bind_UnsupportedMediaType(t *testing.T) {
+	c := &Context{Request: events.APIGatewayProxyRequest{
+		Headers: map[string]string{"Content-Type": "text/csv"},
+		Body:    `name,count`,
+	}}
+
+	var data bindData
+	err := c.Bind(&data)
+
+	assert.Equal(t, Error{StatusCode: http.StatusUnsupportedMediaType, Message: "Unsupported media type"}, err)
+}
+
+type pagination struct {
+	Page    int  `query:"page"`
+	PerPage *int `query:"per_page"`
+}
+
+type paramData struct {
+	pagination
+	ID       uint64    `path:"id"`
+	Tags     []string  `query:"tag"`
+	Scores   []float64 `query:"score"`
+	Draft    bool      `query:"draft"`
+	Since    time.Time `query:"since"`
+	APIKey   string    `header:"X-Api-Key"`
+	Accept   []string  `header:"Accept"`
+	Name     string    `json:"name"`
+	Untagged string
+}
+
+func TestContext_Bind_Params(t *testing.T) {
+	c := &Context{Request: events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"id": "42"},
+		QueryStringParameters: map[string]string{
+			"page":     "3",
+			"per_page": "25",
+			"draft":    "true",
+			"since":    "2020-01-02T03:04:05Z",
+		},
+		MultiValueQueryStringParameters: map[string][]string{
+			"tag":   {"a", "b"},
+			"score": {"1.5", "2"},
+		},
+		Headers:           map[string]string{"x-api-key": "secret"},
+		MultiValueHeaders: map[string][]string{"Accept": {"text/html", "application/json"}},
+		Body:              `{"name": "dog"}`,
+	}}
+
+	var data paramData
+	err := c.Bind(&data)
+
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(42), data.ID)
+	assert.Equal(t, 3, data.Page)
+	assert.Equal(t, 25, *data.PerPage)
+	assert.True(t, data.Draft)
+	assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), data.Since)
+	assert.Equal(t, []string{"a", "b"}, data.Tags)
+	assert.Equal(t, []float64{1.5, 2}, data.Scores)
+	assert.Equal(t, "secret", data.APIKey)
+	assert.Equal(t, []string{"text/html", "application/json"}, data.Accept)
+	assert.Equal(t, "dog", data.Name)
+	assert.Equal(t, "", data.Untagged)
+}
+
+func TestContext_Bind_Params_NoBody(t *testing.T) {
+	c := &Context{Request: events.APIGatewayProxyRequest{
+		HTTPMethod:     http.MethodGet,
+		PathParameters: map[string]string{"id": "42"},
+	}}
+
+	var data paramData
+	err := c.Bind(&data)
+
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(42), data.ID)
+	assert.Nil(t, data.PerPage)
+}
+
+func TestContext_Bind_Params_InvalidValue(t *testing.T) {
+	c := &Context{Request: events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"page": "first"},
+	}}
+
+	var data paramData
+	err := c.Bind(&data)
+
+	assert.Equal(t, Error{StatusCode: 400, Message: "Invalid value for query parameter 'page'"}, err)
+}
+
+type validatedParams struct {
+	ID string `path:"id"`
+}
+
+func (d *validatedParams) Validate() error {
+	if d.ID == "" {
+		return errors.New("id is required")
+	}
+	return nil
+}
+
+func TestContext_Bind_Params_ValidatedAfterBinding(t *testing.T) {
+	c := &Context{Request: events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"id": "42"},
+	}}
+	var data validatedParams
+	assert.Nil(t, c.Bind(&data))
+	assert.Equal(t, "42", data.ID)
+
+	c = &Context{Request: events.APIGatewayProxyRequest{}}
+	data = validatedParams{}
+	assert.EqualError(t, c.Bind(&data), "id is required")
+}
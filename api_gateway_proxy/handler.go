@@ -2,11 +2,17 @@ package api_gateway_proxy
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
+	"sync"
+	"unicode/utf8"
 
 	"github.com/webbgeorge/lambdah"
+	"github.com/webbgeorge/lambdah/internal/clone"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -17,23 +23,54 @@ type Context struct {
 	Context  context.Context
 	Request  events.APIGatewayProxyRequest
 	Response events.APIGatewayProxyResponse
+
+	// multipart forms bound by the handler, whose files are removed when it returns
+	forms *formSet
+}
+
+// Body returns the request body, decoding it if it is base64 encoded, as API
+// Gateway does for binary media types.
+func (c *Context) Body() ([]byte, error) {
+	if c.Request.IsBase64Encoded {
+		return base64.StdEncoding.DecodeString(c.Request.Body)
+	}
+	return []byte(c.Request.Body), nil
 }
 
+// Bind the request into v, and validate it using lambdah.Validate.
+//
+// The request body is decoded using the request Content-Type, supporting JSON
+// (the default if no Content-Type is given), XML, `application/x-www-form-urlencoded`
+// and `multipart/form-data`. Form fields are bound into struct fields tagged
+// `form:"name"`, and multipart files into fields of type *multipart.FileHeader or
+// []*multipart.FileHeader. Other content types return a 415 Error, and bodies
+// which can't be parsed as a form return a 400 Error.
+//
+// Multipart files are only available until the handler returns, when any
+// temporary files of large forms are removed. Copy files which are needed after
+// the handler returns.
+//
+// Struct fields tagged `path:"name"`, `query:"name"` and `header:"Name"` are then set
+// from the path parameters, query string parameters and headers of the request,
+// converting values to the type of the field. Slice fields receive all values of
+// multi value query string parameters and headers. Invalid values return a 400 Error.
 func (c *Context) Bind(v interface{}) error {
-	err := json.Unmarshal([]byte(c.Request.Body), v)
+	err := c.bindBody(v)
 	if err != nil {
 		return err
 	}
 
-	if validatable, ok := v.(lambdah.Validatable); ok {
-		return validatable.Validate()
+	err = c.bindParams(v)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return lambdah.Validate(v)
 }
 
 func (c *Context) String(statusCode int, str string) error {
 	c.Response.Body = str
+	c.Response.IsBase64Encoded = false
 	c.Response.StatusCode = statusCode
 	return nil
 }
@@ -50,11 +87,38 @@ func (c *Context) JSON(statusCode int, body interface{}) error {
 			return err
 		}
 		c.Response.Body = string(b)
+		c.Response.IsBase64Encoded = false
+	}
+	c.Response.StatusCode = statusCode
+	return nil
+}
+
+// Blob responds with binary data, which is base64 encoded in the response.
+//
+// For API Gateway to decode the response, the content type, or the request's
+// `Accept` header, must be one of the API's binary media types.
+func (c *Context) Blob(statusCode int, contentType string, b []byte) error {
+	if c.Response.Headers == nil {
+		c.Response.Headers = make(map[string]string)
 	}
+	c.Response.Headers["Content-Type"] = contentType
+	c.Response.Body = base64.StdEncoding.EncodeToString(b)
+	c.Response.IsBase64Encoded = true
 	c.Response.StatusCode = statusCode
 	return nil
 }
 
+// Stream responds with binary data read from r, see Blob.
+//
+// Lambda responses are not streamed, so r is read in full before responding.
+func (c *Context) Stream(statusCode int, contentType string, r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return c.Blob(statusCode, contentType, b)
+}
+
 type HandlerFunc func(c *Context) error
 
 func (hf HandlerFunc) Start() {
@@ -87,9 +151,11 @@ func (hf HandlerFunc) ToLambdaHandler() func(
 		c := &Context{
 			Context: ctx,
 			Request: request,
+			forms:   &formSet{},
 		}
 
 		err := hf(c)
+		c.removeForms()
 		if err != nil {
 			// catch any unhandled errors and return default error
 			// if error handler middleware is on, no errors will return here
@@ -127,6 +193,13 @@ func (hf HandlerFunc) ToHttpHandler(
 			panic(err)
 		}
 
+		// simulate API Gateway binary media types, by encoding any non UTF-8 body
+		isBase64Encoded := !utf8.Valid(body)
+		requestBody := string(body)
+		if isBase64Encoded {
+			requestBody = base64.StdEncoding.EncodeToString(body)
+		}
+
 		proxyResponse, err := hf.ToLambdaHandler()(r.Context(), events.APIGatewayProxyRequest{
 			Resource:                        resourcePathPattern,
 			Path:                            r.URL.Path,
@@ -137,7 +210,8 @@ func (hf HandlerFunc) ToHttpHandler(
 			MultiValueQueryStringParameters: r.URL.Query(),
 			PathParameters:                  parsePathParams(resourcePathPattern, r.URL.Path),
 			StageVariables:                  stageVariables,
-			Body:                            string(body),
+			Body:                            requestBody,
+			IsBase64Encoded:                 isBase64Encoded,
 		})
 
 		if err != nil {
@@ -190,6 +264,68 @@ func writeResponse(w http.ResponseWriter, proxyResponse events.APIGatewayProxyRe
 		}
 	}
 
+	body := []byte(proxyResponse.Body)
+	if proxyResponse.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(proxyResponse.Body)
+		if err != nil {
+			// API Gateway fails to decode the response in the same way
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`error`))
+			return
+		}
+		body = decoded
+	}
+
 	w.WriteHeader(proxyResponse.StatusCode)
-	_, _ = w.Write([]byte(proxyResponse.Body))
+	_, _ = w.Write(body)
+}
+
+// removeForms removes the temporary files of the multipart forms bound by the
+// handler, so that they do not fill /tmp of warm execution environments.
+func (c *Context) removeForms() {
+	if c.forms != nil {
+		c.forms.removeAll()
+	}
+}
+
+// formSet is the multipart forms bound by a handler. It is shared by the clones of
+// the context made by TimeoutMiddleware, so forms bound by a handler which is
+// still running after a timeout are also removed.
+type formSet struct {
+	mu      sync.Mutex
+	forms   []*multipart.Form
+	removed bool
+}
+
+// add a bound form, removing its files immediately if the handler has already
+// returned.
+func (fs *formSet) add(form *multipart.Form) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.removed {
+		_ = form.RemoveAll()
+		return
+	}
+	fs.forms = append(fs.forms, form)
+}
+
+func (fs *formSet) removeAll() {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, form := range fs.forms {
+		_ = form.RemoveAll()
+	}
+	fs.forms = nil
+	fs.removed = true
+}
+
+// clone returns a copy of the context which shares no response maps with c, so
+// that a handler left running in the background after a timeout does not race
+// with the response written by earlier middleware.
+func (c *Context) clone() Context {
+	tc := *c
+	tc.Request.PathParameters = clone.Map(c.Request.PathParameters)
+	tc.Response.Headers = clone.Map(c.Response.Headers)
+	tc.Response.MultiValueHeaders = clone.MultiValueMap(c.Response.MultiValueHeaders)
+	return tc
 }
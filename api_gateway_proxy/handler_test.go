@@ -1,10 +1,13 @@
 package api_gateway_proxy
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"errors"
 	"net/http"
 	"testing"
+	"testing/iotest"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/steinfletcher/apitest"
@@ -157,6 +160,61 @@ func TestAPIGatewayProxyContext_Bind_Success(t *testing.T) {
 	assert.Equal(t, "hello", data.Message)
 }
 
+func TestAPIGatewayProxyContext_Body(t *testing.T) {
+	c := &Context{
+		Request: events.APIGatewayProxyRequest{
+			Body: `plain body`,
+		},
+	}
+
+	body, err := c.Body()
+
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("plain body"), body)
+}
+
+func TestAPIGatewayProxyContext_Body_Base64Encoded(t *testing.T) {
+	c := &Context{
+		Request: events.APIGatewayProxyRequest{
+			Body:            base64.StdEncoding.EncodeToString([]byte{0xff, 0x00, 0xfe}),
+			IsBase64Encoded: true,
+		},
+	}
+
+	body, err := c.Body()
+
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0xff, 0x00, 0xfe}, body)
+}
+
+func TestAPIGatewayProxyContext_Body_InvalidBase64(t *testing.T) {
+	c := &Context{
+		Request: events.APIGatewayProxyRequest{
+			Body:            `not base64!`,
+			IsBase64Encoded: true,
+		},
+	}
+
+	_, err := c.Body()
+
+	assert.Error(t, err)
+}
+
+func TestAPIGatewayProxyContext_Bind_Base64Encoded(t *testing.T) {
+	c := &Context{
+		Request: events.APIGatewayProxyRequest{
+			Body:            base64.StdEncoding.EncodeToString([]byte(`{"message": "hello"}`)),
+			IsBase64Encoded: true,
+		},
+	}
+
+	var data requestData
+	err := c.Bind(&data)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", data.Message)
+}
+
 func TestAPIGatewayProxyContext_Bind_InvalidJSON(t *testing.T) {
 	c := &Context{
 		Request: events.APIGatewayProxyRequest{
@@ -214,6 +272,49 @@ func TestAPIGatewayProxyContext_JSON_WithoutBody(t *testing.T) {
 	assert.Equal(t, ``, c.Response.Body)
 }
 
+func TestAPIGatewayProxyContext_Blob(t *testing.T) {
+	c := &Context{}
+
+	err := c.Blob(http.StatusOK, "image/png", []byte{0x89, 0x50, 0x4e, 0x47})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, c.Response.StatusCode)
+	assert.Equal(t, "image/png", c.Response.Headers["Content-Type"])
+	assert.True(t, c.Response.IsBase64Encoded)
+	assert.Equal(t, "iVBORw==", c.Response.Body)
+}
+
+func TestAPIGatewayProxyContext_Stream(t *testing.T) {
+	c := &Context{}
+
+	err := c.Stream(http.StatusOK, "application/pdf", bytes.NewReader([]byte("%PDF-1.4")))
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, c.Response.StatusCode)
+	assert.Equal(t, "application/pdf", c.Response.Headers["Content-Type"])
+	assert.True(t, c.Response.IsBase64Encoded)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("%PDF-1.4")), c.Response.Body)
+}
+
+func TestAPIGatewayProxyContext_Stream_ReadError(t *testing.T) {
+	c := &Context{}
+
+	err := c.Stream(http.StatusOK, "application/pdf", iotest.ErrReader(assert.AnError))
+
+	assert.Equal(t, assert.AnError, err)
+}
+
+func TestAPIGatewayProxyContext_String_AfterBlob(t *testing.T) {
+	c := &Context{}
+
+	_ = c.Blob(http.StatusOK, "image/png", []byte{0x89})
+	err := c.String(http.StatusOK, "all good")
+
+	assert.Nil(t, err)
+	assert.False(t, c.Response.IsBase64Encoded)
+	assert.Equal(t, "all good", c.Response.Body)
+}
+
 // test HTTP handler function using steinfletcher/apitest
 func TestHandlerFunc_ToHttpHandler(t *testing.T) {
 	h := func(c *Context) error {
@@ -252,6 +353,30 @@ func TestHandlerFunc_ToHttpHandler(t *testing.T) {
 		End()
 }
 
+func TestHandlerFunc_ToHttpHandler_Binary(t *testing.T) {
+	image := []byte{0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a, 0xff, 0x00}
+
+	h := func(c *Context) error {
+		assert.True(t, c.Request.IsBase64Encoded)
+		body, err := c.Body()
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, image, body)
+		return c.Blob(http.StatusOK, "image/png", body)
+	}
+
+	apitest.New().
+		Handler(HandlerFunc(h).ToHttpHandler("/images", nil)).
+		Post("/images").
+		Body(string(image)).
+		Expect(t).
+		Status(http.StatusOK).
+		Header("Content-Type", "image/png").
+		Body(string(image)).
+		End()
+}
+
 type requestData struct {
 	Message string `json:"message"`
 }
@@ -0,0 +1,157 @@
+package api_gateway_proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type proxyRequestContextKey struct{}
+
+// ProxyRequestFromContext returns the API Gateway proxy request from the context
+// of an *http.Request served by a handler func created with FromHTTPHandler. This
+// gives http.Handlers access to request details such as the authorizer context.
+func ProxyRequestFromContext(c context.Context) (events.APIGatewayProxyRequest, bool) {
+	if c == nil {
+		return events.APIGatewayProxyRequest{}, false
+	}
+	request, ok := c.Value(proxyRequestContextKey{}).(events.APIGatewayProxyRequest)
+	return request, ok
+}
+
+// FromHTTPHandler turns a go http.Handler into a Lambdah handler function, allowing
+// existing http.Handlers, such as chi or gorilla routers, to be run on API Gateway
+// using Lambdah middleware. It is the reverse of HandlerFunc.ToHttpHandler.
+//
+// The API Gateway proxy request, including multi value headers and query string
+// parameters, is converted into an *http.Request with the context c.Context. Base64
+// encoded request bodies are decoded. The API Gateway proxy request is available
+// to the http.Handler by calling ProxyRequestFromContext(r.Context()).
+//
+// The response written by the http.Handler is captured into c.Response. Response
+// bodies which are not valid UTF-8 are base64 encoded.
+func FromHTTPHandler(handler http.Handler) HandlerFunc {
+	return func(c *Context) error {
+		r, err := newHTTPRequest(c)
+		if err != nil {
+			return err
+		}
+
+		w := newResponseWriter()
+		handler.ServeHTTP(w, r)
+
+		if c.Response.MultiValueHeaders == nil {
+			c.Response.MultiValueHeaders = make(map[string][]string)
+		}
+		for k, vs := range w.header {
+			c.Response.MultiValueHeaders[k] = append(c.Response.MultiValueHeaders[k], vs...)
+		}
+
+		c.Response.StatusCode = w.statusCode
+		if utf8.Valid(w.body.Bytes()) {
+			c.Response.Body = w.body.String()
+			c.Response.IsBase64Encoded = false
+		} else {
+			c.Response.Body = base64.StdEncoding.EncodeToString(w.body.Bytes())
+			c.Response.IsBase64Encoded = true
+		}
+
+		return nil
+	}
+}
+
+func newHTTPRequest(c *Context) (*http.Request, error) {
+	body, err := c.Body()
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	for k, vs := range c.Request.MultiValueQueryStringParameters {
+		for _, v := range vs {
+			query.Add(k, v)
+		}
+	}
+	for k, v := range c.Request.QueryStringParameters {
+		if _, ok := query[k]; !ok {
+			query.Set(k, v)
+		}
+	}
+
+	u := &url.URL{
+		Path:     c.Request.Path,
+		RawQuery: query.Encode(),
+	}
+
+	ctx := c.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = context.WithValue(ctx, proxyRequestContextKey{}, c.Request)
+
+	r, err := http.NewRequestWithContext(ctx, c.Request.HTTPMethod, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	for k, vs := range c.Request.MultiValueHeaders {
+		for _, v := range vs {
+			r.Header.Add(k, v)
+		}
+	}
+	for k, v := range c.Request.Headers {
+		if r.Header.Get(k) == "" {
+			r.Header.Set(k, v)
+		}
+	}
+
+	r.Host = r.Header.Get("Host")
+	r.RemoteAddr = c.Request.RequestContext.Identity.SourceIP
+	r.RequestURI = u.RequestURI()
+
+	return r, nil
+}
+
+// responseWriter captures the response written by an http.Handler
+type responseWriter struct {
+	header      http.Header
+	body        *bytes.Buffer
+	statusCode  int
+	wroteHeader bool
+}
+
+func newResponseWriter() *responseWriter {
+	return &responseWriter{
+		header:     make(http.Header),
+		body:       &bytes.Buffer{},
+		statusCode: http.StatusOK,
+	}
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		// detect the content type in the same way as net/http
+		if w.header.Get("Content-Type") == "" {
+			w.header.Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.body.Write(b)
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.statusCode = statusCode
+	w.wroteHeader = true
+}
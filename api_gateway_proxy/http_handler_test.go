@@ -0,0 +1,150 @@
+package api_gateway_proxy
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+type testContextKey struct{}
+
+func TestFromHTTPHandler_Success(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/books", func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.Nil(t, err)
+
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, `{"title":"Dune"}`, string(body))
+		assert.Equal(t, []string{"1", "2"}, r.URL.Query()["page"])
+		assert.Equal(t, "asc", r.URL.Query().Get("sort"))
+		assert.Equal(t, []string{"val1", "val2"}, r.Header.Values("Multi-Header"))
+		assert.Equal(t, "single", r.Header.Get("Single-Header"))
+		assert.Equal(t, "example.com", r.Host)
+		assert.Equal(t, "10.0.0.1", r.RemoteAddr)
+		assert.Equal(t, "test-value", r.Context().Value(testContextKey{}))
+
+		proxyRequest, ok := ProxyRequestFromContext(r.Context())
+		assert.True(t, ok)
+		assert.Equal(t, "request-1", proxyRequest.RequestContext.RequestID)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Add("Test-Header", "valueOne")
+		w.Header().Add("Test-Header", "valueTwo")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"1"}`))
+	})
+
+	awsHandler := FromHTTPHandler(mux).ToLambdaHandler()
+	ctx := context.WithValue(context.Background(), testContextKey{}, "test-value")
+	res, err := awsHandler(ctx, events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/books",
+		Headers: map[string]string{
+			"Host":          "example.com",
+			"Single-Header": "single",
+			"Multi-Header":  "val1",
+		},
+		MultiValueHeaders: map[string][]string{
+			"Multi-Header": {"val1", "val2"},
+		},
+		QueryStringParameters:           map[string]string{"sort": "asc", "page": "1"},
+		MultiValueQueryStringParameters: map[string][]string{"page": {"1", "2"}},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			RequestID: "request-1",
+			Identity:  events.APIGatewayRequestIdentity{SourceIP: "10.0.0.1"},
+		},
+		Body:            base64.StdEncoding.EncodeToString([]byte(`{"title":"Dune"}`)),
+		IsBase64Encoded: true,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 201, res.StatusCode)
+	assert.Equal(t, `{"id":"1"}`, res.Body)
+	assert.False(t, res.IsBase64Encoded)
+	assert.Equal(t, []string{"application/json"}, res.MultiValueHeaders["Content-Type"])
+	assert.Equal(t, []string{"valueOne", "valueTwo"}, res.MultiValueHeaders["Test-Header"])
+}
+
+func TestFromHTTPHandler_DefaultStatusAndContentType(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html></html>"))
+	})
+
+	res, err := FromHTTPHandler(h).ToLambdaHandler()(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Path:       "/",
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "<html></html>", res.Body)
+	assert.Equal(t, []string{"text/html; charset=utf-8"}, res.MultiValueHeaders["Content-Type"])
+}
+
+func TestFromHTTPHandler_BinaryResponse(t *testing.T) {
+	binary := []byte{0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a, 0xff}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(binary)
+	})
+
+	res, err := FromHTTPHandler(h).ToLambdaHandler()(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Path:       "/image.png",
+	})
+
+	assert.Nil(t, err)
+	assert.True(t, res.IsBase64Encoded)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(binary), res.Body)
+}
+
+func TestFromHTTPHandler_InvalidBase64Body(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be called")
+	})
+
+	res, err := FromHTTPHandler(h).
+		Middleware(ErrorHandlerMiddleware()).
+		ToLambdaHandler()(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:      http.MethodPost,
+		Path:            "/",
+		Body:            "not base64!",
+		IsBase64Encoded: true,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 500, res.StatusCode)
+}
+
+func TestFromHTTPHandler_WithMiddleware(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	res, err := FromHTTPHandler(h).
+		Middleware(CorrelationIDMiddleware()).
+		ToLambdaHandler()(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Path:       "/",
+		Headers:    map[string]string{"Correlation-Id": "123abc"},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", res.Body)
+	assert.Equal(t, "123abc", res.Headers["Correlation-Id"])
+}
+
+func TestProxyRequestFromContext(t *testing.T) {
+	// nil context
+	_, ok := ProxyRequestFromContext(nil)
+	assert.False(t, ok)
+	// without request
+	_, ok = ProxyRequestFromContext(context.Background())
+	assert.False(t, ok)
+}
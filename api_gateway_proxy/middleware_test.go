@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
+	"github.com/webbgeorge/lambdah"
 	"github.com/webbgeorge/lambdah/log"
+	"github.com/webbgeorge/lambdah/metrics"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/stretchr/testify/assert"
@@ -97,6 +101,7 @@ func TestErrorHandlerMiddleware_CustomError(t *testing.T) {
 
 func TestCorrelationIDMiddleware_CorrelationIDProvidedInRequest(t *testing.T) {
 	c := &Context{
+		Context: context.Background(),
 		Request: events.APIGatewayProxyRequest{
 			Headers:           map[string]string{"Correlation-Id": "123abc"},
 			MultiValueHeaders: map[string][]string{"Correlation-Id": {"123abc"}},
@@ -119,7 +124,7 @@ func TestCorrelationIDMiddleware_CorrelationIDProvidedInRequest(t *testing.T) {
 }
 
 func TestCorrelationIDMiddleware_CorrelationIDNotProvidedInRequest(t *testing.T) {
-	c := &Context{}
+	c := &Context{Context: context.Background()}
 	handlerCalled := false
 	h := func(c *Context) error {
 		handlerCalled = true
@@ -143,7 +148,7 @@ func TestLoggerMiddleware(t *testing.T) {
 			ResourcePath: "/path/{pathID}",
 		},
 	}}
-	c.Context = log.WithCorrelationID(c.Context, log.NewCorrelationID())
+	c.Context = log.WithCorrelationID(context.Background(), log.NewCorrelationID())
 	handlerCalled := false
 	h := func(c *Context) error {
 		handlerCalled = true
@@ -169,3 +174,226 @@ func TestLoggerMiddleware(t *testing.T) {
 	assert.Equal(t, float64(200), line["res_status"])
 	assert.Equal(t, "Response with status code 200", line["message"])
 }
+
+func TestErrorHandlerMiddleware_ValidationErrors(t *testing.T) {
+	c := &Context{}
+	h := func(c *Context) error {
+		var data struct {
+			Name  string `json:"name" validate:"required"`
+			Count int    `json:"count" validate:"max=10"`
+		}
+		c.Request.Body = `{"count": 11}`
+		return c.Bind(&data)
+	}
+
+	mw := ErrorHandlerMiddleware()
+	h = mw(h)
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 422, c.Response.StatusCode)
+	assert.Equal(t, "application/json", c.Response.Headers["Content-Type"])
+	assert.JSONEq(t, `{
+		"message": "Validation failed",
+		"details": [
+			{"field": "name", "rule": "required", "message": "is required"},
+			{"field": "count", "rule": "max", "param": "10", "message": "must be at most 10"}
+		]
+	}`, c.Response.Body)
+}
+
+func TestErrorHandlerMiddleware_WrappedError(t *testing.T) {
+	c := &Context{}
+	h := func(c *Context) error {
+		return fmt.Errorf("finding book: %w", Error{StatusCode: http.StatusNotFound, Message: "Book not found"})
+	}
+
+	mw := ErrorHandlerMiddleware()
+	h = mw(h)
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 404, c.Response.StatusCode)
+	assert.Equal(t, `{"message":"Book not found"}`, c.Response.Body)
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	logBuffer := &bytes.Buffer{}
+	goCtx := log.WithLogger(context.Background(), log.NewLogger(logBuffer, nil))
+	c := &Context{Context: goCtx}
+	h := func(c *Context) error {
+		panic("oh no")
+	}
+
+	mw := RecoverMiddleware()
+	h = mw(h)
+	err := h(c)
+
+	panicErr, ok := err.(lambdah.PanicError)
+	assert.True(t, ok)
+	assert.Equal(t, "oh no", panicErr.Value)
+	assert.Contains(t, logBuffer.String(), "Recovered from panic: oh no")
+	assert.Contains(t, logBuffer.String(), `"stack":"goroutine`)
+}
+
+func TestRecoverMiddleware_NoPanic(t *testing.T) {
+	c := &Context{Context: context.Background()}
+	h := func(c *Context) error {
+		return assert.AnError
+	}
+
+	mw := RecoverMiddleware()
+	h = mw(h)
+	err := h(c)
+
+	assert.Equal(t, assert.AnError, err)
+}
+
+func TestRecoverMiddleware_WithErrorHandler(t *testing.T) {
+	c := &Context{Context: context.Background()}
+	h := HandlerFunc(func(c *Context) error {
+		panic("oh no")
+	}).Middleware(ErrorHandlerMiddleware(), RecoverMiddleware())
+
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 500, c.Response.StatusCode)
+	assert.Equal(t, `{"message":"Internal server error"}`, c.Response.Body)
+}
+
+func TestTimeoutMiddleware_TimesOut(t *testing.T) {
+	goCtx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	c := &Context{Context: goCtx}
+	h := func(c *Context) error {
+		<-c.Context.Done()
+		return nil
+	}
+
+	mw := TimeoutMiddleware(150 * time.Millisecond)
+	h = mw(h)
+	err := h(c)
+
+	assert.IsType(t, lambdah.TimeoutError{}, err)
+	assert.Equal(t, goCtx, c.Context)
+}
+
+func TestTimeoutMiddleware_Completes(t *testing.T) {
+	goCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	c := &Context{Context: goCtx}
+	h := func(c *Context) error {
+		deadline, _ := c.Context.Deadline()
+		assert.WithinDuration(t, time.Now().Add(900*time.Millisecond), deadline, 50*time.Millisecond)
+		return assert.AnError
+	}
+
+	mw := TimeoutMiddleware(100 * time.Millisecond)
+	h = mw(h)
+	err := h(c)
+
+	assert.Equal(t, assert.AnError, err)
+}
+
+func TestTimeoutMiddleware_WithErrorHandler(t *testing.T) {
+	goCtx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	c := &Context{Context: goCtx}
+	h := HandlerFunc(func(c *Context) error {
+		<-c.Context.Done()
+		return c.String(http.StatusOK, "too late")
+	}).Middleware(ErrorHandlerMiddleware(), TimeoutMiddleware(150*time.Millisecond))
+
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 504, c.Response.StatusCode)
+	assert.Equal(t, `{"message":"Gateway timeout"}`, c.Response.Body)
+}
+
+func TestTimeoutMiddleware_HandlerWritesAfterTimeout(t *testing.T) {
+	goCtx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	c := &Context{Context: goCtx}
+	c.Response.Headers = map[string]string{"X-Correlation-Id": "cid-123"}
+	c.Response.MultiValueHeaders = map[string][]string{"Set-Cookie": {"a=1"}}
+	done := make(chan struct{})
+	h := HandlerFunc(func(c *Context) error {
+		defer close(done)
+		<-c.Context.Done()
+		c.Response.Headers["X-Too-Late"] = "true"
+		c.Response.MultiValueHeaders["Set-Cookie"] = append(c.Response.MultiValueHeaders["Set-Cookie"], "b=2")
+		return c.String(http.StatusOK, "too late")
+	}).Middleware(ErrorHandlerMiddleware(), TimeoutMiddleware(150*time.Millisecond))
+
+	err := h(c)
+	<-done
+
+	assert.Nil(t, err)
+	assert.Equal(t, 504, c.Response.StatusCode)
+	assert.Equal(t, map[string]string{"X-Correlation-Id": "cid-123", "Content-Type": "application/json"}, c.Response.Headers)
+	assert.Equal(t, map[string][]string{"Set-Cookie": {"a=1"}}, c.Response.MultiValueHeaders)
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	metricsBuffer := &bytes.Buffer{}
+	goCtx := log.WithCorrelationID(context.Background(), "cid-123")
+	mw := MetricsMiddleware(metricsBuffer, "MyApp", map[string]string{"Service": "books"})
+	h := mw(func(c *Context) error {
+		metrics.MetricsFromContext(c.Context).Add("BooksListed", 3, metrics.Count)
+		return c.JSON(http.StatusNotFound, nil)
+	})
+
+	c := &Context{Context: goCtx}
+	_ = h(c)
+
+	var doc map[string]interface{}
+	assert.Nil(t, json.Unmarshal(metricsBuffer.Bytes(), &doc))
+	assert.Equal(t, "books", doc["Service"])
+	assert.Equal(t, "cid-123", doc["correlation_id"])
+	assert.Equal(t, float64(3), doc["BooksListed"])
+	assert.Equal(t, float64(1), doc["Invocations"])
+	assert.Equal(t, float64(0), doc["Errors"])
+	assert.Equal(t, float64(1), doc["ColdStart"])
+	assert.Contains(t, doc, "Duration")
+	assert.Equal(t, float64(1), doc["4XXError"])
+	assert.Equal(t, float64(404), doc["StatusCode"])
+
+	metricsBuffer.Reset()
+	c = &Context{Context: goCtx}
+	_ = h(c)
+
+	doc = nil
+	assert.Nil(t, json.Unmarshal(metricsBuffer.Bytes(), &doc))
+	assert.NotContains(t, doc, "ColdStart")
+}
+
+func TestCorrelationIDMiddleware_TraceContext(t *testing.T) {
+	c := &Context{
+		Context: context.Background(),
+		Request: events.APIGatewayProxyRequest{
+			Headers: map[string]string{
+				"X-Amzn-Trace-Id": "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1",
+				"traceparent":     "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+				"tracestate":      "congo=t61rcWkgMzE",
+			},
+		},
+	}
+	var outgoing http.Header
+	h := func(c *Context) error {
+		outgoing = http.Header{}
+		log.InjectTraceHeaders(c.Context, outgoing)
+		return nil
+	}
+	buf := &bytes.Buffer{}
+
+	h = HandlerFunc(h).Middleware(CorrelationIDMiddleware(), LoggerMiddleware(buf, nil))
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", outgoing.Get("traceparent"))
+	assert.Equal(t, "congo=t61rcWkgMzE", outgoing.Get("tracestate"))
+	assert.Equal(t, "Root=1-4bf92f35-77b34da6a3ce929d0e0e4736;Parent=00f067aa0ba902b7;Sampled=1", outgoing.Get("X-Amzn-Trace-Id"))
+}
@@ -0,0 +1,138 @@
+package api_gateway_proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/webbgeorge/lambdah/log"
+)
+
+const problemContentType = "application/problem+json"
+
+// ProblemDetailer is implemented by errors which describe themselves as
+// RFC 7807 problem details, for use with ProblemErrorHandlerMiddleware.
+type ProblemDetailer interface {
+	ProblemDetails() ProblemDetails
+}
+
+// ProblemDetails is an RFC 7807 problem details object.
+//
+// Extensions are additional members, which are written alongside the standard
+// members in the JSON response. ProblemDetails is an error, so it can be returned
+// directly from a handler.
+type ProblemDetails struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+func (p ProblemDetails) Error() string {
+	return fmt.Sprintf("status: %d, title: %s, detail: %s", p.Status, p.Title, p.Detail)
+}
+
+func (p ProblemDetails) ProblemDetails() ProblemDetails {
+	return p
+}
+
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	members := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+	members["type"] = p.Type
+	members["title"] = p.Title
+	members["status"] = p.Status
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+	return json.Marshal(members)
+}
+
+// ProblemDetails converts the error into RFC 7807 problem details, with Message as
+// the detail, and Details, if set, as the `details` extension member.
+func (err Error) ProblemDetails() ProblemDetails {
+	p := ProblemDetails{
+		Status: err.StatusCode,
+		Detail: err.Message,
+	}
+	if err.Details != nil {
+		p.Extensions = map[string]interface{}{"details": err.Details}
+	}
+	return p
+}
+
+// Middleware to handle errors returned by handler, responding with RFC 7807
+// problem details with the content type `application/problem+json`. It is an
+// alternative to ErrorHandlerMiddleware, and should be used in its place.
+//
+// If returned error is, or wraps, an error implementing ProblemDetailer, such as
+// ProblemDetails or Error{}, then its problem details are returned. Otherwise
+// errors are handled in the same way as ErrorHandlerMiddleware, for example a
+// 500 response for unknown errors.
+//
+// Defaults are used for missing members: `about:blank` for type, the HTTP status
+// text for title, and the correlation ID, if the correlation ID middleware is in
+// use, for instance.
+//
+// If the logger middleware is also in use, this middleware will log a
+// message for any errors.
+func ProblemErrorHandlerMiddleware() Middleware {
+	return func(h HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			err := h(c)
+			if err != nil {
+				var detailer ProblemDetailer
+				if !errors.As(err, &detailer) {
+					detailer = toError(err)
+				}
+				problem := detailer.ProblemDetails()
+
+				if problem.Status == 0 {
+					problem.Status = http.StatusInternalServerError
+				}
+				if problem.Type == "" {
+					problem.Type = "about:blank"
+				}
+				if problem.Title == "" {
+					problem.Title = http.StatusText(problem.Status)
+				}
+				if problem.Instance == "" {
+					problem.Instance = log.CorrelationIDFromContext(c.Context)
+				}
+
+				logger := log.LoggerFromContext(c.Context)
+				if logger != nil {
+					logger.Error().
+						Str("error", err.Error()).
+						Msgf("Error: %s", problem.Error())
+				}
+				_ = c.problem(problem)
+			}
+			return nil
+		}
+	}
+}
+
+func (c *Context) problem(p ProblemDetails) error {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	if c.Response.Headers == nil {
+		c.Response.Headers = make(map[string]string)
+	}
+	c.Response.Headers["Content-Type"] = problemContentType
+	c.Response.Body = string(b)
+	c.Response.IsBase64Encoded = false
+	c.Response.StatusCode = p.Status
+	return nil
+}
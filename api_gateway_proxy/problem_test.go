@@ -0,0 +1,104 @@
+package api_gateway_proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/webbgeorge/lambdah/log"
+)
+
+func TestProblemErrorHandlerMiddleware_ProblemDetails(t *testing.T) {
+	c := &Context{Context: log.WithCorrelationID(context.Background(), "cid-123")}
+	h := func(c *Context) error {
+		return ProblemDetails{
+			Type:   "https://example.com/probs/out-of-credit",
+			Title:  "You do not have enough credit.",
+			Status: http.StatusForbidden,
+			Detail: "Your current balance is 30, but that costs 50.",
+			Extensions: map[string]interface{}{
+				"balance": 30,
+			},
+		}
+	}
+
+	h = ProblemErrorHandlerMiddleware()(h)
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 403, c.Response.StatusCode)
+	assert.Equal(t, "application/problem+json", c.Response.Headers["Content-Type"])
+	assert.JSONEq(t, `{
+		"type": "https://example.com/probs/out-of-credit",
+		"title": "You do not have enough credit.",
+		"status": 403,
+		"detail": "Your current balance is 30, but that costs 50.",
+		"instance": "cid-123",
+		"balance": 30
+	}`, c.Response.Body)
+}
+
+func TestProblemErrorHandlerMiddleware_WrappedError(t *testing.T) {
+	c := &Context{}
+	h := func(c *Context) error {
+		return fmt.Errorf("finding book: %w", Error{StatusCode: http.StatusNotFound, Message: "Book not found"})
+	}
+
+	h = ProblemErrorHandlerMiddleware()(h)
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 404, c.Response.StatusCode)
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Not Found",
+		"status": 404,
+		"detail": "Book not found"
+	}`, c.Response.Body)
+}
+
+func TestProblemErrorHandlerMiddleware_ValidationErrors(t *testing.T) {
+	c := &Context{}
+	h := func(c *Context) error {
+		var data struct {
+			Name string `json:"name" validate:"required"`
+		}
+		c.Request.Body = `{}`
+		return c.Bind(&data)
+	}
+
+	h = ProblemErrorHandlerMiddleware()(h)
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 422, c.Response.StatusCode)
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Unprocessable Entity",
+		"status": 422,
+		"detail": "Validation failed",
+		"details": [{"field": "name", "rule": "required", "message": "is required"}]
+	}`, c.Response.Body)
+}
+
+func TestProblemErrorHandlerMiddleware_UnhandledError(t *testing.T) {
+	c := &Context{}
+	h := func(c *Context) error {
+		return assert.AnError
+	}
+
+	h = ProblemErrorHandlerMiddleware()(h)
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 500, c.Response.StatusCode)
+	assert.Equal(t, "application/problem+json", c.Response.Headers["Content-Type"])
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Internal Server Error",
+		"status": 500,
+		"detail": "Internal server error"
+	}`, c.Response.Body)
+}
@@ -0,0 +1,201 @@
+package api_gateway_proxy
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/reverse"
+)
+
+// Router routes API Gateway proxy requests to handler funcs by HTTP method and
+// path pattern, allowing a single Lambda function to serve many endpoints.
+//
+// Path patterns use the same syntax as API Gateway resources, e.g.
+// `/books/{bookID}`, and the values of path variables are added to
+// c.Request.PathParameters. Regular expressions are also supported in path
+// variables, e.g. `/books/{bookID:[0-9]+}`.
+//
+// Requests are matched on c.Request.Resource first, so that routes match API
+// Gateway resources of the same pattern, falling back to c.Request.Path, so that
+// routes also match requests to greedy `{proxy+}` resources.
+//
+// If no route matches the request path a 404 Error is returned, and if routes
+// match the path but not the method, a 405 Error is returned with the `Allow`
+// header set on the response. The errors are rendered by the error handler
+// middleware in use, such as ErrorHandlerMiddleware or ProblemErrorHandlerMiddleware.
+type Router struct {
+	parent     *Router
+	prefix     string
+	middleware []Middleware
+	routes     *[]*route
+}
+
+type route struct {
+	method     string
+	pattern    string
+	path       *reverse.GorillaPath
+	handler    HandlerFunc
+	middleware []Middleware
+	router     *Router
+}
+
+func NewRouter() *Router {
+	return &Router{
+		routes: &[]*route{},
+	}
+}
+
+// Add middleware to all routes in the router or group, including routes
+// which have already been added.
+//
+// Middleware is called in the order it is given to this function, after any
+// middleware of parent groups, and before any route middleware.
+func (r *Router) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// Create a group of routes which share a path prefix and middleware.
+//
+// Middleware given is called after middleware from the parent router.
+func (r *Router) Group(prefix string, middleware ...Middleware) *Router {
+	return &Router{
+		parent:     r,
+		prefix:     r.prefix + prefix,
+		middleware: middleware,
+		routes:     r.routes,
+	}
+}
+
+// Add a route to the router, for the given HTTP method and path pattern.
+//
+// Middleware given is only called for this route. Panics if the path pattern is invalid.
+func (r *Router) Handle(method string, pattern string, h HandlerFunc, middleware ...Middleware) {
+	pattern = r.prefix + pattern
+	path, err := reverse.NewGorillaPath(pattern, false)
+	if err != nil {
+		panic(fmt.Sprintf("invalid route pattern '%s': %s", pattern, err.Error()))
+	}
+
+	*r.routes = append(*r.routes, &route{
+		method:     strings.ToUpper(method),
+		pattern:    pattern,
+		path:       path,
+		handler:    h,
+		middleware: middleware,
+		router:     r,
+	})
+}
+
+func (r *Router) GET(pattern string, h HandlerFunc, middleware ...Middleware) {
+	r.Handle(http.MethodGet, pattern, h, middleware...)
+}
+
+func (r *Router) POST(pattern string, h HandlerFunc, middleware ...Middleware) {
+	r.Handle(http.MethodPost, pattern, h, middleware...)
+}
+
+func (r *Router) PUT(pattern string, h HandlerFunc, middleware ...Middleware) {
+	r.Handle(http.MethodPut, pattern, h, middleware...)
+}
+
+func (r *Router) PATCH(pattern string, h HandlerFunc, middleware ...Middleware) {
+	r.Handle(http.MethodPatch, pattern, h, middleware...)
+}
+
+func (r *Router) DELETE(pattern string, h HandlerFunc, middleware ...Middleware) {
+	r.Handle(http.MethodDelete, pattern, h, middleware...)
+}
+
+func (r *Router) HEAD(pattern string, h HandlerFunc, middleware ...Middleware) {
+	r.Handle(http.MethodHead, pattern, h, middleware...)
+}
+
+func (r *Router) OPTIONS(pattern string, h HandlerFunc, middleware ...Middleware) {
+	r.Handle(http.MethodOptions, pattern, h, middleware...)
+}
+
+// Get the handler func of the router, which dispatches each request to the
+// matching route.
+//
+// The handler func can be used like any other, e.g. with Start(), ToHttpHandler(...)
+// and with middleware that should apply to every request, including unmatched ones.
+func (r *Router) HandlerFunc() HandlerFunc {
+	return func(c *Context) error {
+		matched := r.match(c.Request.Resource, c.Request.Path)
+		if len(matched) == 0 {
+			return Error{
+				StatusCode: http.StatusNotFound,
+				Message:    "Not found",
+			}
+		}
+
+		allowed := make([]string, 0)
+		for _, rt := range matched {
+			if rt.method == c.Request.HTTPMethod {
+				return rt.serve(c)
+			}
+			if !containsString(allowed, rt.method) {
+				allowed = append(allowed, rt.method)
+			}
+		}
+
+		if c.Response.Headers == nil {
+			c.Response.Headers = make(map[string]string)
+		}
+		sort.Strings(allowed)
+		c.Response.Headers["Allow"] = strings.Join(allowed, ", ")
+		return Error{
+			StatusCode: http.StatusMethodNotAllowed,
+			Message:    "Method not allowed",
+		}
+	}
+}
+
+// match returns the routes matching the resource if any, otherwise the routes
+// matching the path.
+func (r *Router) match(resource string, path string) []*route {
+	matched := make([]*route, 0)
+	for _, rt := range *r.routes {
+		if resource != "" && rt.pattern == resource {
+			matched = append(matched, rt)
+		}
+	}
+	if len(matched) > 0 {
+		return matched
+	}
+
+	for _, rt := range *r.routes {
+		if rt.path.MatchString(path) {
+			matched = append(matched, rt)
+		}
+	}
+	return matched
+}
+
+func (rt *route) serve(c *Context) error {
+	if c.Request.PathParameters == nil {
+		c.Request.PathParameters = make(map[string]string)
+	}
+	for name, values := range rt.path.Values(c.Request.Path) {
+		if len(values) > 0 {
+			c.Request.PathParameters[name] = values[0]
+		}
+	}
+
+	h := rt.handler.Middleware(rt.middleware...)
+	for router := rt.router; router != nil; router = router.parent {
+		h = h.Middleware(router.middleware...)
+	}
+	return h(c)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
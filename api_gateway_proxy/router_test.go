@@ -0,0 +1,214 @@
+package api_gateway_proxy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/steinfletcher/apitest"
+	jsonpath "github.com/steinfletcher/apitest-jsonpath"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter_MatchesMethodAndPath(t *testing.T) {
+	r := NewRouter()
+	r.GET("/books", func(c *Context) error {
+		return c.String(http.StatusOK, "list books")
+	})
+	r.GET("/books/{bookID}", func(c *Context) error {
+		return c.String(http.StatusOK, "get book "+c.Request.PathParameters["bookID"])
+	})
+	r.PUT("/books/{bookID}", func(c *Context) error {
+		return c.String(http.StatusOK, "put book "+c.Request.PathParameters["bookID"])
+	})
+
+	awsHandler := r.HandlerFunc().ToLambdaHandler()
+
+	res, err := awsHandler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Path:       "/books",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "list books", res.Body)
+
+	res, err = awsHandler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Path:       "/books/123",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "get book 123", res.Body)
+
+	res, err = awsHandler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPut,
+		Path:       "/books/123",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "put book 123", res.Body)
+}
+
+func TestRouter_MatchesResource(t *testing.T) {
+	r := NewRouter()
+	r.GET("/books/{bookID}", func(c *Context) error {
+		return c.String(http.StatusOK, "get book "+c.Request.PathParameters["bookID"])
+	})
+
+	res, err := r.HandlerFunc().ToLambdaHandler()(context.Background(), events.APIGatewayProxyRequest{
+		Resource:       "/books/{bookID}",
+		HTTPMethod:     http.MethodGet,
+		Path:           "/v1/books/123",
+		PathParameters: map[string]string{"bookID": "123"},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "get book 123", res.Body)
+}
+
+func TestRouter_NotFound(t *testing.T) {
+	r := NewRouter()
+	r.GET("/books", func(c *Context) error {
+		return c.String(http.StatusOK, "list books")
+	})
+
+	res, err := r.HandlerFunc().Middleware(ErrorHandlerMiddleware()).ToLambdaHandler()(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Path:       "/authors",
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 404, res.StatusCode)
+	assert.Equal(t, `{"message":"Not found"}`, res.Body)
+}
+
+func TestRouter_MethodNotAllowed(t *testing.T) {
+	r := NewRouter()
+	r.GET("/books/{bookID}", func(c *Context) error {
+		return nil
+	})
+	r.PUT("/books/{bookID}", func(c *Context) error {
+		return nil
+	})
+	r.DELETE("/books/{bookID}", func(c *Context) error {
+		return nil
+	})
+
+	res, err := r.HandlerFunc().Middleware(ErrorHandlerMiddleware()).ToLambdaHandler()(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/books/123",
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 405, res.StatusCode)
+	assert.Equal(t, "DELETE, GET, PUT", res.Headers["Allow"])
+	assert.Equal(t, `{"message":"Method not allowed"}`, res.Body)
+}
+
+func TestRouter_ProblemErrorHandler(t *testing.T) {
+	r := NewRouter()
+	r.GET("/books/{bookID}", func(c *Context) error {
+		return nil
+	})
+	awsHandler := r.HandlerFunc().Middleware(ProblemErrorHandlerMiddleware()).ToLambdaHandler()
+
+	res, err := awsHandler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Path:       "/authors",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 404, res.StatusCode)
+	assert.Equal(t, "application/problem+json", res.Headers["Content-Type"])
+	assert.JSONEq(t, `{"type":"about:blank","title":"Not Found","status":404,"detail":"Not found"}`, res.Body)
+
+	res, err = awsHandler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/books/123",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 405, res.StatusCode)
+	assert.Equal(t, "GET", res.Headers["Allow"])
+	assert.Equal(t, "application/problem+json", res.Headers["Content-Type"])
+	assert.JSONEq(t, `{"type":"about:blank","title":"Method Not Allowed","status":405,"detail":"Method not allowed"}`, res.Body)
+}
+
+func TestRouter_Middleware(t *testing.T) {
+	callOrder := make([]string, 0)
+	mw := func(name string) Middleware {
+		return func(h HandlerFunc) HandlerFunc {
+			return func(c *Context) error {
+				callOrder = append(callOrder, name)
+				return h(c)
+			}
+		}
+	}
+
+	r := NewRouter()
+	r.Use(mw("router"))
+	g := r.Group("/admin", mw("group"))
+	g.GET("/users", func(c *Context) error {
+		callOrder = append(callOrder, "handler")
+		return c.String(http.StatusOK, "users")
+	}, mw("route"))
+	r.GET("/books", func(c *Context) error {
+		callOrder = append(callOrder, "handler")
+		return c.String(http.StatusOK, "books")
+	})
+
+	awsHandler := r.HandlerFunc().ToLambdaHandler()
+
+	res, err := awsHandler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Path:       "/admin/users",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "users", res.Body)
+	assert.Equal(t, []string{"router", "group", "route", "handler"}, callOrder)
+
+	callOrder = make([]string, 0)
+	res, err = awsHandler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Path:       "/books",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "books", res.Body)
+	assert.Equal(t, []string{"router", "handler"}, callOrder)
+}
+
+func TestRouter_InvalidPattern(t *testing.T) {
+	r := NewRouter()
+	assert.Panics(t, func() {
+		r.GET("/books/{bookID", func(c *Context) error {
+			return nil
+		})
+	})
+}
+
+func TestRouter_ToHttpHandler(t *testing.T) {
+	r := NewRouter()
+	r.GET("/animal/{name}", func(c *Context) error {
+		return c.JSON(http.StatusOK, responseData{Status: c.Request.PathParameters["name"]})
+	})
+
+	httpHandler := r.HandlerFunc().
+		Middleware(ErrorHandlerMiddleware()).
+		ToHttpHandler("/{proxy+}", nil)
+
+	apitest.New().
+		Handler(httpHandler).
+		Get("/animal/dog").
+		Expect(t).
+		Status(http.StatusOK).
+		Assert(jsonpath.Equal(`$.status`, "dog")).
+		End()
+
+	apitest.New().
+		Handler(httpHandler).
+		Post("/animal/dog").
+		Expect(t).
+		Status(http.StatusMethodNotAllowed).
+		Header("Allow", "GET").
+		End()
+}
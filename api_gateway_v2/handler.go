@@ -0,0 +1,318 @@
+package api_gateway_v2
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/webbgeorge/lambdah"
+	"github.com/webbgeorge/lambdah/internal/clone"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gorilla/reverse"
+)
+
+type Context struct {
+	Context  context.Context
+	Request  events.APIGatewayV2HTTPRequest
+	Response events.APIGatewayV2HTTPResponse
+}
+
+func (c *Context) Bind(v interface{}) error {
+	body := []byte(c.Request.Body)
+	if c.Request.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(c.Request.Body)
+		if err != nil {
+			return err
+		}
+		body = decoded
+	}
+
+	err := json.Unmarshal(body, v)
+	if err != nil {
+		return err
+	}
+
+	return lambdah.Validate(v)
+}
+
+// JWTClaims returns the claims of the JWT authorizer, if the route uses one.
+func (c *Context) JWTClaims() map[string]string {
+	if c.Request.RequestContext.Authorizer == nil || c.Request.RequestContext.Authorizer.JWT == nil {
+		return map[string]string{}
+	}
+	return c.Request.RequestContext.Authorizer.JWT.Claims
+}
+
+func (c *Context) String(statusCode int, str string) error {
+	c.Response.Body = str
+	c.Response.StatusCode = statusCode
+	return nil
+}
+
+func (c *Context) JSON(statusCode int, body interface{}) error {
+	if body != nil {
+		if c.Response.Headers == nil {
+			c.Response.Headers = make(map[string]string)
+		}
+		c.Response.Headers["Content-Type"] = "application/json"
+
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		c.Response.Body = string(b)
+	}
+	c.Response.StatusCode = statusCode
+	return nil
+}
+
+type HandlerFunc func(c *Context) error
+
+func (hf HandlerFunc) Start() {
+	lambda.Start(hf.ToLambdaHandler())
+}
+
+// Apply middleware to the handler func.
+//
+// Middleware is called in the order it is given to this function.
+func (hf HandlerFunc) Middleware(middleware ...Middleware) HandlerFunc {
+	// apply middleware in reverse order
+	for i := len(middleware) - 1; i >= 0; i-- {
+		hf = middleware[i](hf)
+	}
+	return hf
+}
+
+// Get the AWS Lambda handler of the handler func.
+//
+// Useful if you need to call AWS lambda.Start(...) directly,
+// not required in most cases.
+func (hf HandlerFunc) ToLambdaHandler() func(
+	ctx context.Context,
+	request events.APIGatewayV2HTTPRequest,
+) (events.APIGatewayV2HTTPResponse, error) {
+	return func(
+		ctx context.Context,
+		request events.APIGatewayV2HTTPRequest,
+	) (events.APIGatewayV2HTTPResponse, error) {
+		c := &Context{
+			Context: ctx,
+			Request: request,
+		}
+
+		err := hf(c)
+		if err != nil {
+			// catch any unhandled errors and return default error
+			// if error handler middleware is on, no errors will return here
+			c.Response.StatusCode = http.StatusInternalServerError
+			c.Response.Body = "Internal server error"
+
+			if c.Response.Headers == nil {
+				c.Response.Headers = make(map[string]string)
+			}
+			c.Response.Headers["Content-Type"] = "text/html"
+
+			return c.Response, nil
+		}
+
+		return c.Response, nil
+	}
+}
+
+// ToHTTPHandler turns the Lambdah handler function into a go http.Handler.
+// This is useful for using go http testing tools with API gateway HTTP API handlers.
+//
+// routePathPattern is a path pattern used to simulate AWS API Gateway path matching
+// functionality for use within your tests. E.g. `/books/{bookID}` would be available from
+// the context as c.Request.PathParameters["bookID"]
+//
+// stageVariables are to simulate any stage variables you have set up on your api
+// gateway in aws
+//
+// If the request has an `Authorization: Bearer <token>` header containing a JWT, its
+// claims are made available as JWT authorizer claims. The token is not verified.
+func (hf HandlerFunc) ToHttpHandler(
+	routePathPattern string,
+	stageVariables map[string]string,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			panic(err)
+		}
+
+		// simulate API Gateway, which base64 encodes any non UTF-8 body
+		isBase64Encoded := !utf8.Valid(body)
+		requestBody := string(body)
+		if isBase64Encoded {
+			requestBody = base64.StdEncoding.EncodeToString(body)
+		}
+
+		request := events.APIGatewayV2HTTPRequest{
+			Version:               "2.0",
+			RouteKey:              fmt.Sprintf("%s %s", r.Method, routePathPattern),
+			RawPath:               r.URL.Path,
+			RawQueryString:        r.URL.RawQuery,
+			Cookies:               cookies(r),
+			Headers:               joinValues(r.Header, true),
+			QueryStringParameters: joinValues(r.URL.Query(), false),
+			PathParameters:        parsePathParams(routePathPattern, r.URL.Path),
+			StageVariables:        stageVariables,
+			Body:                  requestBody,
+			IsBase64Encoded:       isBase64Encoded,
+			RequestContext: events.APIGatewayV2HTTPRequestContext{
+				RouteKey: fmt.Sprintf("%s %s", r.Method, routePathPattern),
+				Stage:    "$default",
+				HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+					Method:    r.Method,
+					Path:      r.URL.Path,
+					Protocol:  r.Proto,
+					SourceIP:  r.RemoteAddr,
+					UserAgent: r.UserAgent(),
+				},
+				Authorizer: jwtAuthorizer(r.Header.Get("Authorization")),
+			},
+		}
+
+		res, err := hf.ToLambdaHandler()(r.Context(), request)
+		if err != nil {
+			// write a generic error, the same as API GW would if an error was returned by handler
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`error`))
+			return
+		}
+
+		writeResponse(w, res)
+	})
+}
+
+// joinValues joins multiple values with commas, as API Gateway HTTP APIs do.
+// Header names are lower cased, and the cookie header is omitted, as cookies are
+// sent in the request's Cookies.
+func joinValues(multiValueMap map[string][]string, isHeader bool) map[string]string {
+	singleValueMap := make(map[string]string)
+	for k, mv := range multiValueMap {
+		if isHeader {
+			k = strings.ToLower(k)
+			if k == "cookie" {
+				continue
+			}
+		}
+		if len(mv) > 0 {
+			singleValueMap[k] = strings.Join(mv, ",")
+		}
+	}
+	return singleValueMap
+}
+
+func cookies(r *http.Request) []string {
+	cookies := make([]string, 0)
+	for _, cookie := range r.Cookies() {
+		cookies = append(cookies, cookie.String())
+	}
+	return cookies
+}
+
+func jwtAuthorizer(authorization string) *events.APIGatewayV2HTTPRequestContextAuthorizerDescription {
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	parts := strings.Split(token, ".")
+	if token == authorization || len(parts) != 3 {
+		return nil
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil
+	}
+
+	var rawClaims map[string]interface{}
+	err = json.Unmarshal(payload, &rawClaims)
+	if err != nil {
+		return nil
+	}
+
+	claims := make(map[string]string)
+	for k, v := range rawClaims {
+		switch v := v.(type) {
+		case string:
+			claims[k] = v
+		default:
+			b, _ := json.Marshal(v)
+			claims[k] = string(b)
+		}
+	}
+
+	return &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+		JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{
+			Claims: claims,
+		},
+	}
+}
+
+func parsePathParams(pathPattern string, path string) map[string]string {
+	re, err := reverse.NewGorillaPath(pathPattern, false)
+	if err != nil {
+		return map[string]string{}
+	}
+
+	params := make(map[string]string)
+	if matches := re.MatchString(path); matches {
+		for name, values := range re.Values(path) {
+			if len(values) > 0 {
+				params[name] = values[0]
+			}
+		}
+	}
+
+	return params
+}
+
+func writeResponse(w http.ResponseWriter, res events.APIGatewayV2HTTPResponse) {
+	for k, v := range res.Headers {
+		w.Header().Add(k, v)
+	}
+
+	for k, vs := range res.MultiValueHeaders {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+
+	for _, cookie := range res.Cookies {
+		w.Header().Add("Set-Cookie", cookie)
+	}
+
+	statusCode := res.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	body := []byte(res.Body)
+	if res.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(res.Body)
+		if err == nil {
+			body = decoded
+		}
+	}
+
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(body)
+}
+
+// clone returns a copy of the context which shares no response maps with c, so
+// that a handler left running in the background after a timeout does not race
+// with the response written by earlier middleware.
+func (c *Context) clone() Context {
+	tc := *c
+	tc.Response.Headers = clone.Map(c.Response.Headers)
+	tc.Response.MultiValueHeaders = clone.MultiValueMap(c.Response.MultiValueHeaders)
+	tc.Response.Cookies = clone.Slice(c.Response.Cookies)
+	return tc
+}
@@ -0,0 +1,354 @@
+package api_gateway_v2
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/steinfletcher/apitest"
+	jsonpath "github.com/steinfletcher/apitest-jsonpath"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIGatewayV2Handler_Success(t *testing.T) {
+	h := func(c *Context) error {
+		var data requestData
+		err := c.Bind(&data)
+		if err != nil {
+			return err
+		}
+
+		assert.Equal(t, "hello", data.Message)
+
+		return c.JSON(http.StatusOK, responseData{Status: "all good"})
+	}
+
+	awsHandler := HandlerFunc(h).ToLambdaHandler()
+	res, err := awsHandler(
+		context.Background(),
+		events.APIGatewayV2HTTPRequest{
+			Body: `{"message": "hello"}`,
+		},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, `{"status":"all good"}`, res.Body)
+}
+
+func TestAPIGatewayV2Handler_NoErrorHandler(t *testing.T) {
+	h := func(c *Context) error {
+		return errors.New("an error happened")
+	}
+
+	awsHandler := HandlerFunc(h).ToLambdaHandler()
+	res, err := awsHandler(
+		context.Background(),
+		events.APIGatewayV2HTTPRequest{
+			Body: `{"message": "hello"}`,
+		},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 500, res.StatusCode)
+	assert.Equal(t, `Internal server error`, res.Body)
+}
+
+func TestAPIGatewayV2Handler_DefaultErrorHandler(t *testing.T) {
+	h := func(c *Context) error {
+		return errors.New("an error happened")
+	}
+
+	awsHandler := HandlerFunc(h).Middleware(ErrorHandlerMiddleware()).ToLambdaHandler()
+	res, err := awsHandler(
+		context.Background(),
+		events.APIGatewayV2HTTPRequest{
+			Body: `{"message": "hello"}`,
+		},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 500, res.StatusCode)
+	assert.Equal(t, `{"message":"Internal server error"}`, res.Body)
+}
+
+func TestAPIGatewayV2Handler_CustomErrorHandler(t *testing.T) {
+	h := func(c *Context) error {
+		return errors.New("an error happened")
+	}
+
+	customErrorHandlerMiddleware := func(h HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			err := h(c)
+			if err != nil {
+				type customError struct {
+					Error string `json:"error"`
+				}
+				_ = c.JSON(http.StatusBadRequest, customError{Error: err.Error()})
+			}
+			return nil
+		}
+	}
+
+	awsHandler := HandlerFunc(h).Middleware(customErrorHandlerMiddleware).ToLambdaHandler()
+
+	res, err := awsHandler(
+		context.Background(),
+		events.APIGatewayV2HTTPRequest{
+			Body: `{"message": "hello"}`,
+		},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 400, res.StatusCode)
+	assert.Equal(t, `{"error":"an error happened"}`, res.Body)
+}
+
+func TestAPIGatewayV2Handler_Middleware(t *testing.T) {
+	callOrder := make([]string, 0)
+
+	h := func(c *Context) error {
+		callOrder = append(callOrder, "handler")
+		return c.JSON(http.StatusOK, responseData{Status: c.Request.Headers["Test"]})
+	}
+
+	mw1 := func(h HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			callOrder = append(callOrder, "mw1 in")
+			err := h(c)
+			callOrder = append(callOrder, "mw1 out")
+			return err
+		}
+	}
+
+	mw2 := func(h HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			callOrder = append(callOrder, "mw2 in")
+			err := h(c)
+			callOrder = append(callOrder, "mw2 out")
+			return err
+		}
+	}
+
+	awsHandler := HandlerFunc(h).Middleware(mw1, mw2).ToLambdaHandler()
+	res, err := awsHandler(
+		context.Background(),
+		events.APIGatewayV2HTTPRequest{},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, []string{"mw1 in", "mw2 in", "handler", "mw2 out", "mw1 out"}, callOrder)
+}
+
+func TestAPIGatewayV2Context_Bind_Success(t *testing.T) {
+	c := &Context{
+		Request: events.APIGatewayV2HTTPRequest{
+			Body: `{"message": "hello"}`,
+		},
+	}
+
+	var data requestData
+	err := c.Bind(&data)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", data.Message)
+}
+
+func TestAPIGatewayV2Context_Bind_InvalidJSON(t *testing.T) {
+	c := &Context{
+		Request: events.APIGatewayV2HTTPRequest{
+			Body: `{"messag`,
+		},
+	}
+
+	var data requestData
+	err := c.Bind(&data)
+
+	assert.Error(t, err)
+}
+
+func TestAPIGatewayV2Context_Bind_WithValidationError(t *testing.T) {
+	c := &Context{
+		Request: events.APIGatewayV2HTTPRequest{
+			Body: `{}`,
+		},
+	}
+
+	var data requestData
+	err := c.Bind(&data)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "invalid message", err.Error())
+}
+
+func TestAPIGatewayV2Context_String(t *testing.T) {
+	c := &Context{}
+
+	err := c.String(http.StatusOK, "all good")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, c.Response.StatusCode)
+	assert.Equal(t, "all good", c.Response.Body)
+}
+
+func TestAPIGatewayV2Context_JSON_WithBody(t *testing.T) {
+	c := &Context{}
+
+	err := c.JSON(http.StatusOK, responseData{Status: "all good"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, c.Response.StatusCode)
+	assert.Equal(t, `{"status":"all good"}`, c.Response.Body)
+}
+
+func TestAPIGatewayV2Context_JSON_WithoutBody(t *testing.T) {
+	c := &Context{}
+
+	err := c.JSON(http.StatusNoContent, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 204, c.Response.StatusCode)
+	assert.Equal(t, ``, c.Response.Body)
+}
+
+func TestAPIGatewayV2Context_Bind_Base64Encoded(t *testing.T) {
+	c := &Context{
+		Request: events.APIGatewayV2HTTPRequest{
+			Body:            base64.StdEncoding.EncodeToString([]byte(`{"message": "hello"}`)),
+			IsBase64Encoded: true,
+		},
+	}
+
+	var data requestData
+	err := c.Bind(&data)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", data.Message)
+}
+
+func TestAPIGatewayV2Context_JWTClaims(t *testing.T) {
+	c := &Context{
+		Request: events.APIGatewayV2HTTPRequest{
+			RequestContext: events.APIGatewayV2HTTPRequestContext{
+				Authorizer: &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+					JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{
+						Claims: map[string]string{"sub": "user-1"},
+					},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, "user-1", c.JWTClaims()["sub"])
+}
+
+func TestAPIGatewayV2Context_JWTClaims_NoAuthorizer(t *testing.T) {
+	c := &Context{}
+
+	assert.Empty(t, c.JWTClaims())
+}
+
+// test HTTP handler function using steinfletcher/apitest
+func TestHandlerFunc_ToHttpHandler(t *testing.T) {
+	h := func(c *Context) error {
+		var data struct {
+			Age int
+		}
+		err := c.Bind(&data)
+		if err != nil {
+			return err
+		}
+
+		assert.Equal(t, 12, data.Age)
+		assert.Equal(t, "PUT /animal/{name}", c.Request.RouteKey)
+		assert.Equal(t, "/animal/dog", c.Request.RawPath)
+		assert.Equal(t, "PUT", c.Request.RequestContext.HTTP.Method)
+		assert.Equal(t, "dog", c.Request.PathParameters["name"])
+		assert.Equal(t, "val1,val2", c.Request.Headers["multi-header"])
+		assert.Equal(t, "b", c.Request.QueryStringParameters["a"])
+		assert.Equal(t, []string{"session=abc"}, c.Request.Cookies)
+		assert.Equal(t, "user-1", c.JWTClaims()["sub"])
+
+		c.Response.MultiValueHeaders = map[string][]string{
+			"Test-Header": {"valueOne", "valueTwo"},
+		}
+		c.Response.Cookies = []string{"session=def"}
+		return c.JSON(http.StatusOK, responseData{Status: "all good"})
+	}
+
+	httpHandler := HandlerFunc(h).
+		ToHttpHandler("/animal/{name}", nil)
+
+	// unsigned JWT with payload {"sub":"user-1"}
+	token := "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user-1"}`)) + "."
+
+	apitest.New().
+		Handler(httpHandler).
+		Put("/animal/dog").
+		Query("a", "b").
+		Header("Multi-Header", "val1").
+		Header("Multi-Header", "val2").
+		Header("Authorization", "Bearer "+token).
+		Cookie("session", "abc").
+		Body(`{"age": 12}`).
+		Expect(t).
+		Status(http.StatusOK).
+		Assert(jsonpath.Equal(`$.status`, "all good")).
+		HeaderPresent("Test-Header").
+		Header("Set-Cookie", "session=def").
+		End()
+}
+
+func TestHandlerFunc_ToHttpHandler_CookieQueryParameter(t *testing.T) {
+	h := func(c *Context) error {
+		assert.Equal(t, "x", c.Request.QueryStringParameters["cookie"])
+		assert.NotContains(t, c.Request.Headers, "cookie")
+		assert.Equal(t, []string{"session=abc"}, c.Request.Cookies)
+		return c.String(http.StatusOK, "ok")
+	}
+
+	apitest.New().
+		Handler(HandlerFunc(h).ToHttpHandler("/", nil)).
+		Get("/").
+		Query("cookie", "x").
+		Cookie("session", "abc").
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+}
+
+func TestHandlerFunc_ToHttpHandler_BinaryBody(t *testing.T) {
+	binary := []byte{0xff, 0xfe, 0x00, 0x01}
+	h := func(c *Context) error {
+		assert.True(t, c.Request.IsBase64Encoded)
+		assert.Equal(t, base64.StdEncoding.EncodeToString(binary), c.Request.Body)
+		return c.String(http.StatusOK, "ok")
+	}
+
+	apitest.New().
+		Handler(HandlerFunc(h).ToHttpHandler("/", nil)).
+		Post("/").
+		Body(string(binary)).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+}
+
+type requestData struct {
+	Message string `json:"message"`
+}
+
+func (d *requestData) Validate() error {
+	if d.Message == "" {
+		return errors.New("invalid message")
+	}
+	return nil
+}
+
+type responseData struct {
+	Status string `json:"status"`
+}
@@ -0,0 +1,263 @@
+package api_gateway_v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/webbgeorge/lambdah"
+	"github.com/webbgeorge/lambdah/internal/handlerlog"
+	"github.com/webbgeorge/lambdah/internal/timeout"
+	"github.com/webbgeorge/lambdah/log"
+	"github.com/webbgeorge/lambdah/metrics"
+)
+
+type Middleware func(h HandlerFunc) HandlerFunc
+
+// Middleware to handle errors returned by handler
+//
+// If returned error is, or wraps, an Error{}, then a custom JSON response is
+// returned in the response, with status Error{}.StatusCode and body
+// `{"message": "Error{}.Message"}`
+//
+// If returned error is, or wraps, lambdah.ValidationErrors, for example from Bind,
+// then a 422 response is returned with the failing fields in the body
+// `{"message": "Validation failed", "details": [{"field": "name", ...}]}`
+//
+// If returned error is, or wraps, lambdah.TimeoutError, from the timeout middleware,
+// then a 504 response is returned.
+//
+// If the logger middleware is also in use, this middleware will log a
+// message for any errors.
+//
+// If you wish to build a custom error handler you can use this pattern
+// and create a middleware and an Error type with your own structure.
+func ErrorHandlerMiddleware() Middleware {
+	return func(h HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			err := h(c)
+			if err != nil {
+				apiGatewayErr := toError(err)
+				logger := log.LoggerFromContext(c.Context)
+				if logger != nil {
+					logger.Error().
+						Str("error", err.Error()).
+						Msgf("Error: %s", apiGatewayErr.Error())
+				}
+				_ = c.JSON(apiGatewayErr.StatusCode, apiGatewayErr)
+			}
+			return nil
+		}
+	}
+}
+
+type Error struct {
+	StatusCode int         `json:"-"`
+	Message    string      `json:"message"`
+	Details    interface{} `json:"details,omitempty"`
+}
+
+func (err Error) Error() string {
+	return fmt.Sprintf("status: %d, message: %s", err.StatusCode, err.Message)
+}
+
+// toError finds the Error to respond with for err, using errors.As so that wrapped
+// errors are found.
+func toError(err error) Error {
+	var apiGatewayErr Error
+	if errors.As(err, &apiGatewayErr) {
+		return apiGatewayErr
+	}
+
+	var validationErrs lambdah.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return Error{
+			StatusCode: http.StatusUnprocessableEntity,
+			Message:    "Validation failed",
+			Details:    validationErrs,
+		}
+	}
+
+	var timeoutErr lambdah.TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return Error{
+			StatusCode: http.StatusGatewayTimeout,
+			Message:    "Gateway timeout",
+		}
+	}
+
+	return Error{
+		StatusCode: http.StatusInternalServerError,
+		Message:    "Internal server error",
+	}
+}
+
+// Middleware to get or attach a correlation ID to the request, useful for tracing
+// requests through distributed systems. The correlation ID is also returned in a
+// response header so it can be used by consumers. You can also access the
+// correlation ID directly in your handlers and middlewares by calling
+// log.CorrelationIDFromContext(c.Context)
+//
+// First looks for a Correlation ID provided in the request header `Correlation-Id`.
+// HTTP APIs lower case header names, so this is read from c.Request.Headers["correlation-id"].
+// If not present a new correlation ID will be created.
+//
+// The trace context is read from the W3C `traceparent` and `tracestate` headers,
+// or the `x-amzn-trace-id` header, and attached to c.Context for the logger.
+//
+// To ensure logs have correlation ID field, this middleware should be called first.
+func CorrelationIDMiddleware() Middleware {
+	return func(h HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			cid := c.Request.Headers["correlation-id"]
+			if cid == "" {
+				cid = log.NewCorrelationID()
+			}
+
+			if c.Response.Headers == nil {
+				c.Response.Headers = make(map[string]string)
+			}
+			c.Response.Headers["Correlation-Id"] = cid
+
+			c.Context = log.WithCorrelationID(c.Context, cid)
+
+			tc, ok := log.ExtractTraceContext(
+				c.Request.Headers["x-amzn-trace-id"],
+				c.Request.Headers[log.TraceparentHeader],
+				c.Request.Headers[log.TracestateHeader],
+			)
+			if ok {
+				c.Context = log.WithTraceContext(c.Context, tc)
+			}
+
+			return h(c)
+		}
+	}
+}
+
+// Middleware to configure a logger in the context.Context, which is then used by other
+// middleware (if available), including the error handler.
+//
+// The middleware logs on the response of each request, and also will include an
+// error log message if the error handler middleware is also used. In addition to
+// the default log messages, you can access the logger in your handlers/middleware
+// by calling log.LoggerFromContext(c.Context). The logger we use is
+// github.com/rs/zerolog
+//
+// w io.Writer              is the log output, for example os.Stdout
+// field map[string]string  is a list of key value fields to include in each log message
+//
+// To ensure that the response status code is correctly reported, this middleware
+// should be called before the error handler middleware.
+func LoggerMiddleware(w io.Writer, fields map[string]string) Middleware {
+	return func(h HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			logger := handlerlog.New(c.Context, w, "api_gateway_v2", fields, map[string]string{
+				"req_method": c.Request.RequestContext.HTTP.Method,
+				"req_path":   c.Request.RawPath,
+				"req_route":  c.Request.RouteKey,
+			})
+			c.Context = log.WithLogger(c.Context, logger)
+			err := h(c)
+
+			logger.Info().
+				Int("res_status", c.Response.StatusCode).
+				Msgf("Response with status code %d", c.Response.StatusCode)
+
+			return err
+		}
+	}
+}
+
+// Middleware to recover from panics in the handler, which would otherwise crash
+// the Lambda runtime process, see lambdah.RecoverAndLog.
+//
+// This middleware should be called after the logger and error handler middlewares,
+// so that the panic is logged and the error handler responds with a 500 status.
+func RecoverMiddleware() Middleware {
+	return func(h HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			return lambdah.RecoverAndLog(c.Context, func() error {
+				return h(c)
+			})
+		}
+	}
+}
+
+// Middleware to stop the handler gracefully before the Lambda deadline.
+//
+// The handler is given a c.Context which is cancelled buffer before the Lambda
+// deadline. If the handler has not returned by then, a lambdah.TimeoutError is
+// returned and the handler is left to finish in the background, without changing
+// the Context seen by earlier middleware.
+//
+// If used with the error handler middleware, a 504 response is returned on timeout.
+// This middleware should be called after the logger and error handler middlewares.
+func TimeoutMiddleware(buffer time.Duration) Middleware {
+	return func(h HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			tc := c.clone()
+			returned, err := timeout.Run(c.Context, buffer, func(ctx context.Context) error {
+				tc.Context = ctx
+				return h(&tc)
+			})
+			if returned {
+				ctx := c.Context
+				*c = tc
+				c.Context = ctx
+			}
+			return err
+		}
+	}
+}
+
+// Middleware to record CloudWatch metrics for each request, written to w in the
+// CloudWatch Embedded Metric Format (EMF). No calls are made to the CloudWatch API,
+// CloudWatch extracts the metrics when w is the Lambda function's stdout.
+//
+// The `Invocations`, `Duration`, `Errors` and `ColdStart` metrics are recorded. The `StatusCode` of the
+// response is set as a property, and `4XXError` and `5XXError` metrics are added.
+// You can add your own metrics in your handlers/middleware by calling
+// metrics.MetricsFromContext(c.Context), which are flushed after the handler returns.
+//
+// w io.Writer                   is the metrics output, for example os.Stdout
+// namespace string              is the CloudWatch metric namespace
+// dimensions map[string]string  is a list of dimensions to add to each metric
+//
+// To ensure the response status code is correctly reported, this middleware
+// should be called before the error handler middleware.
+func MetricsMiddleware(w io.Writer, namespace string, dimensions map[string]string) Middleware {
+	var warm int32
+	return func(h HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			m := metrics.New(w, namespace)
+			for k, v := range dimensions {
+				m.AddDimension(k, v)
+			}
+			if cid := log.CorrelationIDFromContext(c.Context); cid != "" {
+				m.SetProperty("correlation_id", cid)
+			}
+			c.Context = metrics.WithMetrics(c.Context, m)
+
+			start := time.Now()
+			err := h(c)
+
+			m.RecordInvocation(time.Since(start), err, atomic.CompareAndSwapInt32(&warm, 0, 1))
+			m.RecordStatusCode(c.Response.StatusCode)
+			flushErr := m.Flush()
+			if flushErr != nil {
+				logger := log.LoggerFromContext(c.Context)
+				if logger != nil {
+					logger.Error().
+						Str("error", flushErr.Error()).
+						Msg("Error flushing metrics")
+				}
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package api_gateway_v2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/webbgeorge/lambdah"
+	"github.com/webbgeorge/lambdah/log"
+	"github.com/webbgeorge/lambdah/metrics"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorHandlerMiddleware_NoError(t *testing.T) {
+	c := &Context{}
+	handlerCalled := false
+	h := func(c *Context) error {
+		handlerCalled = true
+		return c.JSON(http.StatusOK, responseData{Status: "all good"})
+	}
+
+	mw := ErrorHandlerMiddleware()
+	h = mw(h)
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.True(t, handlerCalled)
+	assert.Equal(t, c.Response.StatusCode, 200)
+	assert.Equal(t, c.Response.Headers["Content-Type"], "application/json")
+	assert.Equal(t, c.Response.Body, `{"status":"all good"}`)
+}
+
+func TestErrorHandlerMiddleware_UnhandledError(t *testing.T) {
+	c := &Context{}
+	handlerCalled := false
+	h := func(c *Context) error {
+		handlerCalled = true
+		return assert.AnError
+	}
+
+	mw := ErrorHandlerMiddleware()
+	h = mw(h)
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.True(t, handlerCalled)
+	assert.Equal(t, c.Response.StatusCode, 500)
+	assert.Equal(t, c.Response.Headers["Content-Type"], "application/json")
+	assert.Equal(t, c.Response.Body, `{"message":"Internal server error"}`)
+}
+
+func TestErrorHandlerMiddleware_ErrorWithLogger(t *testing.T) {
+	logBuffer := &bytes.Buffer{}
+	goCtx := log.WithLogger(context.Background(), log.NewLogger(logBuffer, nil))
+	c := &Context{Context: goCtx}
+	handlerCalled := false
+	h := func(c *Context) error {
+		handlerCalled = true
+		return assert.AnError
+	}
+
+	mw := ErrorHandlerMiddleware()
+	h = mw(h)
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.True(t, handlerCalled)
+	assert.Equal(t, c.Response.StatusCode, 500)
+	assert.Equal(t, c.Response.Headers["Content-Type"], "application/json")
+	assert.Equal(t, c.Response.Body, `{"message":"Internal server error"}`)
+	assert.Contains(t, logBuffer.String(), "Error: status: 500, message: Internal server error")
+}
+
+func TestErrorHandlerMiddleware_CustomError(t *testing.T) {
+	c := &Context{}
+	handlerCalled := false
+	h := func(c *Context) error {
+		handlerCalled = true
+		return Error{
+			StatusCode: 400,
+			Message:    "Bad request",
+		}
+	}
+
+	mw := ErrorHandlerMiddleware()
+	h = mw(h)
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.True(t, handlerCalled)
+	assert.Equal(t, c.Response.StatusCode, 400)
+	assert.Equal(t, c.Response.Headers["Content-Type"], "application/json")
+	assert.Equal(t, c.Response.Body, `{"message":"Bad request"}`)
+}
+
+func TestCorrelationIDMiddleware_CorrelationIDProvidedInRequest(t *testing.T) {
+	c := &Context{
+		Context: context.Background(),
+		Request: events.APIGatewayV2HTTPRequest{
+			Headers: map[string]string{"correlation-id": "123abc"},
+		},
+	}
+	handlerCalled := false
+	h := func(c *Context) error {
+		handlerCalled = true
+		assert.Equal(t, "123abc", log.CorrelationIDFromContext(c.Context))
+		return nil
+	}
+
+	mw := CorrelationIDMiddleware()
+	h = mw(h)
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.True(t, handlerCalled)
+}
+
+func TestCorrelationIDMiddleware_CorrelationIDNotProvidedInRequest(t *testing.T) {
+	c := &Context{Context: context.Background()}
+	handlerCalled := false
+	h := func(c *Context) error {
+		handlerCalled = true
+		assert.NotEmpty(t, log.CorrelationIDFromContext(c.Context))
+		return nil
+	}
+
+	mw := CorrelationIDMiddleware()
+	h = mw(h)
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.True(t, handlerCalled)
+}
+
+func TestLoggerMiddleware(t *testing.T) {
+	c := &Context{Request: events.APIGatewayV2HTTPRequest{
+		RouteKey: "PUT /path/{pathID}",
+		RawPath:  "/path/123",
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+				Method: "PUT",
+			},
+		},
+	}}
+	c.Context = log.WithCorrelationID(context.Background(), log.NewCorrelationID())
+	handlerCalled := false
+	h := func(c *Context) error {
+		handlerCalled = true
+		return c.JSON(http.StatusOK, nil)
+	}
+	buf := &bytes.Buffer{}
+
+	mw := LoggerMiddleware(buf, map[string]string{"one": "val1", "two": "val2"})
+	h = mw(h)
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.True(t, handlerCalled)
+
+	var line map[string]interface{}
+	err = json.Unmarshal(buf.Bytes(), &line)
+	assert.Nil(t, err)
+	assert.Equal(t, "info", line["level"])
+	assert.Equal(t, "PUT", line["req_method"])
+	assert.Equal(t, "/path/123", line["req_path"])
+	assert.Equal(t, "PUT /path/{pathID}", line["req_route"])
+	assert.NotEmpty(t, line["correlation_id"])
+	assert.Equal(t, float64(200), line["res_status"])
+	assert.Equal(t, "Response with status code 200", line["message"])
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	logBuffer := &bytes.Buffer{}
+	goCtx := log.WithLogger(context.Background(), log.NewLogger(logBuffer, nil))
+	c := &Context{Context: goCtx}
+	h := func(c *Context) error {
+		panic("oh no")
+	}
+
+	mw := RecoverMiddleware()
+	h = mw(h)
+	err := h(c)
+
+	panicErr, ok := err.(lambdah.PanicError)
+	assert.True(t, ok)
+	assert.Equal(t, "oh no", panicErr.Value)
+	assert.Contains(t, logBuffer.String(), "Recovered from panic: oh no")
+	assert.Contains(t, logBuffer.String(), `"stack":"goroutine`)
+}
+
+func TestRecoverMiddleware_NoPanic(t *testing.T) {
+	c := &Context{Context: context.Background()}
+	h := func(c *Context) error {
+		return assert.AnError
+	}
+
+	mw := RecoverMiddleware()
+	h = mw(h)
+	err := h(c)
+
+	assert.Equal(t, assert.AnError, err)
+}
+
+func TestRecoverMiddleware_WithErrorHandler(t *testing.T) {
+	c := &Context{Context: context.Background()}
+	h := HandlerFunc(func(c *Context) error {
+		panic("oh no")
+	}).Middleware(ErrorHandlerMiddleware(), RecoverMiddleware())
+
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 500, c.Response.StatusCode)
+	assert.Equal(t, `{"message":"Internal server error"}`, c.Response.Body)
+}
+
+func TestTimeoutMiddleware_TimesOut(t *testing.T) {
+	goCtx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	c := &Context{Context: goCtx}
+	h := func(c *Context) error {
+		<-c.Context.Done()
+		return nil
+	}
+
+	mw := TimeoutMiddleware(150 * time.Millisecond)
+	h = mw(h)
+	err := h(c)
+
+	assert.IsType(t, lambdah.TimeoutError{}, err)
+	assert.Equal(t, goCtx, c.Context)
+}
+
+func TestTimeoutMiddleware_Completes(t *testing.T) {
+	goCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	c := &Context{Context: goCtx}
+	h := func(c *Context) error {
+		deadline, _ := c.Context.Deadline()
+		assert.WithinDuration(t, time.Now().Add(900*time.Millisecond), deadline, 50*time.Millisecond)
+		return assert.AnError
+	}
+
+	mw := TimeoutMiddleware(100 * time.Millisecond)
+	h = mw(h)
+	err := h(c)
+
+	assert.Equal(t, assert.AnError, err)
+}
+
+func TestTimeoutMiddleware_WithErrorHandler(t *testing.T) {
+	goCtx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	c := &Context{Context: goCtx}
+	h := HandlerFunc(func(c *Context) error {
+		<-c.Context.Done()
+		return c.String(http.StatusOK, "too late")
+	}).Middleware(ErrorHandlerMiddleware(), TimeoutMiddleware(150*time.Millisecond))
+
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 504, c.Response.StatusCode)
+	assert.Equal(t, `{"message":"Gateway timeout"}`, c.Response.Body)
+}
+
+func TestTimeoutMiddleware_HandlerWritesAfterTimeout(t *testing.T) {
+	goCtx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	c := &Context{Context: goCtx}
+	c.Response.Headers = map[string]string{"X-Correlation-Id": "cid-123"}
+	c.Response.MultiValueHeaders = map[string][]string{"Set-Cookie": {"a=1"}}
+	done := make(chan struct{})
+	h := HandlerFunc(func(c *Context) error {
+		defer close(done)
+		<-c.Context.Done()
+		c.Response.Headers["X-Too-Late"] = "true"
+		c.Response.MultiValueHeaders["Set-Cookie"] = append(c.Response.MultiValueHeaders["Set-Cookie"], "b=2")
+		return c.String(http.StatusOK, "too late")
+	}).Middleware(ErrorHandlerMiddleware(), TimeoutMiddleware(150*time.Millisecond))
+
+	err := h(c)
+	<-done
+
+	assert.Nil(t, err)
+	assert.Equal(t, 504, c.Response.StatusCode)
+	assert.Equal(t, map[string]string{"X-Correlation-Id": "cid-123", "Content-Type": "application/json"}, c.Response.Headers)
+	assert.Equal(t, map[string][]string{"Set-Cookie": {"a=1"}}, c.Response.MultiValueHeaders)
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	metricsBuffer := &bytes.Buffer{}
+	goCtx := log.WithCorrelationID(context.Background(), "cid-123")
+	mw := MetricsMiddleware(metricsBuffer, "MyApp", map[string]string{"Service": "books"})
+	h := mw(func(c *Context) error {
+		metrics.MetricsFromContext(c.Context).Add("BooksListed", 3, metrics.Count)
+		return c.JSON(http.StatusNotFound, nil)
+	})
+
+	c := &Context{Context: goCtx}
+	_ = h(c)
+
+	var doc map[string]interface{}
+	assert.Nil(t, json.Unmarshal(metricsBuffer.Bytes(), &doc))
+	assert.Equal(t, "books", doc["Service"])
+	assert.Equal(t, "cid-123", doc["correlation_id"])
+	assert.Equal(t, float64(3), doc["BooksListed"])
+	assert.Equal(t, float64(1), doc["Invocations"])
+	assert.Equal(t, float64(0), doc["Errors"])
+	assert.Equal(t, float64(1), doc["ColdStart"])
+	assert.Contains(t, doc, "Duration")
+	assert.Equal(t, float64(1), doc["4XXError"])
+	assert.Equal(t, float64(404), doc["StatusCode"])
+
+	metricsBuffer.Reset()
+	c = &Context{Context: goCtx}
+	_ = h(c)
+
+	doc = nil
+	assert.Nil(t, json.Unmarshal(metricsBuffer.Bytes(), &doc))
+	assert.NotContains(t, doc, "ColdStart")
+}
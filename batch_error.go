@@ -0,0 +1,59 @@
+package lambdah
+
+import (
+	"errors"
+	"fmt"
+)
+
+// BatchError is returned by concurrent batch handlers when one or more records
+// in the batch fail to process. Errors are in the order of their records in the batch.
+type BatchError struct {
+	Errors []error
+}
+
+func (err BatchError) Error() string {
+	if len(err.Errors) == 0 {
+		return "batch error"
+	}
+	return fmt.Sprintf("%d record(s) failed in batch, first error: %s", len(err.Errors), err.Errors[0].Error())
+}
+
+// Is reports whether any of the errors matches target, so that errors.Is finds
+// errors in the batch on Go versions before 1.20, which do not support Unwrap() []error.
+func (err BatchError) Is(target error) bool {
+	for _, e := range err.Errors {
+		if errors.Is(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the errors which matches target, see Is.
+func (err BatchError) As(target interface{}) bool {
+	for _, e := range err.Errors {
+		if errors.As(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (err BatchError) Unwrap() []error {
+	return err.Errors
+}
+
+// NewBatchError returns a BatchError of the non-nil errors in errs, or nil if
+// there are none.
+func NewBatchError(errs []error) error {
+	var batchErr BatchError
+	for _, err := range errs {
+		if err != nil {
+			batchErr.Errors = append(batchErr.Errors, err)
+		}
+	}
+	if len(batchErr.Errors) == 0 {
+		return nil
+	}
+	return batchErr
+}
@@ -0,0 +1,50 @@
+package lambdah
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBatchError_NoErrors(t *testing.T) {
+	assert.Nil(t, NewBatchError(nil))
+	assert.Nil(t, NewBatchError([]error{nil, nil}))
+}
+
+func TestNewBatchError_WithErrors(t *testing.T) {
+	otherErr := errors.New("other error")
+
+	err := NewBatchError([]error{nil, assert.AnError, nil, otherErr})
+
+	var batchErr BatchError
+	assert.True(t, errors.As(err, &batchErr))
+	assert.Equal(t, []error{assert.AnError, otherErr}, batchErr.Errors)
+	assert.True(t, errors.Is(err, otherErr))
+	assert.Equal(t, "2 record(s) failed in batch, first error: assert.AnError general error for testing", err.Error())
+}
+
+func TestBatchError_Is(t *testing.T) {
+	otherErr := errors.New("other error")
+	err := BatchError{Errors: []error{assert.AnError, fmt.Errorf("wrapped: %w", otherErr)}}
+
+	assert.True(t, err.Is(assert.AnError))
+	assert.True(t, err.Is(otherErr))
+	assert.False(t, err.Is(errors.New("not in batch")))
+	assert.True(t, errors.Is(fmt.Errorf("handler: %w", err), otherErr))
+}
+
+func TestBatchError_As(t *testing.T) {
+	panicErr := PanicError{Value: "boom"}
+	err := BatchError{Errors: []error{assert.AnError, fmt.Errorf("wrapped: %w", panicErr)}}
+
+	var target PanicError
+	assert.True(t, err.As(&target))
+	assert.Equal(t, panicErr, target)
+	var timeoutErr TimeoutError
+	assert.False(t, err.As(&timeoutErr))
+	target = PanicError{}
+	assert.True(t, errors.As(fmt.Errorf("handler: %w", err), &target))
+	assert.Equal(t, panicErr, target)
+}
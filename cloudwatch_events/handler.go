@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 
 	"github.com/webbgeorge/lambdah"
+	"github.com/webbgeorge/lambdah/internal/clone"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -21,11 +22,7 @@ func (c *Context) Bind(v interface{}) error {
 		return err
 	}
 
-	if validatable, ok := v.(lambdah.Validatable); ok {
-		return validatable.Validate()
-	}
-
-	return nil
+	return lambdah.Validate(v)
 }
 
 type HandlerFunc func(c *Context) error
@@ -58,3 +55,13 @@ func (hf HandlerFunc) ToLambdaHandler() func(ctx context.Context, event events.C
 		return hf(c)
 	}
 }
+
+// clone returns a copy of the context which shares no maps or slices with c, so
+// that a handler left running in the background after a timeout does not race
+// with earlier middleware.
+func (c *Context) clone() Context {
+	tc := *c
+	tc.Event.Resources = clone.Slice(c.Event.Resources)
+	tc.Event.Detail = clone.Slice(c.Event.Detail)
+	return tc
+}
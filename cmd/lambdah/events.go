@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+)
+
+// Types of the events of the triggers supported by Lambdah.
+const (
+	eventTypeSQS          = "sqs"
+	eventTypeSNS          = "sns"
+	eventTypeS3           = "s3"
+	eventTypeDynamoDB     = "dynamodb"
+	eventTypeKinesis      = "kinesis"
+	eventTypeFirehose     = "firehose"
+	eventTypeCloudWatch   = "cloudwatch"
+	eventTypeScheduled    = "scheduled"
+	eventTypeAPIGateway   = "api-gateway"
+	eventTypeAPIGatewayV2 = "api-gateway-v2"
+	eventTypeALB          = "alb"
+	eventTypeGeneric      = "generic"
+)
+
+var recordEventTypes = map[string]string{
+	"aws:sqs":      eventTypeSQS,
+	"aws:sns":      eventTypeSNS,
+	"aws:s3":       eventTypeS3,
+	"aws:dynamodb": eventTypeDynamoDB,
+	"aws:kinesis":  eventTypeKinesis,
+}
+
+// eventShape is the fields of events which identify their type.
+type eventShape struct {
+	Records []struct {
+		// matched case insensitively, as SNS records have an `EventSource`
+		EventSource string `json:"eventSource"`
+	} `json:"Records"`
+	// Firehose records are `records`, which are matched as Records
+	DeliveryStreamArn string `json:"deliveryStreamArn"`
+	Version           string `json:"version"`
+	Source            string `json:"source"`
+	DetailType        string `json:"detail-type"`
+	HTTPMethod        string `json:"httpMethod"`
+	RouteKey          string `json:"routeKey"`
+	RequestContext    *struct {
+		ELB json.RawMessage `json:"elb"`
+	} `json:"requestContext"`
+}
+
+// detectEventType returns the type of an event, or generic if it is not an event
+// of a supported trigger, and the number of records of batch events.
+func detectEventType(payload []byte) (string, int) {
+	var shape eventShape
+	if err := json.Unmarshal(payload, &shape); err != nil {
+		return eventTypeGeneric, 0
+	}
+
+	if len(shape.Records) > 0 {
+		if shape.DeliveryStreamArn != "" {
+			return eventTypeFirehose, len(shape.Records)
+		}
+		if eventType, ok := recordEventTypes[shape.Records[0].EventSource]; ok {
+			return eventType, len(shape.Records)
+		}
+		return eventTypeGeneric, 0
+	}
+
+	switch {
+	case shape.Source != "" && shape.DetailType != "":
+		return eventTypeCloudWatch, 0
+	case shape.RequestContext == nil:
+		return eventTypeGeneric, 0
+	case shape.RequestContext.ELB != nil:
+		return eventTypeALB, 0
+	case shape.Version == "2.0" && shape.RouteKey != "":
+		return eventTypeAPIGatewayV2, 0
+	case shape.HTTPMethod != "":
+		return eventTypeAPIGateway, 0
+	}
+	return eventTypeGeneric, 0
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/webbgeorge/lambdah/lambdahtest"
+)
+
+func TestDetectEventType(t *testing.T) {
+	testCases := []struct {
+		name            string
+		event           interface{}
+		expectedType    string
+		expectedRecords int
+	}{
+		{"sqs", lambdahtest.SQS().Message("a").Message("b").Build(), eventTypeSQS, 2},
+		{"sns", lambdahtest.SNS().Message("a").Build(), eventTypeSNS, 1},
+		{"s3", lambdahtest.S3().Object("a").Object("b").Object("c").Build(), eventTypeS3, 3},
+		{"dynamodb", lambdahtest.DynamoDB().Insert(map[string]string{"id": "1"}).Build(), eventTypeDynamoDB, 1},
+		{"kinesis", lambdahtest.Kinesis().Record([]byte("{}")).Record([]byte("{}")).Build(), eventTypeKinesis, 2},
+		{"firehose", lambdahtest.Firehose().Record([]byte("{}")).Record([]byte("{}")).Build(), eventTypeFirehose, 2},
+		{"cloudwatch", lambdahtest.CloudWatchEvent().Build(), eventTypeCloudWatch, 0},
+		{"scheduled", lambdahtest.ScheduledEvent().Build(), eventTypeCloudWatch, 0},
+		{"api gateway", lambdahtest.HTTPRequest("GET", "/").APIGatewayProxy(), eventTypeAPIGateway, 0},
+		{"api gateway v2", lambdahtest.HTTPRequest("GET", "/").APIGatewayV2(), eventTypeAPIGatewayV2, 0},
+		{"alb", lambdahtest.HTTPRequest("GET", "/").ALB(), eventTypeALB, 0},
+		{"generic object", map[string]string{"name": "Dave"}, eventTypeGeneric, 0},
+		{"generic string", "hello", eventTypeGeneric, 0},
+		{"unknown records", map[string]interface{}{"Records": []map[string]string{{"eventSource": "aws:unknown"}}}, eventTypeGeneric, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			payload, err := json.Marshal(tc.event)
+			assert.Nil(t, err)
+
+			eventType, records := detectEventType(payload)
+
+			assert.Equal(t, tc.expectedType, eventType)
+			assert.Equal(t, tc.expectedRecords, records)
+		})
+	}
+}
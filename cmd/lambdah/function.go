@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/webbgeorge/lambdah/lambdahtest"
+)
+
+// function runs a Lambda function in a process, against an emulated Runtime API.
+//
+// The function is built from its Go package, or run from a compiled binary, and
+// is started on its first invocation. As in Lambda, the process handles one
+// invocation at a time, and a new process is started if it exits, such as after
+// an unrecovered panic.
+type function struct {
+	config   *functionConfig
+	buildDir string
+	logs     io.Writer
+
+	mu      sync.Mutex
+	binary  string
+	builds  int
+	runtime *lambdahtest.Runtime
+	watched []string
+}
+
+func newFunction(config *functionConfig, buildDir string, logs io.Writer) *function {
+	return &function{
+		config:   config,
+		buildDir: buildDir,
+		logs:     logs,
+	}
+}
+
+// arn returns the ARN of the function, in the region of the AWS_REGION
+// environment variable.
+func (f *function) arn() string {
+	return "arn:aws:lambda:" + region() + ":" + lambdahtest.AccountID + ":function:" + f.config.name
+}
+
+// build the function, if its source is a Go package, and stop any running process
+// so that the new binary is used for the next invocation. If the build fails the
+// previous binary is kept.
+func (f *function) build() error {
+	binary := f.config.source
+	watched := []string{f.config.source}
+	if isDir(f.config.source) {
+		f.mu.Lock()
+		f.builds++
+		// each build has a new path, as a running binary can't be overwritten
+		binary = filepath.Join(f.buildDir, f.config.name+"-"+strconv.Itoa(f.builds))
+		f.mu.Unlock()
+
+		var err error
+		binary, err = filepath.Abs(binary)
+		if err != nil {
+			return err
+		}
+		var stderr bytes.Buffer
+		// #nosec G204 -- builds the function source given on the command line
+		cmd := exec.Command("go", "build", "-o", binary, ".")
+		cmd.Dir = f.config.source
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to build function '%s': %v\n%s", f.config.name, err, stderr.String())
+		}
+		watched, err = sourceFiles(f.config.source)
+		if err != nil {
+			return err
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	previous := f.binary
+	f.binary = binary
+	f.watched = watched
+	f.stop()
+	if previous != "" && previous != binary {
+		_ = os.Remove(previous)
+	}
+	return nil
+}
+
+// invoke the function with event, starting a process if none is running.
+func (f *function) invoke(ctx context.Context, event interface{}, opts ...lambdahtest.InvokeOption) (*lambdahtest.Result, error) {
+	opts = append([]lambdahtest.InvokeOption{
+		lambdahtest.WithTimeout(f.config.timeout),
+		lambdahtest.WithFunctionARN(f.arn()),
+	}, opts...)
+	rt, err := f.process()
+	if err != nil {
+		return nil, err
+	}
+	result, err := rt.Invoke(ctx, event, opts...)
+	if !errors.Is(err, lambdahtest.ErrFunctionExited) {
+		return result, err
+	}
+
+	// the process exited before receiving the invocation, such as after reporting
+	// an unrecovered panic, so it is sent to a new process
+	rt, err = f.process()
+	if err != nil {
+		return nil, err
+	}
+	return rt.Invoke(ctx, event, opts...)
+}
+
+func (f *function) process() (*lambdahtest.Runtime, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.runtime != nil {
+		select {
+		case <-f.runtime.Exited():
+			f.stop()
+		default:
+			return f.runtime, nil
+		}
+	}
+
+	// #nosec G204 -- runs the function binary built or given on the command line
+	cmd := exec.Command(f.binary)
+	cmd.Env = f.environ()
+	cmd.Stdout = f.logs
+	cmd.Stderr = f.logs
+	rt := lambdahtest.NewRuntime()
+	if err := rt.StartProcess(cmd); err != nil {
+		rt.Close()
+		return nil, fmt.Errorf("failed to start function '%s': %w", f.config.name, err)
+	}
+	f.runtime = rt
+	return rt, nil
+}
+
+// environ returns the environment of the function process, with the variables
+// set by Lambda, and those of the function's configuration.
+func (f *function) environ() []string {
+	env := os.Environ()
+	env = append(env,
+		"AWS_LAMBDA_FUNCTION_NAME="+f.config.name,
+		"AWS_LAMBDA_FUNCTION_VERSION=$LATEST",
+		"AWS_LAMBDA_FUNCTION_MEMORY_SIZE="+strconv.Itoa(f.config.memorySize),
+		"AWS_LAMBDA_LOG_GROUP_NAME=/aws/lambda/"+f.config.name,
+		"AWS_REGION="+region(),
+	)
+	keys := make([]string, 0, len(f.config.env))
+	for k := range f.config.env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, k+"="+f.config.env[k])
+	}
+	return env
+}
+
+// stop the running process, if any. f.mu must be held.
+func (f *function) stop() {
+	if f.runtime != nil {
+		f.runtime.Close()
+		f.runtime = nil
+	}
+}
+
+func (f *function) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stop()
+}
+
+// watch the function's source files, or its binary, until ctx is done, and
+// rebuild the function when they change.
+func (f *function) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := f.fingerprint()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := f.fingerprint()
+		if current == last {
+			continue
+		}
+		last = current
+		if err := f.build(); err != nil {
+			_, _ = fmt.Fprintln(f.logs, err.Error())
+			continue
+		}
+		// files may be added to the package by the change
+		last = f.fingerprint()
+		_, _ = fmt.Fprintf(f.logs, "Reloaded function '%s'\n", f.config.name)
+	}
+}
+
+// fingerprint returns the paths, sizes and modification times of the watched files.
+func (f *function) fingerprint() string {
+	f.mu.Lock()
+	watched := f.watched
+	f.mu.Unlock()
+
+	var b strings.Builder
+	for _, path := range watched {
+		info, err := os.Stat(path)
+		if err != nil {
+			_, _ = fmt.Fprintf(&b, "%s:missing\n", path)
+			continue
+		}
+		_, _ = fmt.Fprintf(&b, "%s:%d:%d\n", path, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String()
+}
+
+// sourceFiles returns the Go files of the package in dir, and of the packages of
+// its module which it imports.
+func sourceFiles(dir string) ([]string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("go", "list", "-deps", "-f", "{{if and .Module .Module.Main}}{{.Dir}}{{end}}", ".")
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to list packages of '%s': %v\n%s", dir, err, stderr.String())
+	}
+
+	var files []string
+	for _, pkgDir := range strings.Fields(stdout.String()) {
+		entries, err := ioutil.ReadDir(pkgDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") {
+				files = append(files, filepath.Join(pkgDir, entry.Name()))
+			}
+		}
+	}
+	return files, nil
+}
+
+func region() string {
+	if r := os.Getenv("AWS_REGION"); r != "" {
+		return r
+	}
+	return lambdahtest.Region
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/webbgeorge/lambdah/lambdahtest"
+)
+
+const (
+	// maxPayloadSize is the maximum size of request payloads of API Gateway
+	maxPayloadSize = 10 << 20
+	// integrationTimeout is the maximum integration timeout of API Gateway
+	integrationTimeout = 29 * time.Second
+)
+
+// invoker invokes a Lambda function with an event.
+type invoker interface {
+	invoke(ctx context.Context, event interface{}, opts ...lambdahtest.InvokeOption) (*lambdahtest.Result, error)
+}
+
+// gateway is an http.Handler which simulates an API Gateway REST API, sending
+// requests to the functions of its routes using Lambda proxy integrations.
+type gateway struct {
+	routes         *routeTable
+	functions      map[string]invoker
+	stage          string
+	stageVariables map[string]string
+	claims         map[string]string
+	maxPayloadSize int64
+	timeout        time.Duration
+	logs           io.Writer
+}
+
+type gatewayError struct {
+	Message string `json:"message"`
+}
+
+func (g *gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	status, function := g.serve(w, r)
+	_, _ = fmt.Fprintf(g.logs, "%s %s %d %s %s\n", r.Method, r.URL.RequestURI(), status, function, time.Since(start).Round(time.Millisecond))
+}
+
+func (g *gateway) serve(w http.ResponseWriter, r *http.Request) (int, string) {
+	rt, params := g.routes.match(r.Method, r.URL.Path)
+	if rt == nil {
+		return writeGatewayError(w, http.StatusForbidden, "Missing Authentication Token"), "-"
+	}
+
+	if r.ContentLength > g.maxPayloadSize {
+		return writeGatewayError(w, http.StatusRequestEntityTooLarge, "Request Too Long"), rt.function
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, g.maxPayloadSize+1))
+	if err != nil {
+		return writeGatewayError(w, http.StatusBadRequest, "Bad Request"), rt.function
+	}
+	if int64(len(body)) > g.maxPayloadSize {
+		return writeGatewayError(w, http.StatusRequestEntityTooLarge, "Request Too Long"), rt.function
+	}
+
+	event := g.event(r, rt, params, body)
+	w.Header().Set("X-Amzn-Requestid", event.RequestContext.RequestID)
+
+	ctx, cancel := context.WithTimeout(r.Context(), g.timeout)
+	defer cancel()
+	result, err := g.functions[rt.function].invoke(ctx, event)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return writeGatewayError(w, http.StatusGatewayTimeout, "Endpoint request timed out"), rt.function
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(g.logs, "Failed to invoke function '%s': %s\n", rt.function, err.Error())
+		return writeGatewayError(w, http.StatusBadGateway, "Internal server error"), rt.function
+	}
+	if result.Error != nil {
+		_, _ = fmt.Fprintf(g.logs, "Function '%s' returned an error: %s\n", rt.function, result.Error.Error())
+		return writeGatewayError(w, http.StatusBadGateway, "Internal server error"), rt.function
+	}
+
+	var res events.APIGatewayProxyResponse
+	if err := result.Unmarshal(&res); err != nil || res.StatusCode == 0 {
+		_, _ = fmt.Fprintf(g.logs, "Function '%s' returned a malformed Lambda proxy response: %s\n", rt.function, result.Payload)
+		return writeGatewayError(w, http.StatusBadGateway, "Internal server error"), rt.function
+	}
+	return writeProxyResponse(w, res), rt.function
+}
+
+// event returns the Lambda proxy integration event of a request.
+func (g *gateway) event(r *http.Request, rt *route, params map[string]string, body []byte) events.APIGatewayProxyRequest {
+	b := lambdahtest.HTTPRequest(r.Method, r.URL.RequestURI()).
+		Route(rt.resource).
+		Stage(g.stage).
+		Header("Host", r.Host)
+	for name, values := range r.Header {
+		for _, v := range values {
+			b.Header(name, v)
+		}
+	}
+	for name, value := range params {
+		b.PathParam(name, value)
+	}
+	for name, value := range g.stageVariables {
+		b.StageVariable(name, value)
+	}
+	for name, value := range g.claims {
+		b.Claim(name, value)
+	}
+	for name, value := range jwtClaims(r.Header.Get("Authorization")) {
+		b.Claim(name, value)
+	}
+	if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		b.SourceIP(ip)
+	}
+
+	// simulate API Gateway binary media types, by encoding any non UTF-8 body
+	if utf8.Valid(body) {
+		b.Body(string(body))
+	} else {
+		b.BinaryBody(body)
+	}
+	return b.APIGatewayProxy()
+}
+
+// jwtClaims returns the claims of a JWT bearer token, without verifying it, as a
+// Cognito user pool authorizer would once it has verified the token.
+func jwtClaims(authorization string) map[string]string {
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil
+	}
+
+	var claims map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err := dec.Decode(&claims); err != nil {
+		return nil
+	}
+	values := make(map[string]string, len(claims))
+	for name, value := range claims {
+		values[name] = stringValue(value)
+	}
+	return values
+}
+
+func writeProxyResponse(w http.ResponseWriter, res events.APIGatewayProxyResponse) int {
+	body := []byte(res.Body)
+	if res.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(res.Body)
+		if err != nil {
+			return writeGatewayError(w, http.StatusBadGateway, "Internal server error")
+		}
+		body = decoded
+	}
+
+	for k, v := range res.Headers {
+		w.Header().Set(k, v)
+	}
+	for k, vs := range res.MultiValueHeaders {
+		w.Header().Del(k)
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(res.StatusCode)
+	_, _ = w.Write(body)
+	return res.StatusCode
+}
+
+func writeGatewayError(w http.ResponseWriter, status int, message string) int {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(gatewayError{Message: message})
+	return status
+}
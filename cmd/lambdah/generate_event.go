@@ -0,0 +1,447 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/webbgeorge/lambdah/lambdahtest"
+)
+
+const generateEventUsage = `Usage: lambdah generate-event <type> [flags]
+
+Generate a sample event of a trigger supported by Lambdah, with realistic values
+for the fields set by AWS, and write it to stdout as JSON. Events are generated
+by the lambdahtest builders, so that fixtures and tests agree.
+
+Types:
+%s
+Run 'lambdah generate-event <type> -h' for the flags of a type.
+`
+
+// eventGenerator generates the events of a type. Its flags are defined on the
+// flag set, and the returned function builds the event once they are parsed.
+type eventGenerator struct {
+	description string
+	define      func(fs *flag.FlagSet) func() (interface{}, error)
+}
+
+var eventGenerators = map[string]eventGenerator{
+	eventTypeSQS:          {"SQS messages", defineSQSEvent},
+	eventTypeSNS:          {"SNS notifications", defineSNSEvent},
+	eventTypeS3:           {"S3 event notifications", defineS3Event},
+	eventTypeDynamoDB:     {"DynamoDB stream records", defineDynamoDBEvent},
+	eventTypeKinesis:      {"Kinesis Data Streams records", defineKinesisEvent},
+	eventTypeFirehose:     {"Kinesis Data Firehose transformations", defineFirehoseEvent},
+	eventTypeCloudWatch:   {"CloudWatch (EventBridge) events", defineCloudWatchEvent},
+	eventTypeScheduled:    {"CloudWatch scheduled events", defineScheduledEvent},
+	eventTypeAPIGateway:   {"API Gateway REST API proxy requests", defineHTTPEvent(eventTypeAPIGateway)},
+	eventTypeAPIGatewayV2: {"API Gateway HTTP API requests, payload format 2.0", defineHTTPEvent(eventTypeAPIGatewayV2)},
+	eventTypeALB:          {"Application Load Balancer requests", defineHTTPEvent(eventTypeALB)},
+}
+
+func generateEventCommand(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		_, _ = fmt.Fprintf(stderr, generateEventUsage, eventTypesUsage())
+		if len(args) == 0 {
+			return usageError{errors.New("missing event type")}
+		}
+		return flag.ErrHelp
+	}
+
+	eventType := args[0]
+	generator, ok := eventGenerators[eventType]
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "unknown event type '%s'\n\n", eventType)
+		_, _ = fmt.Fprintf(stderr, generateEventUsage, eventTypesUsage())
+		return usageError{fmt.Errorf("unknown event type '%s'", eventType)}
+	}
+
+	fs := newFlagSet("generate-event "+eventType, stderr, fmt.Sprintf(
+		"Usage: lambdah generate-event %s [flags]\n\nGenerate %s.\n\nFlags:\n",
+		eventType,
+		generator.description,
+	))
+	build := generator.define(fs)
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return usageError{errors.New("too many arguments")}
+	}
+
+	event, err := build()
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(event, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(stdout, string(b))
+	return nil
+}
+
+func eventTypesUsage() string {
+	types := make([]string, 0, len(eventGenerators))
+	for eventType := range eventGenerators {
+		types = append(types, eventType)
+	}
+	sort.Strings(types)
+
+	var b strings.Builder
+	for _, eventType := range types {
+		_, _ = fmt.Fprintf(&b, "  %-16s %s\n", eventType, eventGenerators[eventType].description)
+	}
+	return b.String()
+}
+
+func defineSQSEvent(fs *flag.FlagSet) func() (interface{}, error) {
+	queue := fs.String("queue", "lambdah-test", "queue name")
+	region := fs.String("region", lambdahtest.Region, "AWS region")
+	var bodies stringsFlag
+	fs.Var(&bodies, "body", "message `body`, may be repeated for many messages (default {})")
+	attrs := keyValueFlag{}
+	fs.Var(attrs, "attr", "message attribute as `name=value`, may be repeated")
+	group := fs.String("fifo", "", "message `group` ID, for a FIFO queue")
+
+	return func() (interface{}, error) {
+		b := lambdahtest.SQS().Queue(*queue).Region(*region)
+		if len(bodies) == 0 {
+			bodies = stringsFlag{"{}"}
+		}
+		for _, body := range bodies {
+			b.Message(body)
+			for _, name := range sortedStringKeys(attrs) {
+				b.Attr(name, attrs[name])
+			}
+			if *group != "" {
+				b.FIFO(*group)
+			}
+		}
+		return b.Build(), nil
+	}
+}
+
+func defineSNSEvent(fs *flag.FlagSet) func() (interface{}, error) {
+	topic := fs.String("topic", "lambdah-test", "topic name")
+	region := fs.String("region", lambdahtest.Region, "AWS region")
+	var messages stringsFlag
+	fs.Var(&messages, "message", "`message`, may be repeated for many notifications (default {})")
+	subject := fs.String("subject", "", "message subject")
+	attrs := keyValueFlag{}
+	fs.Var(attrs, "attr", "message attribute as `name=value`, may be repeated")
+
+	return func() (interface{}, error) {
+		b := lambdahtest.SNS().Topic(*topic).Region(*region)
+		if len(messages) == 0 {
+			messages = stringsFlag{"{}"}
+		}
+		for _, message := range messages {
+			b.Message(message)
+			if *subject != "" {
+				b.Subject(*subject)
+			}
+			for _, name := range sortedStringKeys(attrs) {
+				b.Attr(name, attrs[name])
+			}
+		}
+		return b.Build(), nil
+	}
+}
+
+func defineS3Event(fs *flag.FlagSet) func() (interface{}, error) {
+	bucket := fs.String("bucket", "lambdah-test", "bucket name")
+	region := fs.String("region", lambdahtest.Region, "AWS region")
+	var keys stringsFlag
+	fs.Var(&keys, "key", "object `key`, may be repeated for many objects (default test-key)")
+	eventName := fs.String("event", "ObjectCreated:Put", "event `name`, such as ObjectCreated:Copy or ObjectRemoved:Delete")
+	size := fs.Int64("size", 1024, "object size in bytes")
+	versionID := fs.String("version-id", "", "object version ID, for buckets with versioning")
+
+	return func() (interface{}, error) {
+		b := lambdahtest.S3().Bucket(*bucket).Region(*region)
+		if len(keys) == 0 {
+			keys = stringsFlag{"test-key"}
+		}
+		for _, key := range keys {
+			b.Object(key).Size(*size).EventName(*eventName)
+			if *versionID != "" {
+				b.VersionID(*versionID)
+			}
+		}
+		return b.Build(), nil
+	}
+}
+
+func defineDynamoDBEvent(fs *flag.FlagSet) func() (interface{}, error) {
+	table := fs.String("table", "lambdah-test", "table name")
+	region := fs.String("region", lambdahtest.Region, "AWS region")
+	keys := fs.String("keys", "id", "comma separated `names` of the key attributes")
+	eventName := fs.String("event", lambdahtest.DynamoDBInsert, "event `name`, INSERT, MODIFY or REMOVE")
+	newImage := fs.String("new-image", "", "`file` of the new image, as JSON or DynamoDB JSON")
+	oldImage := fs.String("old-image", "", "`file` of the old image, as JSON or DynamoDB JSON")
+
+	return func() (interface{}, error) {
+		keyAttributes := strings.Split(*keys, ",")
+		b := lambdahtest.DynamoDB().Table(*table).Region(*region).KeyAttributes(keyAttributes...)
+
+		name := strings.ToUpper(*eventName)
+		if name != lambdahtest.DynamoDBInsert && name != lambdahtest.DynamoDBModify && name != lambdahtest.DynamoDBRemove {
+			return nil, fmt.Errorf("invalid DynamoDB event name '%s', expected INSERT, MODIFY or REMOVE", *eventName)
+		}
+
+		var oldItem, newItem interface{}
+		var err error
+		if name != lambdahtest.DynamoDBInsert {
+			if oldItem, err = readImage(*oldImage, keyAttributes); err != nil {
+				return nil, err
+			}
+		}
+		if name != lambdahtest.DynamoDBRemove {
+			if newItem, err = readImage(*newImage, keyAttributes); err != nil {
+				return nil, err
+			}
+		}
+		return b.Record(name, oldItem, newItem).Build(), nil
+	}
+}
+
+func defineKinesisEvent(fs *flag.FlagSet) func() (interface{}, error) {
+	stream := fs.String("stream", "lambdah-test", "stream name")
+	region := fs.String("region", lambdahtest.Region, "AWS region")
+	shard := fs.String("shard", "shardId-000000000000", "`ID` of the shard the records are from")
+	var data stringsFlag
+	fs.Var(&data, "data", "record `data`, may be repeated for many records (default {})")
+	partitionKey := fs.String("partition-key", "", "partition `key` of the records (default a random UUID)")
+
+	return func() (interface{}, error) {
+		b := lambdahtest.Kinesis().Stream(*stream).Region(*region).Shard(*shard)
+		if len(data) == 0 {
+			data = stringsFlag{"{}"}
+		}
+		for _, d := range data {
+			b.Record([]byte(d))
+			if *partitionKey != "" {
+				b.PartitionKey(*partitionKey)
+			}
+		}
+		return b.Build(), nil
+	}
+}
+
+func defineFirehoseEvent(fs *flag.FlagSet) func() (interface{}, error) {
+	deliveryStream := fs.String("delivery-stream", "lambdah-test", "delivery stream name")
+	region := fs.String("region", lambdahtest.Region, "AWS region")
+	sourceStream := fs.String("source-stream", "", "`name` of the Kinesis stream which is the source of the delivery stream")
+	var data stringsFlag
+	fs.Var(&data, "data", "record `data`, may be repeated for many records (default {})")
+
+	return func() (interface{}, error) {
+		b := lambdahtest.Firehose().DeliveryStream(*deliveryStream).Region(*region).SourceStream(*sourceStream)
+		if len(data) == 0 {
+			data = stringsFlag{"{}"}
+		}
+		for _, d := range data {
+			b.Record([]byte(d))
+		}
+		return b.Build(), nil
+	}
+}
+
+func defineCloudWatchEvent(fs *flag.FlagSet) func() (interface{}, error) {
+	source := fs.String("source", "lambdah.test", "event source")
+	detailType := fs.String("detail-type", "Test Event", "event detail type")
+	detail := fs.String("detail", "{}", "event detail as JSON, or @file to read it from a file")
+	region := fs.String("region", lambdahtest.Region, "AWS region")
+	var resources stringsFlag
+	fs.Var(&resources, "resource", "`ARN` of a resource of the event, may be repeated")
+
+	return func() (interface{}, error) {
+		d, err := readJSONArg(*detail)
+		if err != nil {
+			return nil, fmt.Errorf("invalid detail: %w", err)
+		}
+		b := lambdahtest.CloudWatchEvent().
+			Source(*source).
+			DetailType(*detailType).
+			Detail(d).
+			Region(*region)
+		if len(resources) > 0 {
+			b.Resources(resources...)
+		}
+		return b.Build(), nil
+	}
+}
+
+func defineScheduledEvent(fs *flag.FlagSet) func() (interface{}, error) {
+	rule := fs.String("rule", "lambdah-test", "name of the schedule rule")
+	region := fs.String("region", lambdahtest.Region, "AWS region")
+
+	return func() (interface{}, error) {
+		return lambdahtest.ScheduledEvent().
+			Region(*region).
+			Resources("arn:aws:events:" + *region + ":" + lambdahtest.AccountID + ":rule/" + *rule).
+			Build(), nil
+	}
+}
+
+func defineHTTPEvent(eventType string) func(fs *flag.FlagSet) func() (interface{}, error) {
+	return func(fs *flag.FlagSet) func() (interface{}, error) {
+		method := fs.String("method", "GET", "HTTP method")
+		path := fs.String("path", "/", "request `path`, which may have a query string")
+		body := fs.String("body", "", "request body")
+		sourceIP := fs.String("source-ip", "127.0.0.1", "IP address of the client")
+		headers := keyValueFlag{}
+		fs.Var(headers, "header", "request header as `name=value`, may be repeated")
+		query := keyValueFlag{}
+		fs.Var(query, "query", "query string parameter as `name=value`, may be repeated")
+		pathParams := keyValueFlag{}
+		claims := keyValueFlag{}
+		stageVariables := keyValueFlag{}
+		route := new(string)
+		stage := new(string)
+		if eventType != eventTypeALB {
+			fs.StringVar(route, "route", "", "API Gateway resource or route, such as /books/{id}")
+			fs.StringVar(stage, "stage", "", "API Gateway stage name")
+			fs.Var(pathParams, "path-param", "path parameter as `name=value`, may be repeated")
+			fs.Var(claims, "claim", "authorizer claim as `name=value`, may be repeated")
+			fs.Var(stageVariables, "stage-var", "stage variable as `name=value`, may be repeated")
+		}
+
+		return func() (interface{}, error) {
+			b := lambdahtest.HTTPRequest(*method, *path).
+				Route(*route).
+				Stage(*stage).
+				SourceIP(*sourceIP)
+			if *body != "" {
+				b.Body(*body)
+			}
+			for name, value := range headers {
+				b.Header(name, value)
+			}
+			for name, value := range query {
+				b.Query(name, value)
+			}
+			for name, value := range pathParams {
+				b.PathParam(name, value)
+			}
+			for name, value := range claims {
+				b.Claim(name, value)
+			}
+			for name, value := range stageVariables {
+				b.StageVariable(name, value)
+			}
+
+			switch eventType {
+			case eventTypeAPIGatewayV2:
+				return b.APIGatewayV2(), nil
+			case eventTypeALB:
+				return b.ALB(), nil
+			default:
+				return b.APIGatewayProxy(), nil
+			}
+		}
+	}
+}
+
+// readImage reads a DynamoDB image from a JSON file, which is either a plain JSON
+// object or DynamoDB JSON, as in DynamoDB stream records. Without a file, the image
+// has the value "1" for each key attribute.
+func readImage(path string, keyAttributes []string) (interface{}, error) {
+	if path == "" {
+		image := make(map[string]string, len(keyAttributes))
+		for _, name := range keyAttributes {
+			image[name] = "1"
+		}
+		return image, nil
+	}
+
+	b, err := ioutil.ReadFile(path) // #nosec G304 -- image path given on the command line
+	if err != nil {
+		return nil, err
+	}
+	var item map[string]interface{}
+	if err := json.Unmarshal(b, &item); err != nil {
+		return nil, fmt.Errorf("invalid image '%s': %w", path, err)
+	}
+	if !isDynamoDBJSON(item) {
+		return item, nil
+	}
+
+	var image map[string]events.DynamoDBAttributeValue
+	if err := json.Unmarshal(b, &image); err != nil {
+		return nil, fmt.Errorf("invalid image '%s': %w", path, err)
+	}
+	return image, nil
+}
+
+var dynamoDBTypes = map[string]bool{
+	"S": true, "N": true, "B": true, "BOOL": true, "NULL": true,
+	"M": true, "L": true, "SS": true, "NS": true, "BS": true,
+}
+
+// isDynamoDBJSON reports whether every attribute of item is a single DynamoDB type
+// descriptor, such as `{"S": "value"}`.
+func isDynamoDBJSON(item map[string]interface{}) bool {
+	if len(item) == 0 {
+		return false
+	}
+	for _, v := range item {
+		av, ok := v.(map[string]interface{})
+		if !ok || len(av) != 1 {
+			return false
+		}
+		for k := range av {
+			if !dynamoDBTypes[k] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// readJSONArg returns the JSON of a flag value, or of the file it names if it has
+// an @ prefix.
+func readJSONArg(value string) (json.RawMessage, error) {
+	b := []byte(value)
+	if strings.HasPrefix(value, "@") {
+		var err error
+		// #nosec G304 -- @file path given on the command line
+		if b, err = ioutil.ReadFile(value[1:]); err != nil {
+			return nil, err
+		}
+	}
+	if !json.Valid(b) {
+		return nil, errors.New("not valid JSON")
+	}
+	return json.RawMessage(b), nil
+}
+
+// stringsFlag is a repeatable string flag.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
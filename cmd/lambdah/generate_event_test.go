@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func generateEvent(t *testing.T, args ...string) []byte {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	err := generateEventCommand(args, nil, stdout, stderr)
+	assert.Nil(t, err)
+	assert.Empty(t, stderr.String())
+	return stdout.Bytes()
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestGenerateEvent_DetectedType(t *testing.T) {
+	testCases := []struct {
+		eventType       string
+		expectedType    string
+		expectedRecords int
+	}{
+		{eventType: "sqs", expectedType: eventTypeSQS, expectedRecords: 1},
+		{eventType: "sns", expectedType: eventTypeSNS, expectedRecords: 1},
+		{eventType: "s3", expectedType: eventTypeS3, expectedRecords: 1},
+		{eventType: "dynamodb", expectedType: eventTypeDynamoDB, expectedRecords: 1},
+		{eventType: "kinesis", expectedType: eventTypeKinesis, expectedRecords: 1},
+		{eventType: "firehose", expectedType: eventTypeFirehose, expectedRecords: 1},
+		{eventType: "cloudwatch", expectedType: eventTypeCloudWatch},
+		{eventType: "scheduled", expectedType: eventTypeCloudWatch},
+		{eventType: "api-gateway", expectedType: eventTypeAPIGateway},
+		{eventType: "api-gateway-v2", expectedType: eventTypeAPIGatewayV2},
+		{eventType: "alb", expectedType: eventTypeALB},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.eventType, func(t *testing.T) {
+			eventType, records := detectEventType(generateEvent(t, tc.eventType))
+
+			assert.Equal(t, tc.expectedType, eventType)
+			assert.Equal(t, tc.expectedRecords, records)
+		})
+	}
+}
+
+func TestGenerateEvent_SQS(t *testing.T) {
+	b := generateEvent(t, "sqs", "-queue", "orders.fifo", "-body", `{"id":1}`, "-body", `{"id":2}`, "-attr", "type=order", "-fifo", "group-1")
+
+	var event events.SQSEvent
+	assert.Nil(t, json.Unmarshal(b, &event))
+	assert.Len(t, event.Records, 2)
+	assert.Equal(t, `{"id":1}`, event.Records[0].Body)
+	assert.Equal(t, `{"id":2}`, event.Records[1].Body)
+	assert.Equal(t, "arn:aws:sqs:us-east-1:123456789012:orders.fifo", event.Records[0].EventSourceARN)
+	assert.Equal(t, "order", *event.Records[1].MessageAttributes["type"].StringValue)
+	assert.Equal(t, "group-1", event.Records[1].Attributes["MessageGroupId"])
+}
+
+func TestGenerateEvent_SNS(t *testing.T) {
+	b := generateEvent(t, "sns", "-topic", "orders", "-region", "eu-west-1", "-message", "hello", "-subject", "greeting")
+
+	var event events.SNSEvent
+	assert.Nil(t, json.Unmarshal(b, &event))
+	assert.Len(t, event.Records, 1)
+	assert.Equal(t, "hello", event.Records[0].SNS.Message)
+	assert.Equal(t, "greeting", event.Records[0].SNS.Subject)
+	assert.Equal(t, "arn:aws:sns:eu-west-1:123456789012:orders", event.Records[0].SNS.TopicArn)
+}
+
+func TestGenerateEvent_S3(t *testing.T) {
+	b := generateEvent(t, "s3", "-bucket", "b", "-key", "k", "-event", "ObjectRemoved:Delete")
+
+	var event events.S3Event
+	assert.Nil(t, json.Unmarshal(b, &event))
+	assert.Len(t, event.Records, 1)
+	assert.Equal(t, "ObjectRemoved:Delete", event.Records[0].EventName)
+	assert.Equal(t, "b", event.Records[0].S3.Bucket.Name)
+	assert.Equal(t, "k", event.Records[0].S3.Object.Key)
+	assert.Equal(t, int64(0), event.Records[0].S3.Object.Size)
+}
+
+func TestGenerateEvent_DynamoDB(t *testing.T) {
+	oldImage := writeFile(t, "old.json", `{"pk":"book-1","title":"Dune","pages":412}`)
+	newImage := writeFile(t, "new.json", `{"pk":{"S":"book-1"},"title":{"S":"Dune Messiah"},"pages":{"N":"256"}}`)
+
+	b := generateEvent(t, "dynamodb", "-table", "books", "-keys", "pk", "-event", "MODIFY", "-old-image", oldImage, "-new-image", newImage)
+
+	var event events.DynamoDBEvent
+	assert.Nil(t, json.Unmarshal(b, &event))
+	assert.Len(t, event.Records, 1)
+	record := event.Records[0]
+	assert.Equal(t, "MODIFY", record.EventName)
+	assert.Contains(t, record.EventSourceArn, ":table/books/stream/")
+	assert.Equal(t, "book-1", record.Change.Keys["pk"].String())
+	assert.Equal(t, "Dune", record.Change.OldImage["title"].String())
+	assert.Equal(t, "412", record.Change.OldImage["pages"].Number())
+	assert.Equal(t, "Dune Messiah", record.Change.NewImage["title"].String())
+	assert.Equal(t, "256", record.Change.NewImage["pages"].Number())
+}
+
+func TestGenerateEvent_DynamoDB_DefaultImage(t *testing.T) {
+	b := generateEvent(t, "dynamodb", "-event", "remove")
+
+	var event events.DynamoDBEvent
+	assert.Nil(t, json.Unmarshal(b, &event))
+	record := event.Records[0]
+	assert.Equal(t, "REMOVE", record.EventName)
+	assert.Equal(t, "1", record.Change.Keys["id"].String())
+	assert.Equal(t, "1", record.Change.OldImage["id"].String())
+	assert.Nil(t, record.Change.NewImage)
+}
+
+func TestGenerateEvent_Kinesis(t *testing.T) {
+	b := generateEvent(t, "kinesis", "-stream", "orders", "-shard", "shardId-000000000006", "-data", `{"id":1}`, "-data", `{"id":2}`, "-partition-key", "order-1")
+
+	var event events.KinesisEvent
+	assert.Nil(t, json.Unmarshal(b, &event))
+	assert.Len(t, event.Records, 2)
+	assert.Equal(t, []byte(`{"id":1}`), event.Records[0].Kinesis.Data)
+	assert.Equal(t, []byte(`{"id":2}`), event.Records[1].Kinesis.Data)
+	assert.Equal(t, "order-1", event.Records[1].Kinesis.PartitionKey)
+	assert.Equal(t, "arn:aws:kinesis:us-east-1:123456789012:stream/orders", event.Records[0].EventSourceArn)
+	assert.Equal(t, "shardId-000000000006:"+event.Records[0].Kinesis.SequenceNumber, event.Records[0].EventID)
+}
+
+func TestGenerateEvent_Firehose(t *testing.T) {
+	b := generateEvent(t, "firehose", "-delivery-stream", "orders", "-source-stream", "orders-stream", "-data", `{"id":1}`, "-data", `{"id":2}`)
+
+	var event events.KinesisFirehoseEvent
+	assert.Nil(t, json.Unmarshal(b, &event))
+	assert.Len(t, event.Records, 2)
+	assert.Equal(t, []byte(`{"id":1}`), event.Records[0].Data)
+	assert.Equal(t, []byte(`{"id":2}`), event.Records[1].Data)
+	assert.Equal(t, "arn:aws:firehose:us-east-1:123456789012:deliverystream/orders", event.DeliveryStreamArn)
+	assert.Equal(t, "arn:aws:kinesis:us-east-1:123456789012:stream/orders-stream", event.SourceKinesisStreamArn)
+}
+
+func TestGenerateEvent_CloudWatch(t *testing.T) {
+	detail := writeFile(t, "detail.json", `{"orderId":"123"}`)
+
+	b := generateEvent(t, "cloudwatch", "-source", "com.example.orders", "-detail-type", "Order Placed", "-detail", "@"+detail)
+
+	var event events.CloudWatchEvent
+	assert.Nil(t, json.Unmarshal(b, &event))
+	assert.Equal(t, "com.example.orders", event.Source)
+	assert.Equal(t, "Order Placed", event.DetailType)
+	assert.JSONEq(t, `{"orderId":"123"}`, string(event.Detail))
+}
+
+func TestGenerateEvent_Scheduled(t *testing.T) {
+	b := generateEvent(t, "scheduled", "-rule", "nightly", "-region", "eu-west-1")
+
+	var event events.CloudWatchEvent
+	assert.Nil(t, json.Unmarshal(b, &event))
+	assert.Equal(t, "aws.events", event.Source)
+	assert.Equal(t, "Scheduled Event", event.DetailType)
+	assert.Equal(t, []string{"arn:aws:events:eu-west-1:123456789012:rule/nightly"}, event.Resources)
+}
+
+func TestGenerateEvent_APIGateway(t *testing.T) {
+	b := generateEvent(
+		t,
+		"api-gateway",
+		"-method", "post",
+		"-path", "/books/123?draft=true",
+		"-route", "/books/{id}",
+		"-path-param", "id=123",
+		"-header", "Content-Type=application/json",
+		"-claim", "sub=user-1",
+		"-stage", "Prod",
+		"-body", `{"title":"Dune"}`,
+	)
+
+	var event events.APIGatewayProxyRequest
+	assert.Nil(t, json.Unmarshal(b, &event))
+	assert.Equal(t, "POST", event.HTTPMethod)
+	assert.Equal(t, "/books/123", event.Path)
+	assert.Equal(t, "/books/{id}", event.Resource)
+	assert.Equal(t, map[string]string{"id": "123"}, event.PathParameters)
+	assert.Equal(t, map[string]string{"draft": "true"}, event.QueryStringParameters)
+	assert.Equal(t, "application/json", event.Headers["Content-Type"])
+	assert.Equal(t, "Prod", event.RequestContext.Stage)
+	assert.Equal(t, map[string]interface{}{"sub": "user-1"}, event.RequestContext.Authorizer["claims"])
+	assert.Equal(t, `{"title":"Dune"}`, event.Body)
+}
+
+func TestGenerateEvent_Errors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		args          []string
+		expectedErr   string
+		expectedUsage bool
+	}{
+		{
+			name:          "missing type",
+			args:          nil,
+			expectedErr:   "missing event type",
+			expectedUsage: true,
+		},
+		{
+			name:          "unknown type",
+			args:          []string{"kafka"},
+			expectedErr:   "unknown event type 'kafka'",
+			expectedUsage: true,
+		},
+		{
+			name:          "flag of another type",
+			args:          []string{"alb", "-stage", "Prod"},
+			expectedErr:   "flag provided but not defined: -stage",
+			expectedUsage: true,
+		},
+		{
+			name:        "invalid DynamoDB event",
+			args:        []string{"dynamodb", "-event", "UPDATE"},
+			expectedErr: "invalid DynamoDB event name 'UPDATE', expected INSERT, MODIFY or REMOVE",
+		},
+		{
+			name:        "invalid detail",
+			args:        []string{"cloudwatch", "-detail", "{"},
+			expectedErr: "invalid detail: not valid JSON",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+			err := generateEventCommand(tc.args, nil, stdout, stderr)
+
+			assert.EqualError(t, err, tc.expectedErr)
+			_, isUsageErr := err.(usageError)
+			assert.Equal(t, tc.expectedUsage, isUsageErr)
+			assert.Empty(t, stdout.String())
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/webbgeorge/lambdah/lambdahtest"
+)
+
+const invokeUsage = `Usage: lambdah invoke [flags] [package]
+
+Invoke a handler once with a JSON event, such as an event captured from
+CloudWatch Logs, as Lambda does. The handler is built from its Go package, or
+run from a compiled binary, and is invoked with a simulated deadline and Lambda
+context.
+
+The event is read from the file of -event, or from stdin. The type of the event
+is detected, and reported with the function's logs on stderr. The response, or
+the error of the function, is written to stdout.
+
+Flags:
+`
+
+type invokeOptions struct {
+	event      string
+	source     string
+	name       string
+	requestID  string
+	timeout    time.Duration
+	memorySize int
+	env        keyValueFlag
+}
+
+func invokeCommand(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	opts := invokeOptions{env: keyValueFlag{}}
+	fs := newFlagSet("invoke", stderr, invokeUsage)
+	fs.StringVar(&opts.event, "event", "-", "`file` of the JSON event, or - for stdin")
+	fs.StringVar(&opts.name, "name", "", "function name (default the name of the package directory)")
+	fs.StringVar(&opts.requestID, "request-id", "", "request ID of the invocation (default a random UUID)")
+	fs.DurationVar(&opts.timeout, "timeout", lambdahtest.DefaultTimeout, "function timeout")
+	fs.IntVar(&opts.memorySize, "memory", defaultMemorySize, "function memory size in MB")
+	fs.Var(opts.env, "env", "environment variable as `name=value`, may be repeated")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return usageError{errors.New("too many arguments")}
+	}
+	opts.source = fs.Arg(0)
+	if opts.source == "" {
+		opts.source = "."
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return invoke(ctx, opts, stdin, stdout, stderr)
+}
+
+// invoke builds the function and invokes it with the event.
+func invoke(ctx context.Context, opts invokeOptions, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	payload, err := readEvent(opts.event, stdin)
+	if err != nil {
+		return err
+	}
+
+	name := opts.name
+	if name == "" {
+		name = functionName(opts.source)
+	}
+	config := newFunctionConfig(name, opts.source)
+	config.timeout = opts.timeout
+	config.memorySize = opts.memorySize
+	config.env = opts.env
+
+	eventType, records := detectEventType(payload)
+	if records > 0 {
+		_, _ = fmt.Fprintf(stderr, "Invoking function '%s' with %s event of %d records\n", name, eventType, records)
+	} else {
+		_, _ = fmt.Fprintf(stderr, "Invoking function '%s' with %s event\n", name, eventType)
+	}
+
+	buildDir, err := ioutil.TempDir("", "lambdah-invoke-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(buildDir)
+
+	fn := newFunction(config, buildDir, stderr)
+	if err := fn.build(); err != nil {
+		return err
+	}
+	defer fn.close()
+	// start the function before the invocation, so that logs of its
+	// initialisation come first, as they do in Lambda
+	if _, err := fn.process(); err != nil {
+		return err
+	}
+
+	requestID := opts.requestID
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	_, _ = fmt.Fprintf(stderr, "START RequestId: %s Version: $LATEST\n", requestID)
+	result, err := fn.invoke(ctx, json.RawMessage(payload), lambdahtest.WithRequestID(requestID))
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(stderr, "END RequestId: %s\n", requestID)
+	duration := float64(result.Duration) / float64(time.Millisecond)
+	_, _ = fmt.Fprintf(
+		stderr,
+		"REPORT RequestId: %s\tDuration: %.2f ms\tBilled Duration: %.0f ms\tMemory Size: %d MB\n",
+		requestID,
+		duration,
+		math.Ceil(duration),
+		config.memorySize,
+	)
+
+	if result.Error != nil {
+		b, _ := json.Marshal(result.Error)
+		writeJSON(stdout, b)
+		return fmt.Errorf("function returned an error: %s", result.Error.Error())
+	}
+	writeJSON(stdout, result.Payload)
+	return nil
+}
+
+// readEvent reads the JSON event from the file at path, or from stdin if path is -.
+func readEvent(path string, stdin io.Reader) ([]byte, error) {
+	var payload []byte
+	var err error
+	if path == "-" {
+		payload, err = ioutil.ReadAll(stdin)
+	} else {
+		payload, err = ioutil.ReadFile(path) // #nosec G304 -- event path given on the command line
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event: %w", err)
+	}
+	if !json.Valid(payload) {
+		return nil, errors.New("event is not valid JSON")
+	}
+	return payload, nil
+}
+
+// writeJSON writes indented JSON to w, or b as is if it is not JSON.
+func writeJSON(w io.Writer, b []byte) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, b, "", "  "); err != nil {
+		_, _ = w.Write(b)
+		_, _ = fmt.Fprintln(w)
+		return
+	}
+	_, _ = fmt.Fprintln(w, buf.String())
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/webbgeorge/lambdah/lambdahtest"
+)
+
+// syncBuffer is a buffer which the function process can write logs to
+// concurrently with the command.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func writeEvent(t *testing.T, event interface{}) string {
+	b, err := json.Marshal(event)
+	assert.Nil(t, err)
+	path := filepath.Join(t.TempDir(), "event.json")
+	assert.Nil(t, ioutil.WriteFile(path, b, 0600))
+	return path
+}
+
+func TestInvoke(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &syncBuffer{}
+	opts := invokeOptions{
+		event:      writeEvent(t, lambdahtest.HTTPRequest("GET", "/books/123").Route("/books/{id}").APIGatewayProxy()),
+		source:     "testdata/books",
+		requestID:  "request-1",
+		timeout:    time.Second,
+		memorySize: 256,
+	}
+
+	err := invoke(context.Background(), opts, nil, stdout, stderr)
+
+	assert.Nil(t, err)
+	var res events.APIGatewayProxyResponse
+	assert.Nil(t, json.Unmarshal(stdout.Bytes(), &res))
+	assert.Equal(t, 200, res.StatusCode)
+	var book bookResponse
+	assert.Nil(t, json.Unmarshal([]byte(res.Body), &book))
+	assert.Equal(t, "123", book.ID)
+	assert.Equal(t, "books", book.Function)
+
+	assert.Contains(t, stderr.String(), "Invoking function 'books' with api-gateway event\n")
+	assert.Contains(t, stderr.String(), "START RequestId: request-1 Version: $LATEST\n")
+	assert.Contains(t, stderr.String(), "END RequestId: request-1\n")
+	assert.Contains(t, stderr.String(), "REPORT RequestId: request-1\tDuration: ")
+	assert.Contains(t, stderr.String(), "Memory Size: 256 MB\n")
+}
+
+func TestInvoke_Stdin(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &syncBuffer{}
+	opts := invokeOptions{
+		event:   "-",
+		source:  "testdata/books",
+		timeout: time.Second,
+	}
+	stdin := strings.NewReader(`{"httpMethod":"GET","path":"/books/456","requestContext":{}}`)
+
+	err := invoke(context.Background(), opts, stdin, stdout, stderr)
+
+	assert.Nil(t, err)
+	assert.Contains(t, stdout.String(), `\"id\":\"456\"`)
+}
+
+func TestInvoke_FunctionError(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &syncBuffer{}
+	opts := invokeOptions{
+		event:   writeEvent(t, lambdahtest.HTTPRequest("POST", "/panic").APIGatewayProxy()),
+		source:  "testdata/books",
+		timeout: time.Second,
+	}
+
+	err := invoke(context.Background(), opts, nil, stdout, stderr)
+
+	assert.EqualError(t, err, "function returned an error: string: something went wrong")
+	var fnErr lambdahtest.FunctionError
+	assert.Nil(t, json.Unmarshal(stdout.Bytes(), &fnErr))
+	assert.Equal(t, "something went wrong", fnErr.Message)
+	assert.Equal(t, "string", fnErr.Type)
+	assert.NotEmpty(t, fnErr.StackTrace)
+}
+
+func TestInvoke_Timeout(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &syncBuffer{}
+	opts := invokeOptions{
+		event:   writeEvent(t, lambdahtest.HTTPRequest("GET", "/slow").APIGatewayProxy()),
+		source:  "testdata/books",
+		timeout: 100 * time.Millisecond,
+	}
+
+	err := invoke(context.Background(), opts, nil, stdout, stderr)
+
+	assert.EqualError(t, err, "function returned an error: Sandbox.Timedout: Task timed out after 0.10 seconds")
+}
+
+func TestInvoke_InvalidEvent(t *testing.T) {
+	opts := invokeOptions{event: "-", source: "testdata/books"}
+
+	err := invoke(context.Background(), opts, strings.NewReader("{"), &bytes.Buffer{}, &syncBuffer{})
+
+	assert.EqualError(t, err, "event is not valid JSON")
+}
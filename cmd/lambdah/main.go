@@ -0,0 +1,128 @@
+// Command lambdah is a tool for developing Lambdah handlers locally.
+//
+// Usage:
+//
+//	lambdah <command> [flags]
+//
+// The commands are:
+//
+//	serve            serve API Gateway proxy handlers on a local HTTP server
+//	invoke           invoke a handler with a JSON event
+//	generate-event   generate a sample event of a supported trigger
+//
+// Run `lambdah <command> -h` for the flags of a command.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const usage = `Usage: lambdah <command> [flags]
+
+Commands:
+  serve            serve API Gateway proxy handlers on a local HTTP server
+  invoke           invoke a handler with a JSON event
+  generate-event   generate a sample event of a supported trigger
+
+Run 'lambdah <command> -h' for the flags of a command.
+`
+
+type command func(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error
+
+var commands = map[string]command{
+	"serve":          serveCommand,
+	"invoke":         invokeCommand,
+	"generate-event": generateEventCommand,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		_, _ = fmt.Fprint(stderr, usage)
+		return 2
+	}
+	if args[0] == "-h" || args[0] == "-help" || args[0] == "--help" || args[0] == "help" {
+		_, _ = fmt.Fprint(stdout, usage)
+		return 0
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "lambdah: unknown command '%s'\n\n%s", args[0], usage)
+		return 2
+	}
+
+	err := cmd(args[1:], stdin, stdout, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		if _, ok := err.(usageError); !ok {
+			_, _ = fmt.Fprintf(stderr, "lambdah %s: %s\n", args[0], err.Error())
+		}
+		return exitCode(err)
+	}
+	return 0
+}
+
+// usageError is returned for invalid flags or arguments, which the flag set has
+// already reported.
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string {
+	return e.err.Error()
+}
+
+func exitCode(err error) int {
+	if _, ok := err.(usageError); ok {
+		return 2
+	}
+	return 1
+}
+
+// newFlagSet returns a flag set for a command, which reports errors to stderr
+// rather than exiting.
+func newFlagSet(name string, stderr io.Writer, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet("lambdah "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		_, _ = fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args, wrapping errors as usage errors.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return usageError{err}
+	}
+	return err
+}
+
+// keyValueFlag is a repeatable flag of `key=value` pairs.
+type keyValueFlag map[string]string
+
+func (f keyValueFlag) String() string {
+	return fmt.Sprint(map[string]string(f))
+}
+
+func (f keyValueFlag) Set(value string) error {
+	k, v, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("expected key=value, got '%s'", value)
+	}
+	f[k] = v
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun_Usage(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := run(nil, nil, stdout, stderr)
+
+	assert.Equal(t, 2, code)
+	assert.Equal(t, usage, stderr.String())
+}
+
+func TestRun_Help(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := run([]string{"help"}, nil, stdout, stderr)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, usage, stdout.String())
+}
+
+func TestRun_UnknownCommand(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := run([]string{"deploy"}, nil, stdout, stderr)
+
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr.String(), "lambdah: unknown command 'deploy'")
+}
+
+func TestRun_CommandHelp(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := run([]string{"serve", "-h"}, nil, stdout, stderr)
+
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stderr.String(), "Usage: lambdah serve [flags] [package]")
+}
+
+func TestRun_InvalidFlag(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := run([]string{"serve", "-stage-var", "invalid"}, nil, stdout, stderr)
+
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr.String(), "expected key=value, got 'invalid'")
+}
+
+func TestRun_CommandError(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := run([]string{"serve", "-addr", "127.0.0.1:0", "-template", "testdata/missing.yaml"}, nil, stdout, stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "lambdah serve: open testdata/missing.yaml: no such file or directory")
+}
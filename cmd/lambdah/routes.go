@@ -0,0 +1,378 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/webbgeorge/lambdah/lambdahtest"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	methodAny         = "ANY"
+	defaultMemorySize = 128
+)
+
+var integrationFunctionRegexp = regexp.MustCompile(`\$\{([A-Za-z0-9]+)\.Arn\}`)
+
+// routeTable is the API Gateway resources served, and the functions they are
+// integrated with.
+type routeTable struct {
+	routes         []*route
+	functions      map[string]*functionConfig
+	stage          string
+	stageVariables map[string]string
+}
+
+// route is a method of an API Gateway resource, such as `GET /books/{id}`, which
+// is integrated with a function using a Lambda proxy integration.
+type route struct {
+	method   string
+	resource string
+	function string
+	pattern  *regexp.Regexp
+	params   []string
+}
+
+// functionConfig is the configuration of a Lambda function. The source is the
+// directory of its Go package, or a compiled binary.
+type functionConfig struct {
+	name       string
+	source     string
+	timeout    time.Duration
+	memorySize int
+	env        map[string]string
+}
+
+// proxyRouteTable returns a route table which sends every request to a single
+// function, as a greedy `/{proxy+}` resource does. Routing is left to the
+// function, such as with an api_gateway_proxy.Router.
+func proxyRouteTable(source string) (*routeTable, error) {
+	fn := newFunctionConfig(functionName(source), source)
+	table := &routeTable{functions: map[string]*functionConfig{fn.name: fn}}
+	for _, resource := range []string{"/", "/{proxy+}"} {
+		if err := table.add(methodAny, resource, fn.name); err != nil {
+			return nil, err
+		}
+	}
+	return table, nil
+}
+
+// loadRouteTable reads a route table from a SAM template, or from the `paths` of
+// an OpenAPI definition. Routes of an OpenAPI definition are integrated with the
+// function of the given source, unless their integration URI refers to a function
+// of the template.
+func loadRouteTable(path string, source string) (*routeTable, error) {
+	b, err := ioutil.ReadFile(path) // #nosec G304 -- template path given on the command line
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse '%s': %w", path, err)
+	}
+
+	table := &routeTable{functions: map[string]*functionConfig{}}
+	if source != "" {
+		fn := newFunctionConfig(functionName(source), source)
+		table.functions[fn.name] = fn
+	}
+
+	switch {
+	case doc["Resources"] != nil:
+		err = table.addSAMTemplate(doc, filepath.Dir(path))
+	case doc["paths"] != nil:
+		err = table.addOpenAPIPaths(mapValue(doc, "paths"), functionName(source))
+	default:
+		err = fmt.Errorf("'%s' is neither a SAM template nor an OpenAPI definition", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(table.routes) == 0 {
+		return nil, fmt.Errorf("'%s' has no API Gateway routes", path)
+	}
+	return table, nil
+}
+
+func (t *routeTable) addSAMTemplate(doc map[string]interface{}, dir string) error {
+	globals := mapValue(mapValue(doc, "Globals"), "Function")
+	resources := mapValue(doc, "Resources")
+
+	// functions are added first, so that API definitions can refer to them
+	for _, name := range sortedKeys(resources) {
+		resource := mapValue(resources, name)
+		if stringValue(resource["Type"]) != "AWS::Serverless::Function" {
+			continue
+		}
+		props := mapValue(resource, "Properties")
+		codeURI := stringValue(props["CodeUri"])
+		if codeURI == "" {
+			codeURI = stringValue(globals["CodeUri"])
+		}
+		fn := newFunctionConfig(name, filepath.Join(dir, codeURI))
+		if timeout := intValue(props["Timeout"], intValue(globals["Timeout"], 0)); timeout > 0 {
+			fn.timeout = time.Duration(timeout) * time.Second
+		}
+		fn.memorySize = intValue(props["MemorySize"], intValue(globals["MemorySize"], defaultMemorySize))
+		for k, v := range mapValue(mapValue(globals, "Environment"), "Variables") {
+			fn.env[k] = stringValue(v)
+		}
+		for k, v := range mapValue(mapValue(props, "Environment"), "Variables") {
+			fn.env[k] = stringValue(v)
+		}
+		t.functions[name] = fn
+
+		events := mapValue(props, "Events")
+		for _, eventName := range sortedKeys(events) {
+			event := mapValue(events, eventName)
+			if stringValue(event["Type"]) != "Api" {
+				continue
+			}
+			eventProps := mapValue(event, "Properties")
+			if err := t.add(stringValue(eventProps["Method"]), stringValue(eventProps["Path"]), name); err != nil {
+				return fmt.Errorf("event '%s' of function '%s': %w", eventName, name, err)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(resources) {
+		resource := mapValue(resources, name)
+		if stringValue(resource["Type"]) != "AWS::Serverless::Api" {
+			continue
+		}
+		props := mapValue(resource, "Properties")
+		if t.stage == "" {
+			t.stage = stringValue(props["StageName"])
+		}
+		if variables := mapValue(props, "Variables"); len(variables) > 0 && t.stageVariables == nil {
+			t.stageVariables = make(map[string]string, len(variables))
+			for k, v := range variables {
+				t.stageVariables[k] = stringValue(v)
+			}
+		}
+		if paths := mapValue(mapValue(props, "DefinitionBody"), "paths"); paths != nil {
+			if err := t.addOpenAPIPaths(paths, ""); err != nil {
+				return fmt.Errorf("API '%s': %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (t *routeTable) addOpenAPIPaths(paths map[string]interface{}, defaultFunction string) error {
+	for _, path := range sortedKeys(paths) {
+		operations := mapValue(paths, path)
+		for _, method := range sortedKeys(operations) {
+			if strings.EqualFold(method, "parameters") {
+				continue
+			}
+			function := integrationFunction(mapValue(operations, method))
+			if _, ok := t.functions[function]; !ok {
+				function = defaultFunction
+			}
+			if function == "" {
+				return fmt.Errorf("no function for '%s %s', as its integration is not a function of the template", method, path)
+			}
+			if err := t.add(method, path, function); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// add a route to the table, unless it is already routed.
+func (t *routeTable) add(method string, resource string, function string) error {
+	method = strings.ToUpper(method)
+	if method == "" || method == "X-AMAZON-APIGATEWAY-ANY-METHOD" {
+		method = methodAny
+	}
+	for _, rt := range t.routes {
+		if rt.method == method && rt.resource == resource {
+			return nil
+		}
+	}
+
+	rt, err := newRoute(method, resource, function)
+	if err != nil {
+		return err
+	}
+	t.routes = append(t.routes, rt)
+	sort.SliceStable(t.routes, func(i, j int) bool {
+		return t.routes[i].precedes(t.routes[j])
+	})
+	return nil
+}
+
+// match returns the route of a request, and the values of its path parameters.
+// Routes are matched in the order of API Gateway, which prefers static resources
+// to those with path parameters, and those to greedy resources.
+func (t *routeTable) match(method string, path string) (*route, map[string]string) {
+	for _, rt := range t.routes {
+		if rt.method != methodAny && rt.method != method {
+			continue
+		}
+		matches := rt.pattern.FindStringSubmatch(path)
+		if matches == nil {
+			continue
+		}
+		params := make(map[string]string, len(rt.params))
+		for i, name := range rt.params {
+			params[name] = matches[i+1]
+		}
+		return rt, params
+	}
+	return nil, nil
+}
+
+// newRoute compiles the resource path of a route, where path parameters such as
+// `{id}` match a single path segment, and greedy path parameters such as
+// `{proxy+}` match the rest of the path.
+func newRoute(method string, resource string, function string) (*route, error) {
+	if !strings.HasPrefix(resource, "/") {
+		return nil, fmt.Errorf("invalid resource path '%s'", resource)
+	}
+	rt := &route{method: method, resource: resource, function: function}
+
+	pattern := "^"
+	segments := strings.Split(strings.TrimPrefix(resource, "/"), "/")
+	for i, segment := range segments {
+		if i > 0 || segment != "" {
+			pattern += "/"
+		}
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			pattern += regexp.QuoteMeta(segment)
+			continue
+		}
+		name := segment[1 : len(segment)-1]
+		if strings.HasSuffix(name, "+") {
+			if i != len(segments)-1 {
+				return nil, fmt.Errorf("invalid resource path '%s', greedy path parameters must be last", resource)
+			}
+			name = strings.TrimSuffix(name, "+")
+			pattern += "(.+)"
+		} else {
+			pattern += "([^/]+)"
+		}
+		rt.params = append(rt.params, name)
+	}
+
+	var err error
+	rt.pattern, err = regexp.Compile(pattern + "$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource path '%s': %w", resource, err)
+	}
+	return rt, nil
+}
+
+// precedes reports whether rt is matched before other. Resources are compared by
+// segment, where static segments precede path parameters, which precede greedy
+// path parameters, and methods precede ANY.
+func (rt *route) precedes(other *route) bool {
+	segments := strings.Split(rt.resource, "/")
+	otherSegments := strings.Split(other.resource, "/")
+	for i := 0; i < len(segments) && i < len(otherSegments); i++ {
+		kind, otherKind := segmentKind(segments[i]), segmentKind(otherSegments[i])
+		if kind != otherKind {
+			return kind < otherKind
+		}
+	}
+	if rt.method != other.method {
+		return rt.method != methodAny && other.method == methodAny
+	}
+	return false
+}
+
+// segmentKind orders static segments, path parameters and greedy path parameters.
+func segmentKind(segment string) int {
+	switch {
+	case !strings.HasPrefix(segment, "{"):
+		return 0
+	case strings.HasSuffix(segment, "+}"):
+		return 2
+	default:
+		return 1
+	}
+}
+
+func newFunctionConfig(name string, source string) *functionConfig {
+	return &functionConfig{
+		name:       name,
+		source:     source,
+		timeout:    lambdahtest.DefaultTimeout,
+		memorySize: defaultMemorySize,
+		env:        map[string]string{},
+	}
+}
+
+func functionName(source string) string {
+	if source == "" {
+		return ""
+	}
+	abs, err := filepath.Abs(source)
+	if err != nil {
+		return filepath.Base(source)
+	}
+	return filepath.Base(abs)
+}
+
+// integrationFunction returns the logical ID of the function of an OpenAPI
+// operation's Lambda integration, such as `BooksFunction` of
+// `arn:aws:apigateway:${AWS::Region}:lambda:path/2015-03-31/functions/${BooksFunction.Arn}/invocations`.
+func integrationFunction(operation map[string]interface{}) string {
+	uri := mapValue(operation, "x-amazon-apigateway-integration")["uri"]
+	if sub, ok := uri.(map[string]interface{}); ok {
+		uri = sub["Fn::Sub"]
+	}
+	matches := integrationFunctionRegexp.FindStringSubmatch(stringValue(uri))
+	if matches == nil {
+		return ""
+	}
+	return matches[1]
+}
+
+func mapValue(m map[string]interface{}, key string) map[string]interface{} {
+	v, _ := m[key].(map[string]interface{})
+	return v
+}
+
+func stringValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func intValue(v interface{}, def int) int {
+	switch v := v.(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	case string:
+		if i, err := strconv.Atoi(v); err == nil {
+			return i
+		}
+	}
+	return def
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/webbgeorge/lambdah/lambdahtest"
+)
+
+type routeInfo struct {
+	method   string
+	resource string
+	function string
+}
+
+func routeInfos(table *routeTable) []routeInfo {
+	infos := make([]routeInfo, len(table.routes))
+	for i, rt := range table.routes {
+		infos[i] = routeInfo{rt.method, rt.resource, rt.function}
+	}
+	return infos
+}
+
+func TestLoadRouteTable_SAMTemplate(t *testing.T) {
+	table, err := loadRouteTable("testdata/template.yaml", "")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []routeInfo{
+		{"GET", "/books/{id}", "BooksFunction"},
+		{"POST", "/panic", "BooksFunction"},
+		{"GET", "/slow", "BooksFunction"},
+		{"GET", "/authors/{id}", "BooksFunction"},
+	}, routeInfos(table))
+	assert.Equal(t, "Prod", table.stage)
+	assert.Equal(t, map[string]string{"env": "dev"}, table.stageVariables)
+	assert.Equal(t, &functionConfig{
+		name:       "BooksFunction",
+		source:     filepath.Join("testdata", "books"),
+		timeout:    time.Second,
+		memorySize: 256,
+		env:        map[string]string{"LOG_LEVEL": "debug", "TABLE_NAME": "books"},
+	}, table.functions["BooksFunction"])
+}
+
+func TestLoadRouteTable_OpenAPI(t *testing.T) {
+	table, err := loadRouteTable("testdata/openapi.yaml", "testdata/books")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []routeInfo{
+		{"GET", "/books", "books"},
+		{"POST", "/books", "books"},
+		{"ANY", "/books/{id}", "books"},
+		{"OPTIONS", "/{proxy+}", "books"},
+	}, routeInfos(table))
+	assert.Equal(t, lambdahtest.DefaultTimeout, table.functions["books"].timeout)
+}
+
+func TestLoadRouteTable_OpenAPIWithoutSource(t *testing.T) {
+	_, err := loadRouteTable("testdata/openapi.yaml", "")
+
+	assert.EqualError(t, err, "no function for 'get /books', as its integration is not a function of the template")
+}
+
+func TestLoadRouteTable_NotATemplate(t *testing.T) {
+	_, err := loadRouteTable("testdata/books/main.go", "")
+
+	assert.NotNil(t, err)
+}
+
+func TestProxyRouteTable(t *testing.T) {
+	table, err := proxyRouteTable("testdata/books")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []routeInfo{
+		{"ANY", "/", "books"},
+		{"ANY", "/{proxy+}", "books"},
+	}, routeInfos(table))
+	assert.Equal(t, "testdata/books", table.functions["books"].source)
+}
+
+func TestRouteTable_Match(t *testing.T) {
+	table := &routeTable{}
+	assert.Nil(t, table.add("ANY", "/{proxy+}", "proxy"))
+	assert.Nil(t, table.add("GET", "/books/{id}", "getBook"))
+	assert.Nil(t, table.add("ANY", "/books/{id}", "anyBook"))
+	assert.Nil(t, table.add("GET", "/books/latest", "latestBook"))
+	assert.Nil(t, table.add("GET", "/books/{id}/files/{path+}", "bookFile"))
+
+	testCases := []struct {
+		method   string
+		path     string
+		function string
+		params   map[string]string
+	}{
+		{"GET", "/books/latest", "latestBook", map[string]string{}},
+		{"GET", "/books/123", "getBook", map[string]string{"id": "123"}},
+		{"PUT", "/books/123", "anyBook", map[string]string{"id": "123"}},
+		{"GET", "/books/123/files/a/b.txt", "bookFile", map[string]string{"id": "123", "path": "a/b.txt"}},
+		{"POST", "/authors/1", "proxy", map[string]string{"proxy": "authors/1"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			rt, params := table.match(tc.method, tc.path)
+			assert.Equal(t, tc.function, rt.function)
+			assert.Equal(t, tc.params, params)
+		})
+	}
+}
+
+func TestRouteTable_Match_NotFound(t *testing.T) {
+	table := &routeTable{}
+	assert.Nil(t, table.add("GET", "/books/{id}", "getBook"))
+
+	rt, _ := table.match("GET", "/books")
+
+	assert.Nil(t, rt)
+}
+
+func TestNewRoute_InvalidGreedyPathParameter(t *testing.T) {
+	_, err := newRoute("GET", "/{proxy+}/books", "proxy")
+
+	assert.EqualError(t, err, "invalid resource path '/{proxy+}/books', greedy path parameters must be last")
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+)
+
+const serveUsage = `Usage: lambdah serve [flags] [package]
+
+Serve API Gateway proxy handlers on a local HTTP server, simulating an API
+Gateway REST API with Lambda proxy integrations.
+
+Routes are read from a SAM template or OpenAPI definition with -template.
+Without a template, every request is sent to the function of the package, as
+with a greedy /{proxy+} resource, so routes registered in Go with an
+api_gateway_proxy.Router are served.
+
+Functions are built from their Go package, or run from a compiled binary, and
+are rebuilt and restarted when their source files or binary change.
+
+Flags:
+`
+
+type serveOptions struct {
+	addr           string
+	template       string
+	source         string
+	stage          string
+	stageVariables keyValueFlag
+	claims         keyValueFlag
+	watch          bool
+	watchInterval  time.Duration
+}
+
+func serveCommand(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	opts := serveOptions{
+		stageVariables: keyValueFlag{},
+		claims:         keyValueFlag{},
+	}
+	fs := newFlagSet("serve", stderr, serveUsage)
+	fs.StringVar(&opts.addr, "addr", "localhost:3000", "`address` to listen on")
+	fs.StringVar(&opts.template, "template", "", "SAM template or OpenAPI definition of the routes")
+	fs.StringVar(&opts.stage, "stage", "", "API Gateway stage name (default the template's StageName, or test)")
+	fs.Var(opts.stageVariables, "stage-var", "stage variable as `name=value`, may be repeated")
+	fs.Var(opts.claims, "claim", "authorizer claim as `name=value`, may be repeated")
+	fs.BoolVar(&opts.watch, "watch", true, "rebuild and restart functions when they change")
+	fs.DurationVar(&opts.watchInterval, "watch-interval", time.Second, "interval to check functions for changes")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return usageError{errors.New("too many arguments")}
+	}
+	opts.source = fs.Arg(0)
+	if opts.template == "" && opts.source == "" {
+		opts.source = "."
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ln, err := net.Listen("tcp", opts.addr)
+	if err != nil {
+		return err
+	}
+	return serve(ctx, ln, opts, stdout, stderr)
+}
+
+// serve builds the functions of the route table, and serves requests to them on ln
+// until ctx is done.
+func serve(ctx context.Context, ln net.Listener, opts serveOptions, stdout io.Writer, stderr io.Writer) error {
+	var table *routeTable
+	var err error
+	if opts.template != "" {
+		table, err = loadRouteTable(opts.template, opts.source)
+	} else {
+		table, err = proxyRouteTable(opts.source)
+	}
+	if err != nil {
+		return err
+	}
+
+	buildDir, err := ioutil.TempDir("", "lambdah-serve-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(buildDir)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	functions := make(map[string]invoker, len(table.functions))
+	for _, name := range sortedFunctionNames(table) {
+		fn := newFunction(table.functions[name], buildDir, stderr)
+		_, _ = fmt.Fprintf(stdout, "Building function '%s' from %s\n", name, fn.config.source)
+		if err := fn.build(); err != nil {
+			return err
+		}
+		defer fn.close()
+		if opts.watch {
+			go fn.watch(ctx, opts.watchInterval)
+		}
+		functions[name] = fn
+	}
+
+	g := &gateway{
+		routes:         table,
+		functions:      functions,
+		stage:          table.stage,
+		stageVariables: table.stageVariables,
+		claims:         opts.claims,
+		maxPayloadSize: maxPayloadSize,
+		timeout:        integrationTimeout,
+		logs:           stderr,
+	}
+	if opts.stage != "" {
+		g.stage = opts.stage
+	}
+	if len(opts.stageVariables) > 0 {
+		g.stageVariables = opts.stageVariables
+	}
+
+	for _, rt := range table.routes {
+		_, _ = fmt.Fprintf(stdout, "Mounted %s %s -> %s\n", rt.method, rt.resource, rt.function)
+	}
+	_, _ = fmt.Fprintf(stdout, "Serving on http://%s\n", ln.Addr().String())
+
+	server := &http.Server{Handler: g, ReadHeaderTimeout: 10 * time.Second}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(ln)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelShutdown()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return server.Close()
+		}
+		return nil
+	}
+}
+
+func sortedFunctionNames(table *routeTable) []string {
+	names := make([]string, 0, len(table.functions))
+	for name := range table.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
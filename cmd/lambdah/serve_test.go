@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type bookResponse struct {
+	ID       string `json:"id"`
+	Resource string `json:"resource"`
+	Stage    string `json:"stage"`
+	Env      string `json:"env"`
+	Sub      string `json:"sub"`
+	Function string `json:"function"`
+	PID      int    `json:"pid"`
+}
+
+// startServer serves the functions of opts, and returns the URL of the server.
+func startServer(t *testing.T, opts serveOptions) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(ctx, ln, opts, ioutil.Discard, ioutil.Discard)
+	}()
+	t.Cleanup(func() {
+		http.DefaultClient.CloseIdleConnections()
+		cancel()
+		assert.Nil(t, <-done)
+	})
+
+	url := "http://" + ln.Addr().String()
+	// wait for the functions to be built
+	assert.Eventually(t, func() bool {
+		res, err := http.Get(url + "/ready")
+		if err != nil {
+			return false
+		}
+		_ = res.Body.Close()
+		return true
+	}, time.Minute, 50*time.Millisecond)
+	return url
+}
+
+func getBook(t *testing.T, url string) bookResponse {
+	res, err := http.Get(url + "/books/123")
+	assert.Nil(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var book bookResponse
+	assert.Nil(t, json.NewDecoder(res.Body).Decode(&book))
+	return book
+}
+
+// tryGetBook gets the book without asserting the response, for polling while the
+// function restarts, when requests may fail with a 502.
+func tryGetBook(url string) (bookResponse, bool) {
+	var book bookResponse
+	res, err := http.Get(url + "/books/123")
+	if err != nil {
+		return book, false
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return book, false
+	}
+	return book, json.NewDecoder(res.Body).Decode(&book) == nil
+}
+
+func TestServe_Template(t *testing.T) {
+	url := startServer(t, serveOptions{
+		template: "testdata/template.yaml",
+		claims:   keyValueFlag{"sub": "user-1"},
+	})
+
+	book := getBook(t, url)
+
+	assert.Equal(t, "123", book.ID)
+	assert.Equal(t, "/books/{id}", book.Resource)
+	assert.Equal(t, "Prod", book.Stage)
+	assert.Equal(t, "dev", book.Env)
+	assert.Equal(t, "user-1", book.Sub)
+	assert.Equal(t, "BooksFunction", book.Function)
+	// the same process handles the next request
+	assert.Equal(t, book.PID, getBook(t, url).PID)
+
+	res, err := http.Get(url + "/slow")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadGateway, res.StatusCode)
+}
+
+func TestServe_Proxy(t *testing.T) {
+	url := startServer(t, serveOptions{
+		source:         "testdata/books",
+		stage:          "dev",
+		stageVariables: keyValueFlag{"env": "local"},
+	})
+
+	book := getBook(t, url)
+
+	assert.Equal(t, "123", book.ID)
+	assert.Equal(t, "/{proxy+}", book.Resource)
+	assert.Equal(t, "dev", book.Stage)
+	assert.Equal(t, "local", book.Env)
+	assert.Equal(t, "books", book.Function)
+}
+
+func TestServe_RestartsAfterPanic(t *testing.T) {
+	url := startServer(t, serveOptions{source: "testdata/books"})
+	pid := getBook(t, url).PID
+
+	res, err := http.Post(url+"/panic", "application/json", bytes.NewReader(nil))
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadGateway, res.StatusCode)
+	assert.NotEqual(t, pid, getBook(t, url).PID)
+}
+
+func TestServe_Watch(t *testing.T) {
+	url := startServer(t, serveOptions{
+		source:        "testdata/books",
+		watch:         true,
+		watchInterval: 50 * time.Millisecond,
+	})
+	pid := getBook(t, url).PID
+
+	now := time.Now()
+	assert.Nil(t, os.Chtimes("testdata/books/main.go", now, now))
+
+	assert.Eventually(t, func() bool {
+		book, ok := tryGetBook(url)
+		return ok && book.PID != pid
+	}, time.Minute, 100*time.Millisecond)
+	assert.NotEqual(t, pid, getBook(t, url).PID)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/webbgeorge/lambdah/api_gateway_proxy"
+)
+
+type book struct {
+	ID       string `json:"id"`
+	Resource string `json:"resource"`
+	Stage    string `json:"stage"`
+	Env      string `json:"env"`
+	Sub      string `json:"sub"`
+	Function string `json:"function"`
+	PID      int    `json:"pid"`
+}
+
+func main() {
+	r := api_gateway_proxy.NewRouter()
+	r.GET("/books/{id}", func(c *api_gateway_proxy.Context) error {
+		var sub string
+		if claims, ok := c.Request.RequestContext.Authorizer["claims"].(map[string]interface{}); ok {
+			sub, _ = claims["sub"].(string)
+		}
+		return c.JSON(http.StatusOK, book{
+			ID:       c.Request.PathParameters["id"],
+			Resource: c.Request.Resource,
+			Stage:    c.Request.RequestContext.Stage,
+			Env:      c.Request.StageVariables["env"],
+			Sub:      sub,
+			Function: os.Getenv("AWS_LAMBDA_FUNCTION_NAME"),
+			PID:      os.Getpid(),
+		})
+	})
+	r.GET("/slow", func(c *api_gateway_proxy.Context) error {
+		time.Sleep(2 * time.Second)
+		return c.JSON(http.StatusOK, nil)
+	})
+	r.POST("/panic", func(c *api_gateway_proxy.Context) error {
+		panic("something went wrong")
+	})
+	r.HandlerFunc().Start()
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"encoding/json"
 
+	"github.com/webbgeorge/lambdah"
+	"github.com/webbgeorge/lambdah/internal/batch"
+	"github.com/webbgeorge/lambdah/internal/clone"
+
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
@@ -64,6 +68,47 @@ func (hf HandlerFunc) ToLambdaHandler() func(ctx context.Context, event events.D
 	}
 }
 
+// ConcurrentHandler processes the records of each event concurrently, see HandlerFunc.Concurrent.
+type ConcurrentHandler struct {
+	handler batch.Handler[Context]
+}
+
+// Process the records of each event concurrently, using a pool of at most
+// concurrency workers. Each record is given its own *Context.
+//
+// All records are processed even if some fail, and the errors are returned
+// together as a lambdah.BatchError. If the Lambda deadline is reached, no further
+// records are started and each remaining record fails with the context's error.
+func (hf HandlerFunc) Concurrent(concurrency int) ConcurrentHandler {
+	return ConcurrentHandler{
+		handler: batch.Handler[Context]{HandlerFunc: hf, Concurrency: concurrency},
+	}
+}
+
+func (ch ConcurrentHandler) Start() {
+	lambda.Start(ch.ToLambdaHandler())
+}
+
+// Get the AWS Lambda handler of the concurrent handler.
+//
+// Useful if you need to call AWS lambda.Start(...) directly,
+// not required in most cases.
+func (ch ConcurrentHandler) ToLambdaHandler() func(ctx context.Context, event events.DynamoDBEvent) error {
+	return func(ctx context.Context, event events.DynamoDBEvent) error {
+		errs := ch.process(ctx, event)
+		return lambdah.NewBatchError(errs)
+	}
+}
+
+func (ch ConcurrentHandler) process(ctx context.Context, event events.DynamoDBEvent) []error {
+	return ch.handler.Process(ctx, len(event.Records), func(i int) *Context {
+		return &Context{
+			Context:     ctx,
+			EventRecord: event.Records[i],
+		}
+	})
+}
+
 func unmarshalStreamImage(attributes map[string]events.DynamoDBAttributeValue, out interface{}) error {
 	sdkAttributeMap := make(map[string]*dynamodb.AttributeValue)
 
@@ -83,5 +128,21 @@ func unmarshalStreamImage(attributes map[string]events.DynamoDBAttributeValue, o
 		sdkAttributeMap[k] = &sdkAttribute
 	}
 
-	return dynamodbattribute.UnmarshalMap(sdkAttributeMap, out)
+	err := dynamodbattribute.UnmarshalMap(sdkAttributeMap, out)
+	if err != nil {
+		return err
+	}
+
+	return lambdah.Validate(out)
+}
+
+// clone returns a copy of the context which shares no maps or slices with c, so
+// that a handler left running in the background after a timeout does not race
+// with earlier middleware.
+func (c *Context) clone() Context {
+	tc := *c
+	tc.EventRecord.Change.Keys = clone.Map(c.EventRecord.Change.Keys)
+	tc.EventRecord.Change.NewImage = clone.Map(c.EventRecord.Change.NewImage)
+	tc.EventRecord.Change.OldImage = clone.Map(c.EventRecord.Change.OldImage)
+	return tc
 }
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+
+	lambdah "github.com/webbgeorge/lambdah/api_gateway_proxy"
+)
+
+func main() {
+	newHandler().Start()
+}
+
+func newHandler() lambdah.HandlerFunc {
+	r := lambdah.NewRouter()
+	r.GET("/books", listBooks)
+	r.GET("/books/{bookID}", getBook)
+
+	admin := r.Group("/admin", requireAdmin())
+	admin.DELETE("/books/{bookID}", deleteBook)
+
+	return r.HandlerFunc().Middleware(lambdah.ErrorHandlerMiddleware())
+}
+
+var books = map[string]book{
+	"1": {ID: "1", Title: "Nineteen Eighty-Four"},
+	"2": {ID: "2", Title: "Brave New World"},
+}
+
+func listBooks(c *lambdah.Context) error {
+	list := make([]book, 0)
+	for _, id := range []string{"1", "2"} {
+		list = append(list, books[id])
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+func getBook(c *lambdah.Context) error {
+	b, ok := books[c.Request.PathParameters["bookID"]]
+	if !ok {
+		return lambdah.Error{
+			StatusCode: http.StatusNotFound,
+			Message:    "Book not found",
+		}
+	}
+	return c.JSON(http.StatusOK, b)
+}
+
+func deleteBook(c *lambdah.Context) error {
+	return c.JSON(http.StatusNoContent, nil)
+}
+
+// example: only allow requests with an admin API key
+func requireAdmin() lambdah.Middleware {
+	return func(h lambdah.HandlerFunc) lambdah.HandlerFunc {
+		return func(c *lambdah.Context) error {
+			if c.Request.Headers["X-Api-Key"] != "admin" {
+				return lambdah.Error{
+					StatusCode: http.StatusForbidden,
+					Message:    "Forbidden",
+				}
+			}
+			return h(c)
+		}
+	}
+}
+
+type book struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/steinfletcher/apitest"
+	"github.com/steinfletcher/apitest-jsonpath"
+)
+
+func TestNewHandler_ListBooks(t *testing.T) {
+	httpHandler := newHandler().
+		ToHttpHandler("/{proxy+}", nil)
+
+	apitest.New().
+		Handler(httpHandler).
+		Get("/books").
+		Expect(t).
+		Status(http.StatusOK).
+		Assert(jsonpath.Len(`$`, 2)).
+		End()
+}
+
+func TestNewHandler_GetBook(t *testing.T) {
+	httpHandler := newHandler().
+		ToHttpHandler("/{proxy+}", nil)
+
+	apitest.New().
+		Handler(httpHandler).
+		Get("/books/2").
+		Expect(t).
+		Status(http.StatusOK).
+		Assert(jsonpath.Equal(`$.title`, "Brave New World")).
+		End()
+}
+
+func TestNewHandler_BookNotFound(t *testing.T) {
+	httpHandler := newHandler().
+		ToHttpHandler("/{proxy+}", nil)
+
+	apitest.New().
+		Handler(httpHandler).
+		Get("/books/3").
+		Expect(t).
+		Status(http.StatusNotFound).
+		Assert(jsonpath.Equal(`$.message`, "Book not found")).
+		End()
+}
+
+func TestNewHandler_AdminForbidden(t *testing.T) {
+	httpHandler := newHandler().
+		ToHttpHandler("/{proxy+}", nil)
+
+	apitest.New().
+		Handler(httpHandler).
+		Delete("/admin/books/1").
+		Expect(t).
+		Status(http.StatusForbidden).
+		End()
+}
+
+func TestNewHandler_AdminDeleteBook(t *testing.T) {
+	httpHandler := newHandler().
+		ToHttpHandler("/{proxy+}", nil)
+
+	apitest.New().
+		Handler(httpHandler).
+		Delete("/admin/books/1").
+		Header("X-Api-Key", "admin").
+		Expect(t).
+		Status(http.StatusNoContent).
+		End()
+}
+
+func TestNewHandler_MethodNotAllowed(t *testing.T) {
+	httpHandler := newHandler().
+		ToHttpHandler("/{proxy+}", nil)
+
+	apitest.New().
+		Handler(httpHandler).
+		Post("/books").
+		Expect(t).
+		Status(http.StatusMethodNotAllowed).
+		Header("Allow", "GET").
+		End()
+}
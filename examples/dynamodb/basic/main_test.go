@@ -5,8 +5,8 @@ import (
 	"context"
 	"testing"
 
-	"github.com/aws/aws-lambda-go/events"
 	"github.com/stretchr/testify/assert"
+	"github.com/webbgeorge/lambdah/lambdahtest"
 )
 
 func TestNewHandler_Success(t *testing.T) {
@@ -16,19 +16,11 @@ func TestNewHandler_Success(t *testing.T) {
 
 	err := h(
 		context.Background(),
-		events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{{
-			EventName: "dynamodb:test:event",
-			Change: events.DynamoDBStreamRecord{
-				NewImage: map[string]events.DynamoDBAttributeValue{
-					"name": events.NewStringAttribute("Dave"),
-					"age":  events.NewNumberAttribute("42"),
-				},
-			},
-		}}},
+		lambdahtest.DynamoDB().Insert(data{Name: "Dave", Age: 42}).Build(),
 	)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "dynamodb change of type 'dynamodb:test:event' for name 'Dave'", logMock.String())
+	assert.Equal(t, "dynamodb change of type 'INSERT' for name 'Dave'", logMock.String())
 }
 
 func TestNewHandler_Error(t *testing.T) {
@@ -38,15 +30,8 @@ func TestNewHandler_Error(t *testing.T) {
 
 	err := h(
 		context.Background(),
-		events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{{
-			EventName: "dynamodb:test:event",
-			Change: events.DynamoDBStreamRecord{
-				NewImage: map[string]events.DynamoDBAttributeValue{
-					// incorrect type
-					"name": events.NewBooleanAttribute(false),
-				},
-			},
-		}}},
+		// incorrect type
+		lambdahtest.DynamoDB().Insert(map[string]bool{"name": false}).Build(),
 	)
 
 	assert.NotNil(t, err)
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	lambdah "github.com/webbgeorge/lambdah/firehose"
+)
+
+func main() {
+	newHandler().Start()
+}
+
+// example: drop test orders, and transform the rest to a flat record
+func newHandler() lambdah.HandlerFunc {
+	return func(c *lambdah.Context) error {
+		var data orderData
+		err := c.Bind(&data)
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(data.OrderID, "test-") {
+			c.Drop()
+			return nil
+		}
+
+		return c.TransformJSON(flatOrder{
+			OrderID:    data.OrderID,
+			CustomerID: data.Customer.ID,
+			Total:      data.Total,
+		})
+	}
+}
+
+type orderData struct {
+	OrderID  string `json:"order_id"`
+	Customer struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"customer"`
+	Total float64 `json:"total"`
+}
+
+func (d *orderData) Validate() error {
+	if d.OrderID == "" {
+		return errors.New("order_id is required")
+	}
+	return nil
+}
+
+type flatOrder struct {
+	OrderID    string  `json:"order_id"`
+	CustomerID string  `json:"customer_id"`
+	Total      float64 `json:"total"`
+}
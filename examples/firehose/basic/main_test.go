@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/webbgeorge/lambdah/lambdahtest"
+)
+
+func TestNewHandler(t *testing.T) {
+	event := lambdahtest.Firehose().
+		Record([]byte(`{"order_id":"o-1","customer":{"id":"c-1","name":"Dave"},"total":9.99}`)).
+		Record([]byte(`{"order_id":"test-1","customer":{"id":"c-2","name":"Sarah"},"total":1}`)).
+		Record([]byte(`{"customer":{"id":"c-3","name":"Sam"}}`)).
+		Build()
+
+	h := newHandler().ToLambdaHandler()
+
+	res, err := h(context.Background(), event)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []events.KinesisFirehoseResponseRecord{
+		{
+			RecordID: event.Records[0].RecordID,
+			Result:   events.KinesisFirehoseTransformedStateOk,
+			Data:     []byte("{\"order_id\":\"o-1\",\"customer_id\":\"c-1\",\"total\":9.99}\n"),
+		},
+		{
+			RecordID: event.Records[1].RecordID,
+			Result:   events.KinesisFirehoseTransformedStateDropped,
+			Data:     event.Records[1].Data,
+		},
+		{
+			RecordID: event.Records[2].RecordID,
+			Result:   events.KinesisFirehoseTransformedStateProcessingFailed,
+			Data:     event.Records[2].Data,
+		},
+	}, res.Records)
+}
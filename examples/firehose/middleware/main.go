@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	lambdah "github.com/webbgeorge/lambdah/firehose"
+	"github.com/webbgeorge/lambdah/log"
+)
+
+func main() {
+	newHandler(os.Stdout).Start()
+}
+
+// example: log the customer of each order, and partition orders by customer
+func newHandler(logWriter io.Writer) lambdah.HandlerFunc {
+	return lambdah.HandlerFunc(func(c *lambdah.Context) error {
+		var data orderData
+		err := c.Bind(&data)
+		if err != nil {
+			return err
+		}
+
+		log.LoggerFromContext(c.Context).
+			Info().
+			Msgf("order received from customer: %s", data.CustomerID)
+
+		c.PartitionKey("customer_id", data.CustomerID)
+		return nil
+	}).Middleware(
+		lambdah.CorrelationIDMiddleware(),
+		lambdah.LoggerMiddleware(logWriter, map[string]string{
+			"appName":      "lambdahExamples",
+			"functionName": "firehoseMiddlewareExample",
+		}),
+		lambdah.RecoverMiddleware(),
+	)
+}
+
+type orderData struct {
+	OrderID    string `json:"order_id"`
+	CustomerID string `json:"customer_id"`
+}
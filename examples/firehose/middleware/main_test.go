@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/webbgeorge/lambdah/lambdahtest"
+)
+
+func TestNewHandler_Success(t *testing.T) {
+	mockLogger := &bytes.Buffer{}
+
+	h := newHandler(mockLogger).ToLambdaHandler()
+
+	res, err := h(
+		context.Background(),
+		lambdahtest.Firehose().DeliveryStream("orders").Record([]byte(`{"order_id":"o-1","customer_id":"c-1"}`)).Build(),
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, events.KinesisFirehoseTransformedStateOk, res.Records[0].Result)
+	assert.Equal(t, map[string]string{"customer_id": "c-1"}, res.Records[0].Metadata.PartitionKeys)
+	assert.Contains(t, mockLogger.String(), "order received from customer: c-1")
+	assert.Contains(t, mockLogger.String(), `"delivery_stream_arn":"arn:aws:firehose:us-east-1:123456789012:deliverystream/orders"`)
+}
+
+func TestNewHandler_ParseError(t *testing.T) {
+	mockLogger := &bytes.Buffer{}
+
+	h := newHandler(mockLogger).ToLambdaHandler()
+
+	res, err := h(
+		context.Background(),
+		lambdahtest.Firehose().Record([]byte(`{"order`)).Build(),
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, events.KinesisFirehoseTransformedStateProcessingFailed, res.Records[0].Result)
+	assert.Contains(t, mockLogger.String(), "Error processing Firehose record: unexpected end of JSON input")
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	lambdah "github.com/webbgeorge/lambdah/kinesis"
+)
+
+func main() {
+	newHandler(os.Stdout).StartPartialBatch()
+}
+
+// example: just log the name from the record
+func newHandler(logger io.Writer) lambdah.HandlerFunc {
+	return func(c *lambdah.Context) error {
+		var data recordData
+		err := c.Bind(&data)
+		if err != nil {
+			return err
+		}
+
+		_, _ = logger.Write([]byte(data.Name))
+
+		return nil
+	}
+}
+
+type recordData struct {
+	Greeting string `json:"greeting"`
+	Name     string `json:"name"`
+}
+
+func (d *recordData) Validate() error {
+	if d.Greeting != "Hi" && d.Greeting != "Hello" {
+		return errors.New("greeting not allowed")
+	}
+	if d.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/webbgeorge/lambdah/lambdahtest"
+)
+
+func TestNewHandler_Success(t *testing.T) {
+	mockLogger := &bytes.Buffer{}
+
+	h := newHandler(mockLogger).ToLambdaPartialBatchHandler()
+
+	res, err := h(
+		context.Background(),
+		lambdahtest.Kinesis().
+			Record([]byte(`{"name":"Dave","greeting":"Hi"}`)).
+			Record([]byte(`{"name":"Sarah","greeting":"Hello"}`)).
+			Build(),
+	)
+
+	assert.Nil(t, err)
+	assert.Empty(t, res.BatchItemFailures)
+	assert.Equal(t, "DaveSarah", mockLogger.String())
+}
+
+func TestNewHandler_ValidationError(t *testing.T) {
+	mockLogger := &bytes.Buffer{}
+	event := lambdahtest.Kinesis().
+		Record([]byte(`{"name":"Dave","greeting":"Hi"}`)).
+		Record([]byte(`{"name":"Sarah","greeting":"Hey"}`)).
+		Record([]byte(`{"name":"Sam","greeting":"Hi"}`)).
+		Build()
+
+	h := newHandler(mockLogger).ToLambdaPartialBatchHandler()
+
+	res, err := h(context.Background(), event)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []events.KinesisBatchItemFailure{
+		{ItemIdentifier: event.Records[1].Kinesis.SequenceNumber},
+	}, res.BatchItemFailures)
+	assert.Equal(t, "Dave", mockLogger.String())
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	lambdah "github.com/webbgeorge/lambdah/kinesis"
+	"github.com/webbgeorge/lambdah/log"
+)
+
+func main() {
+	newHandler(os.Stdout).StartPartialBatch()
+}
+
+// example: just log the name from the record
+func newHandler(logWriter io.Writer) lambdah.HandlerFunc {
+	return lambdah.HandlerFunc(func(c *lambdah.Context) error {
+		var data recordData
+		err := c.Bind(&data)
+		if err != nil {
+			return err
+		}
+
+		log.LoggerFromContext(c.Context).
+			Info().
+			Msgf("name received: %s", data.Name)
+
+		return nil
+	}).Middleware(
+		lambdah.CorrelationIDMiddleware(),
+		lambdah.LoggerMiddleware(logWriter, map[string]string{
+			"appName":      "lambdahExamples",
+			"functionName": "kinesisMiddlewareExample",
+		}),
+		lambdah.RecoverMiddleware(),
+	)
+}
+
+type recordData struct {
+	Greeting string `json:"greeting"`
+	Name     string `json:"name"`
+}
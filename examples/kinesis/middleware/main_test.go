@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/webbgeorge/lambdah/lambdahtest"
+)
+
+func TestNewHandler_Success(t *testing.T) {
+	mockLogger := &bytes.Buffer{}
+
+	h := newHandler(mockLogger).ToLambdaHandler()
+
+	err := h(
+		context.Background(),
+		lambdahtest.Kinesis().Stream("greetings").Record([]byte(`{"name":"Dave","greeting":"Hi"}`)).Build(),
+	)
+
+	assert.Nil(t, err)
+	assert.Contains(t, mockLogger.String(), "name received: Dave")
+	assert.Contains(t, mockLogger.String(), `"stream_arn":"arn:aws:kinesis:us-east-1:123456789012:stream/greetings"`)
+}
+
+func TestNewHandler_ParseError(t *testing.T) {
+	mockLogger := &bytes.Buffer{}
+
+	h := newHandler(mockLogger).ToLambdaHandler()
+
+	err := h(
+		context.Background(),
+		lambdahtest.Kinesis().Record([]byte(`{"name`)).Build(),
+	)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "unexpected end of JSON input", err.Error())
+	assert.Contains(t, mockLogger.String(), "Error processing Kinesis record: unexpected end of JSON input")
+}
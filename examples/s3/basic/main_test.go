@@ -6,11 +6,11 @@ import (
 	"io/ioutil"
 	"testing"
 
-	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/stretchr/testify/assert"
+	"github.com/webbgeorge/lambdah/lambdahtest"
 )
 
 func TestNewHandler_Success_SkipOtherEvents(t *testing.T) {
@@ -20,7 +20,7 @@ func TestNewHandler_Success_SkipOtherEvents(t *testing.T) {
 
 	err := h(
 		context.Background(),
-		events.S3Event{Records: []events.S3EventRecord{{EventName: "s3:test:event"}}},
+		lambdahtest.S3().Object("my-key").EventName("ObjectRemoved:Delete").Build(),
 	)
 
 	assert.Nil(t, err)
@@ -43,17 +43,7 @@ func TestNewHandler_Success_CreatedEvent(t *testing.T) {
 
 	err := h(
 		context.Background(),
-		events.S3Event{Records: []events.S3EventRecord{{
-			EventName: "ObjectCreated:Put",
-			S3: events.S3Entity{
-				Bucket: events.S3Bucket{
-					Name: "my-bucket",
-				},
-				Object: events.S3Object{
-					Key: "my-key",
-				},
-			},
-		}}},
+		lambdahtest.S3().Bucket("my-bucket").Object("my-key").Build(),
 	)
 
 	assert.Nil(t, err)
@@ -72,17 +62,7 @@ func TestNewHandler_Failure_S3Error(t *testing.T) {
 
 	err := h(
 		context.Background(),
-		events.S3Event{Records: []events.S3EventRecord{{
-			EventName: "ObjectCreated:Put",
-			S3: events.S3Entity{
-				Bucket: events.S3Bucket{
-					Name: "my-bucket",
-				},
-				Object: events.S3Object{
-					Key: "my-key",
-				},
-			},
-		}}},
+		lambdahtest.S3().Bucket("my-bucket").Object("my-key").Build(),
 	)
 
 	assert.Equal(t, assert.AnError, err)
@@ -5,8 +5,8 @@ import (
 	"context"
 	"testing"
 
-	"github.com/aws/aws-lambda-go/events"
 	"github.com/stretchr/testify/assert"
+	"github.com/webbgeorge/lambdah/lambdahtest"
 )
 
 func TestNewHandler_Success(t *testing.T) {
@@ -16,9 +16,7 @@ func TestNewHandler_Success(t *testing.T) {
 
 	err := h(
 		context.Background(),
-		events.SQSEvent{Records: []events.SQSMessage{{
-			Body: `{"name":"Dave","greeting":"Hi"}`,
-		}}},
+		lambdahtest.SQS().Message(`{"name":"Dave","greeting":"Hi"}`).Build(),
 	)
 
 	assert.Nil(t, err)
@@ -32,9 +30,7 @@ func TestNewHandler_ValidationError(t *testing.T) {
 
 	err := h(
 		context.Background(),
-		events.SQSEvent{Records: []events.SQSMessage{{
-			Body: `{"name":"Dave","greeting":"Hey"}`,
-		}}},
+		lambdahtest.SQS().Message(`{"name":"Dave","greeting":"Hey"}`).Build(),
 	)
 
 	assert.NotNil(t, err)
@@ -49,9 +45,7 @@ func TestNewHandler_ParseError(t *testing.T) {
 
 	err := h(
 		context.Background(),
-		events.SQSEvent{Records: []events.SQSMessage{{
-			Body: `{"name`,
-		}}},
+		lambdahtest.SQS().Message(`{"name`).Build(),
 	)
 
 	assert.NotNil(t, err)
@@ -0,0 +1,207 @@
+package firehose
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/webbgeorge/lambdah"
+	"github.com/webbgeorge/lambdah/internal/batch"
+	"github.com/webbgeorge/lambdah/internal/clone"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Context of a record transformed by a Kinesis Data Firehose transformation.
+//
+// The data of the record is decoded from base64 by the event, and is returned to
+// Firehose unchanged unless the handler calls Transform or Drop. If the handler
+// returns an error, the record is returned with the `ProcessingFailed` result, and
+// is delivered to the error output of the delivery stream.
+type Context struct {
+	Context           context.Context
+	InvocationID      string
+	DeliveryStreamArn string
+	Record            events.KinesisFirehoseEventRecord
+
+	result        string
+	data          []byte
+	partitionKeys map[string]string
+}
+
+// Bind the data of the record as JSON.
+func (c *Context) Bind(v interface{}) error {
+	err := json.Unmarshal(c.Record.Data, v)
+	if err != nil {
+		return err
+	}
+
+	return lambdah.Validate(v)
+}
+
+// Transform the record, replacing its data with data.
+func (c *Context) Transform(data []byte) {
+	c.result = events.KinesisFirehoseTransformedStateOk
+	c.data = data
+}
+
+// TransformJSON transforms the record, replacing its data with v marshalled to JSON.
+// A newline is appended, so that records delivered to the same object are newline
+// delimited.
+func (c *Context) TransformJSON(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	c.Transform(append(b, '\n'))
+	return nil
+}
+
+// Drop the record, so that it is not delivered.
+func (c *Context) Drop() {
+	c.result = events.KinesisFirehoseTransformedStateDropped
+}
+
+// PartitionKey sets a partition key of the record, for delivery streams with dynamic
+// partitioning, which can be used in the S3 prefix as
+// `!{partitionKeyFromLambda:name}`.
+func (c *Context) PartitionKey(name string, value string) {
+	if c.partitionKeys == nil {
+		c.partitionKeys = make(map[string]string)
+	}
+	c.partitionKeys[name] = value
+}
+
+// Result returns the result of the record, which is `Ok` unless the handler has
+// called Drop.
+func (c *Context) Result() string {
+	if c.result == "" {
+		return events.KinesisFirehoseTransformedStateOk
+	}
+	return c.result
+}
+
+func (c *Context) response(err error) events.KinesisFirehoseResponseRecord {
+	// Firehose requires data for every record, so dropped and failed records are
+	// returned with their original data
+	res := events.KinesisFirehoseResponseRecord{
+		RecordID: c.Record.RecordID,
+		Result:   c.Result(),
+		Data:     c.Record.Data,
+		Metadata: events.KinesisFirehoseResponseRecordMetadata{PartitionKeys: c.partitionKeys},
+	}
+	if err != nil {
+		res.Result = events.KinesisFirehoseTransformedStateProcessingFailed
+	} else if c.result == events.KinesisFirehoseTransformedStateOk {
+		res.Data = c.data
+	}
+	return res
+}
+
+type HandlerFunc func(c *Context) error
+
+func (hf HandlerFunc) Start() {
+	lambda.Start(hf.ToLambdaHandler())
+}
+
+// Apply middleware to the handler func.
+//
+// Middleware is called in the order it is given to this function.
+func (hf HandlerFunc) Middleware(middleware ...Middleware) HandlerFunc {
+	// apply middleware in reverse order
+	for i := len(middleware) - 1; i >= 0; i-- {
+		hf = middleware[i](hf)
+	}
+	return hf
+}
+
+// Get the AWS Lambda handler of the handler func.
+//
+// Useful if you need to call AWS lambda.Start(...) directly,
+// not required in most cases.
+//
+// Every record in the event is processed, and returned in the response with its
+// result. Errors of the handler fail only their record, so the Lambda handler
+// itself never returns an error.
+func (hf HandlerFunc) ToLambdaHandler() func(
+	ctx context.Context,
+	event events.KinesisFirehoseEvent,
+) (events.KinesisFirehoseResponse, error) {
+	return func(ctx context.Context, event events.KinesisFirehoseEvent) (events.KinesisFirehoseResponse, error) {
+		res := events.KinesisFirehoseResponse{
+			Records: make([]events.KinesisFirehoseResponseRecord, len(event.Records)),
+		}
+		for i := range event.Records {
+			c := newContext(ctx, event, i)
+			err := hf(c)
+			res.Records[i] = c.response(err)
+		}
+		return res, nil
+	}
+}
+
+// ConcurrentHandler processes the records of each event concurrently, see HandlerFunc.Concurrent.
+type ConcurrentHandler struct {
+	handler batch.Handler[Context]
+}
+
+// Process the records of each event concurrently, using a pool of at most
+// concurrency workers. Each record is given its own *Context, and the records of
+// the response are in the order of the event.
+//
+// If the Lambda deadline is reached, no further records are started and each
+// remaining record is returned with the `ProcessingFailed` result.
+func (hf HandlerFunc) Concurrent(concurrency int) ConcurrentHandler {
+	return ConcurrentHandler{
+		handler: batch.Handler[Context]{HandlerFunc: hf, Concurrency: concurrency},
+	}
+}
+
+func (ch ConcurrentHandler) Start() {
+	lambda.Start(ch.ToLambdaHandler())
+}
+
+// Get the AWS Lambda handler of the concurrent handler.
+//
+// Useful if you need to call AWS lambda.Start(...) directly,
+// not required in most cases.
+func (ch ConcurrentHandler) ToLambdaHandler() func(
+	ctx context.Context,
+	event events.KinesisFirehoseEvent,
+) (events.KinesisFirehoseResponse, error) {
+	return func(ctx context.Context, event events.KinesisFirehoseEvent) (events.KinesisFirehoseResponse, error) {
+		res := events.KinesisFirehoseResponse{
+			Records: make([]events.KinesisFirehoseResponseRecord, len(event.Records)),
+		}
+		contexts := make([]*Context, len(event.Records))
+		for i := range event.Records {
+			contexts[i] = newContext(ctx, event, i)
+		}
+		errs := ch.handler.Process(ctx, len(event.Records), func(i int) *Context {
+			return contexts[i]
+		})
+		for i, c := range contexts {
+			res.Records[i] = c.response(errs[i])
+		}
+		return res, nil
+	}
+}
+
+func newContext(ctx context.Context, event events.KinesisFirehoseEvent, i int) *Context {
+	return &Context{
+		Context:           ctx,
+		InvocationID:      event.InvocationID,
+		DeliveryStreamArn: event.DeliveryStreamArn,
+		Record:            event.Records[i],
+	}
+}
+
+// clone returns a copy of the context which shares no maps or slices with c, so
+// that a handler left running in the background after a timeout does not race
+// with the response of the record.
+func (c *Context) clone() Context {
+	tc := *c
+	tc.Record.Data = clone.Slice(c.Record.Data)
+	tc.partitionKeys = clone.Map(c.partitionKeys)
+	return tc
+}
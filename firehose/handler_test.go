@@ -0,0 +1,251 @@
+package firehose
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func testEvent(data ...string) events.KinesisFirehoseEvent {
+	event := events.KinesisFirehoseEvent{
+		InvocationID:      "invocation-1",
+		DeliveryStreamArn: "arn:aws:firehose:us-east-1:123456789012:deliverystream/orders",
+		Region:            "us-east-1",
+	}
+	for i, d := range data {
+		event.Records = append(event.Records, events.KinesisFirehoseEventRecord{
+			RecordID: "record-" + string(rune('1'+i)),
+			Data:     []byte(d),
+		})
+	}
+	return event
+}
+
+func TestFirehoseHandler_Results(t *testing.T) {
+	h := func(c *Context) error {
+		switch string(c.Record.Data) {
+		case "transform":
+			c.Transform([]byte("TRANSFORMED"))
+		case "drop":
+			c.Drop()
+		case "fail":
+			c.Transform([]byte("partial"))
+			return assert.AnError
+		}
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).ToLambdaHandler()
+	res, err := awsHandler(context.Background(), testEvent("transform", "drop", "fail", "unchanged"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []events.KinesisFirehoseResponseRecord{
+		{RecordID: "record-1", Result: "Ok", Data: []byte("TRANSFORMED")},
+		{RecordID: "record-2", Result: "Dropped", Data: []byte("drop")},
+		{RecordID: "record-3", Result: "ProcessingFailed", Data: []byte("fail")},
+		{RecordID: "record-4", Result: "Ok", Data: []byte("unchanged")},
+	}, res.Records)
+}
+
+func TestFirehoseHandler_Context(t *testing.T) {
+	h := func(c *Context) error {
+		assert.Equal(t, "invocation-1", c.InvocationID)
+		assert.Equal(t, "arn:aws:firehose:us-east-1:123456789012:deliverystream/orders", c.DeliveryStreamArn)
+		assert.Equal(t, "record-1", c.Record.RecordID)
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).ToLambdaHandler()
+	res, err := awsHandler(context.Background(), testEvent("data"))
+
+	assert.Nil(t, err)
+	assert.Len(t, res.Records, 1)
+}
+
+func TestFirehoseHandler_PartitionKeys(t *testing.T) {
+	h := func(c *Context) error {
+		var data recordData
+		if err := c.Bind(&data); err != nil {
+			return err
+		}
+		c.PartitionKey("customer_id", data.CustomerID)
+		c.PartitionKey("year", "2026")
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).ToLambdaHandler()
+	res, err := awsHandler(context.Background(), testEvent(`{"customer_id":"c-1"}`))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Ok", res.Records[0].Result)
+	assert.Equal(t, map[string]string{"customer_id": "c-1", "year": "2026"}, res.Records[0].Metadata.PartitionKeys)
+}
+
+func TestFirehoseConcurrentHandler_Results(t *testing.T) {
+	var callCount int32
+	h := func(c *Context) error {
+		atomic.AddInt32(&callCount, 1)
+		if string(c.Record.Data) == "fail" {
+			return assert.AnError
+		}
+		c.Transform([]byte(strings.ToUpper(string(c.Record.Data))))
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).Concurrent(2).ToLambdaHandler()
+	res, err := awsHandler(context.Background(), testEvent("a", "fail", "c"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), callCount)
+	assert.Equal(t, []events.KinesisFirehoseResponseRecord{
+		{RecordID: "record-1", Result: "Ok", Data: []byte("A")},
+		{RecordID: "record-2", Result: "ProcessingFailed", Data: []byte("fail")},
+		{RecordID: "record-3", Result: "Ok", Data: []byte("C")},
+	}, res.Records)
+}
+
+func TestFirehoseConcurrentHandler_DeadlineExceeded(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var callCount int32
+	h := func(c *Context) error {
+		atomic.AddInt32(&callCount, 1)
+		cancel()
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).Concurrent(1).ToLambdaHandler()
+	res, err := awsHandler(ctx, testEvent("a", "b", "c"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), callCount)
+	assert.Equal(t, "Ok", res.Records[0].Result)
+	assert.Equal(t, "ProcessingFailed", res.Records[1].Result)
+	assert.Equal(t, "ProcessingFailed", res.Records[2].Result)
+}
+
+func TestHandlerFunc_ToLambdaHandler_Timeout(t *testing.T) {
+	h := HandlerFunc(func(c *Context) error {
+		if string(c.Record.Data) == "slow" {
+			<-c.Context.Done()
+		}
+		c.Transform([]byte("done"))
+		return nil
+	}).Middleware(TimeoutMiddleware(150 * time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	res, err := h.ToLambdaHandler()(ctx, testEvent("fast", "slow"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []events.KinesisFirehoseResponseRecord{
+		{RecordID: "record-1", Result: "Ok", Data: []byte("done")},
+		{RecordID: "record-2", Result: "ProcessingFailed", Data: []byte("slow")},
+	}, res.Records)
+}
+
+func TestFirehoseContext_Bind_Success(t *testing.T) {
+	c := &Context{
+		Record: events.KinesisFirehoseEventRecord{Data: []byte(`{"customer_id": "c-1"}`)},
+	}
+
+	var data recordData
+	err := c.Bind(&data)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "c-1", data.CustomerID)
+}
+
+func TestFirehoseContext_Bind_InvalidJSON(t *testing.T) {
+	c := &Context{
+		Record: events.KinesisFirehoseEventRecord{Data: []byte(`{"customer`)},
+	}
+
+	var data recordData
+	err := c.Bind(&data)
+
+	assert.Error(t, err)
+}
+
+func TestFirehoseContext_Bind_Invalid(t *testing.T) {
+	c := &Context{
+		Record: events.KinesisFirehoseEventRecord{Data: []byte(`{}`)},
+	}
+
+	var data recordData
+	err := c.Bind(&data)
+
+	assert.EqualError(t, err, "customer_id is required")
+}
+
+func TestFirehoseContext_TransformJSON(t *testing.T) {
+	c := &Context{
+		Record: events.KinesisFirehoseEventRecord{RecordID: "record-1", Data: []byte("data")},
+	}
+
+	err := c.TransformJSON(recordData{CustomerID: "c-1"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Ok", c.Result())
+	assert.Equal(t, []byte("{\"customer_id\":\"c-1\"}\n"), c.response(nil).Data)
+}
+
+func TestFirehoseContext_TransformJSON_Error(t *testing.T) {
+	c := &Context{
+		Record: events.KinesisFirehoseEventRecord{RecordID: "record-1", Data: []byte("data")},
+	}
+
+	err := c.TransformJSON(make(chan int))
+
+	assert.Error(t, err)
+	assert.Equal(t, []byte("data"), c.response(nil).Data)
+}
+
+func TestFirehoseHandler_Middleware(t *testing.T) {
+	callOrder := make([]string, 0)
+
+	h := func(c *Context) error {
+		callOrder = append(callOrder, "handler")
+		return nil
+	}
+
+	mw1 := func(h HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			callOrder = append(callOrder, "mw1 in")
+			err := h(c)
+			callOrder = append(callOrder, "mw1 out")
+			return err
+		}
+	}
+
+	mw2 := func(h HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			callOrder = append(callOrder, "mw2 in")
+			err := h(c)
+			callOrder = append(callOrder, "mw2 out")
+			return err
+		}
+	}
+
+	awsHandler := HandlerFunc(h).Middleware(mw1, mw2).ToLambdaHandler()
+	_, err := awsHandler(context.Background(), testEvent("data"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mw1 in", "mw2 in", "handler", "mw2 out", "mw1 out"}, callOrder)
+}
+
+type recordData struct {
+	CustomerID string `json:"customer_id"`
+}
+
+func (d *recordData) Validate() error {
+	if d.CustomerID == "" {
+		return errors.New("customer_id is required")
+	}
+	return nil
+}
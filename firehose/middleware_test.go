@@ -0,0 +1,227 @@
+package firehose
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/webbgeorge/lambdah"
+	"github.com/webbgeorge/lambdah/log"
+	"github.com/webbgeorge/lambdah/metrics"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func testContext() *Context {
+	return &Context{
+		Context:           context.Background(),
+		InvocationID:      "invocation-1",
+		DeliveryStreamArn: "arn:aws:firehose:us-east-1:123456789012:deliverystream/orders",
+		Record: events.KinesisFirehoseEventRecord{
+			RecordID: "record-1",
+			Data:     []byte(`{"customer_id":"c-1"}`),
+		},
+	}
+}
+
+func TestCorrelationIDMiddleware(t *testing.T) {
+	c := testContext()
+	handlerCalled := false
+	h := func(c *Context) error {
+		handlerCalled = true
+		assert.Equal(t, "record-1", c.Record.RecordID)
+		assert.Len(t, log.CorrelationIDFromContext(c.Context), 36)
+		return nil
+	}
+
+	mw := CorrelationIDMiddleware()
+	h = mw(h)
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.True(t, handlerCalled)
+}
+
+func TestLoggerMiddleware_Success(t *testing.T) {
+	c := testContext()
+	h := func(c *Context) error {
+		log.LoggerFromContext(c.Context).Info().Msg("msg from handler")
+		return nil
+	}
+	buf := &bytes.Buffer{}
+
+	mw := LoggerMiddleware(buf, map[string]string{"testField": "testFieldData"})
+	h = mw(h)
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), `"testField":"testFieldData"`)
+	assert.Contains(t, buf.String(), `"record_id":"record-1"`)
+	assert.Contains(t, buf.String(), `"invocation_id":"invocation-1"`)
+	assert.Contains(t, buf.String(), `"delivery_stream_arn":"arn:aws:firehose:us-east-1:123456789012:deliverystream/orders"`)
+	assert.Contains(t, buf.String(), "Processing Firehose record")
+	assert.Contains(t, buf.String(), "msg from handler")
+}
+
+func TestLoggerMiddleware_Error(t *testing.T) {
+	c := testContext()
+	h := func(c *Context) error {
+		log.LoggerFromContext(c.Context).Info().Msg("msg from handler")
+		return assert.AnError
+	}
+	buf := &bytes.Buffer{}
+
+	mw := LoggerMiddleware(buf, map[string]string{})
+	h = mw(h)
+	err := h(c)
+
+	assert.NotNil(t, err)
+	assert.Contains(t, buf.String(), "Processing Firehose record")
+	assert.Contains(t, buf.String(), "msg from handler")
+	assert.Contains(t, buf.String(), "Error processing Firehose record: assert.AnError general error for testing")
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	logBuffer := &bytes.Buffer{}
+	goCtx := log.WithLogger(context.Background(), log.NewLogger(logBuffer, nil))
+	c := &Context{Context: goCtx}
+	h := func(c *Context) error {
+		panic("oh no")
+	}
+
+	mw := RecoverMiddleware()
+	h = mw(h)
+	err := h(c)
+
+	panicErr, ok := err.(lambdah.PanicError)
+	assert.True(t, ok)
+	assert.Equal(t, "oh no", panicErr.Value)
+	assert.Contains(t, logBuffer.String(), "Recovered from panic: oh no")
+	assert.Contains(t, logBuffer.String(), `"stack":"goroutine`)
+}
+
+func TestRecoverMiddleware_NoPanic(t *testing.T) {
+	c := &Context{Context: context.Background()}
+	h := func(c *Context) error {
+		return assert.AnError
+	}
+
+	mw := RecoverMiddleware()
+	h = mw(h)
+	err := h(c)
+
+	assert.Equal(t, assert.AnError, err)
+}
+
+func TestTimeoutMiddleware_TimesOut(t *testing.T) {
+	goCtx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	c := &Context{Context: goCtx}
+	h := func(c *Context) error {
+		<-c.Context.Done()
+		return nil
+	}
+
+	mw := TimeoutMiddleware(150 * time.Millisecond)
+	h = mw(h)
+	err := h(c)
+
+	assert.IsType(t, lambdah.TimeoutError{}, err)
+	assert.Equal(t, goCtx, c.Context)
+}
+
+func TestTimeoutMiddleware_KeepsTransform(t *testing.T) {
+	goCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	c := &Context{Context: goCtx}
+	h := func(c *Context) error {
+		c.Transform([]byte("transformed"))
+		c.PartitionKey("year", "2026")
+		return nil
+	}
+
+	mw := TimeoutMiddleware(100 * time.Millisecond)
+	h = mw(h)
+	err := h(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, goCtx, c.Context)
+	res := c.response(err)
+	assert.Equal(t, []byte("transformed"), res.Data)
+	assert.Equal(t, map[string]string{"year": "2026"}, res.Metadata.PartitionKeys)
+}
+
+func TestTimeoutMiddleware_HandlerWritesAfterTimeout(t *testing.T) {
+	goCtx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	c := &Context{Context: goCtx}
+	c.PartitionKey("year", "2026")
+	done := make(chan struct{})
+	h := func(c *Context) error {
+		defer close(done)
+		<-c.Context.Done()
+		c.PartitionKey("customer_id", "c-1")
+		return nil
+	}
+
+	mw := TimeoutMiddleware(150 * time.Millisecond)
+	h = mw(h)
+	err := h(c)
+	res := c.response(err)
+	<-done
+
+	assert.IsType(t, lambdah.TimeoutError{}, err)
+	assert.Equal(t, "ProcessingFailed", res.Result)
+	assert.Equal(t, map[string]string{"year": "2026"}, res.Metadata.PartitionKeys)
+}
+
+func TestTimeoutMiddleware_Completes(t *testing.T) {
+	goCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	c := &Context{Context: goCtx}
+	h := func(c *Context) error {
+		deadline, _ := c.Context.Deadline()
+		assert.WithinDuration(t, time.Now().Add(900*time.Millisecond), deadline, 50*time.Millisecond)
+		return assert.AnError
+	}
+
+	mw := TimeoutMiddleware(100 * time.Millisecond)
+	h = mw(h)
+	err := h(c)
+
+	assert.Equal(t, assert.AnError, err)
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	metricsBuffer := &bytes.Buffer{}
+	goCtx := log.WithCorrelationID(context.Background(), "cid-123")
+	mw := MetricsMiddleware(metricsBuffer, "MyApp", map[string]string{"Service": "books"})
+	h := mw(func(c *Context) error {
+		metrics.MetricsFromContext(c.Context).Add("BooksListed", 3, metrics.Count)
+		return assert.AnError
+	})
+
+	c := &Context{Context: goCtx}
+	_ = h(c)
+
+	var doc map[string]interface{}
+	assert.Nil(t, json.Unmarshal(metricsBuffer.Bytes(), &doc))
+	assert.Equal(t, "books", doc["Service"])
+	assert.Equal(t, "cid-123", doc["correlation_id"])
+	assert.Equal(t, float64(3), doc["BooksListed"])
+	assert.Equal(t, float64(1), doc["Invocations"])
+	assert.Equal(t, float64(1), doc["Errors"])
+	assert.Equal(t, float64(1), doc["ColdStart"])
+	assert.Contains(t, doc, "Duration")
+
+	metricsBuffer.Reset()
+	c = &Context{Context: goCtx}
+	_ = h(c)
+
+	doc = nil
+	assert.Nil(t, json.Unmarshal(metricsBuffer.Bytes(), &doc))
+	assert.NotContains(t, doc, "ColdStart")
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 
 	"github.com/webbgeorge/lambdah"
+	"github.com/webbgeorge/lambdah/internal/clone"
 
 	"github.com/aws/aws-lambda-go/lambda"
 )
@@ -21,11 +22,7 @@ func (c *Context) Bind(v interface{}) error {
 		return err
 	}
 
-	if validatable, ok := v.(lambdah.Validatable); ok {
-		return validatable.Validate()
-	}
-
-	return nil
+	return lambdah.Validate(v)
 }
 
 type HandlerFunc func(c *Context) error
@@ -49,8 +46,11 @@ func (hf HandlerFunc) Middleware(middleware ...Middleware) HandlerFunc {
 //
 // Useful if you need to call AWS lambda.Start(...) directly,
 // not required in most cases.
-func (hf HandlerFunc) ToLambdaHandler() func(ctx context.Context, event []byte) (interface{}, error) {
-	return func(ctx context.Context, event []byte) (interface{}, error) {
+//
+// The event is a json.RawMessage, so that the Lambda runtime passes the raw
+// payload rather than decoding it as a base64 JSON string.
+func (hf HandlerFunc) ToLambdaHandler() func(ctx context.Context, event json.RawMessage) (interface{}, error) {
+	return func(ctx context.Context, event json.RawMessage) (interface{}, error) {
 		c := &Context{
 			Context: ctx,
 			Event:   event,
@@ -59,3 +59,12 @@ func (hf HandlerFunc) ToLambdaHandler() func(ctx context.Context, event []byte)
 		return c.Response, err
 	}
 }
+
+// clone returns a copy of the context which shares no maps or slices with c, so
+// that a handler left running in the background after a timeout does not race
+// with earlier middleware.
+func (c *Context) clone() Context {
+	tc := *c
+	tc.Event = clone.Slice(c.Event)
+	return tc
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -28,6 +29,27 @@ func TestGenericHandler_Success_OneEvent(t *testing.T) {
 	assert.Equal(t, 1, callCount)
 }
 
+func TestGenericHandler_LambdaRuntime_JSONObjectEvent(t *testing.T) {
+	h := func(c *Context) error {
+		var data eventDetail
+		if err := c.Bind(&data); err != nil {
+			return err
+		}
+		c.Response = data
+		return nil
+	}
+
+	// invoke via the handler of the Lambda runtime, which decodes the payload into
+	// the event type of the handler
+	res, err := lambda.NewHandler(HandlerFunc(h).ToLambdaHandler()).Invoke(
+		context.Background(),
+		[]byte(`{"name":"Dave","age":45}`),
+	)
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"name":"Dave","age":45}`, string(res))
+}
+
 func TestGenericHandler_Error(t *testing.T) {
 	callCount := 0
 	h := func(c *Context) error {
@@ -1,14 +1,29 @@
 module github.com/webbgeorge/lambdah
 
-go 1.14
+go 1.18
 
 require (
-	github.com/aws/aws-lambda-go v1.16.0
+	github.com/aws/aws-lambda-go v1.38.0
 	github.com/aws/aws-sdk-go v1.33.13
 	github.com/google/uuid v1.1.1
 	github.com/gorilla/reverse v1.0.0
+	github.com/jmespath/go-jmespath v0.3.0
 	github.com/rs/zerolog v1.19.0
 	github.com/steinfletcher/apitest v1.4.6
 	github.com/steinfletcher/apitest-jsonpath v1.5.0
-	github.com/stretchr/testify v1.5.1
+	github.com/stretchr/testify v1.8.2
+	go.opentelemetry.io/otel v1.14.0
+	go.opentelemetry.io/otel/sdk v1.14.0
+	go.opentelemetry.io/otel/trace v1.14.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/PaesslerAG/gval v1.0.0 // indirect
+	github.com/PaesslerAG/jsonpath v0.1.1 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-logr/logr v1.2.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.5.0 // indirect
 )
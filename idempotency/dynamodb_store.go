@@ -0,0 +1,185 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// DynamoDBAPI is the subset of the DynamoDB client used by DynamoDBStore, which
+// is implemented by *dynamodb.DynamoDB.
+type DynamoDBAPI interface {
+	GetItemWithContext(ctx aws.Context, input *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error)
+	PutItemWithContext(ctx aws.Context, input *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error)
+	DeleteItemWithContext(ctx aws.Context, input *dynamodb.DeleteItemInput, opts ...request.Option) (*dynamodb.DeleteItemOutput, error)
+}
+
+// DynamoDBStore is a Store which persists records in a DynamoDB table, so they are
+// shared by all invocations. The table must have a string partition key, named
+// `id` by default, and should have TTL enabled on the `expiration` attribute so
+// that expired records are deleted.
+//
+// Keys are claimed with a conditional write, which succeeds if there is no record
+// for the key or the record has expired, as DynamoDB can take some time to delete
+// items after their TTL.
+type DynamoDBStore struct {
+	client       DynamoDBAPI
+	tableName    string
+	keyAttribute string
+	now          func() time.Time
+}
+
+type DynamoDBStoreOption func(s *DynamoDBStore)
+
+// WithKeyAttribute sets the name of the table's partition key. The default is `id`.
+func WithKeyAttribute(name string) DynamoDBStoreOption {
+	return func(s *DynamoDBStore) {
+		s.keyAttribute = name
+	}
+}
+
+func NewDynamoDBStore(client DynamoDBAPI, tableName string, opts ...DynamoDBStoreOption) *DynamoDBStore {
+	s := &DynamoDBStore{
+		client:       client,
+		tableName:    tableName,
+		keyAttribute: "id",
+		now:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// dynamoDBItem is a record as stored in DynamoDB. Expiration is in epoch seconds,
+// as required by DynamoDB TTL.
+type dynamoDBItem struct {
+	Status     Status `dynamodbav:"status"`
+	Response   []byte `dynamodbav:"response,omitempty"`
+	Expiration int64  `dynamodbav:"expiration"`
+}
+
+func (s *DynamoDBStore) Get(ctx context.Context, key string) (Record, error) {
+	out, err := s.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.tableName),
+		Key:            s.itemKey(key),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to get idempotency record: %w", err)
+	}
+	if len(out.Item) == 0 {
+		return Record{}, ErrRecordNotFound
+	}
+
+	var item dynamoDBItem
+	err = dynamodbattribute.UnmarshalMap(out.Item, &item)
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to unmarshal idempotency record: %w", err)
+	}
+	if s.now().Unix() >= item.Expiration {
+		return Record{}, ErrRecordNotFound
+	}
+
+	return Record{
+		Key:       key,
+		Status:    item.Status,
+		Response:  item.Response,
+		ExpiresAt: time.Unix(item.Expiration, 0),
+	}, nil
+}
+
+func (s *DynamoDBStore) PutInProgress(ctx context.Context, key string, expiresAt time.Time) error {
+	item, err := s.item(key, dynamoDBItem{
+		Status:     StatusInProgress,
+		Expiration: epochSeconds(expiresAt),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(#key) OR #expiration <= :now"),
+		ExpressionAttributeNames: map[string]*string{
+			"#key":        aws.String(s.keyAttribute),
+			"#expiration": aws.String("expiration"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":now": {N: aws.String(strconv.FormatInt(s.now().Unix(), 10))},
+		},
+	})
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return ErrRecordExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to put idempotency record: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) Complete(ctx context.Context, key string, response []byte, expiresAt time.Time) error {
+	item, err := s.item(key, dynamoDBItem{
+		Status:     StatusCompleted,
+		Response:   response,
+		Expiration: epochSeconds(expiresAt),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency record: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) Expire(ctx context.Context, key string) error {
+	_, err := s.client.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.tableName),
+		Key:       s.itemKey(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete idempotency record: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) itemKey(key string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		s.keyAttribute: {S: aws.String(key)},
+	}
+}
+
+func (s *DynamoDBStore) item(key string, record dynamoDBItem) (map[string]*dynamodb.AttributeValue, error) {
+	item, err := dynamodbattribute.MarshalMap(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+	item[s.keyAttribute] = &dynamodb.AttributeValue{S: aws.String(key)}
+	return item, nil
+}
+
+// epochSeconds returns t in epoch seconds, rounded up so that records do not
+// expire early.
+func epochSeconds(t time.Time) int64 {
+	seconds := t.Unix()
+	if t.Nanosecond() > 0 {
+		seconds++
+	}
+	return seconds
+}
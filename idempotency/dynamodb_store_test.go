@@ -0,0 +1,214 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDynamoDB is an in process DynamoDBAPI, which supports the conditional write
+// used by DynamoDBStore.PutInProgress.
+type fakeDynamoDB struct {
+	mu           sync.Mutex
+	items        map[string]map[string]*dynamodb.AttributeValue
+	keyAttribute string
+	err          error
+}
+
+func newFakeDynamoDB(keyAttribute string) *fakeDynamoDB {
+	return &fakeDynamoDB{
+		items:        make(map[string]map[string]*dynamodb.AttributeValue),
+		keyAttribute: keyAttribute,
+	}
+}
+
+func (f *fakeDynamoDB) GetItemWithContext(ctx aws.Context, input *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &dynamodb.GetItemOutput{Item: f.items[*input.Key[f.keyAttribute].S]}, nil
+}
+
+func (f *fakeDynamoDB) PutItemWithContext(ctx aws.Context, input *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	key := *input.Item[f.keyAttribute].S
+	if input.ConditionExpression != nil {
+		existing, ok := f.items[key]
+		if ok {
+			expiration, _ := strconv.ParseInt(*existing[*input.ExpressionAttributeNames["#expiration"]].N, 10, 64)
+			now, _ := strconv.ParseInt(*input.ExpressionAttributeValues[":now"].N, 10, 64)
+			if expiration > now {
+				return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+			}
+		}
+	}
+	f.items[key] = input.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) DeleteItemWithContext(ctx aws.Context, input *dynamodb.DeleteItemInput, opts ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	delete(f.items, *input.Key[f.keyAttribute].S)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+// dynamoDBLocalClient returns a client for DynamoDB Local with a new table, if the
+// DYNAMODB_LOCAL_ENDPOINT environment variable is set.
+func dynamoDBLocalClient(t *testing.T) (*dynamodb.DynamoDB, string) {
+	endpoint := os.Getenv("DYNAMODB_LOCAL_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("DYNAMODB_LOCAL_ENDPOINT is not set")
+	}
+	sess := session.Must(session.NewSession(&aws.Config{
+		Endpoint:    aws.String(endpoint),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("local", "local", ""),
+	}))
+	client := dynamodb.New(sess)
+	tableName := fmt.Sprintf("idempotency-%d", time.Now().UnixNano())
+	_, err := client.CreateTableWithContext(context.Background(), &dynamodb.CreateTableInput{
+		TableName: aws.String(tableName),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("id"), AttributeType: aws.String(dynamodb.ScalarAttributeTypeS)},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("id"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, _ = client.DeleteTable(&dynamodb.DeleteTableInput{TableName: aws.String(tableName)})
+	})
+	return client, tableName
+}
+
+func testDynamoDBStore(t *testing.T, client DynamoDBAPI, tableName string) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	s := NewDynamoDBStore(client, tableName)
+	s.now = func() time.Time { return now }
+
+	_, err := s.Get(ctx, "key")
+	assert.Equal(t, ErrRecordNotFound, err)
+
+	assert.Nil(t, s.PutInProgress(ctx, "key", now.Add(time.Minute)))
+	assert.Equal(t, ErrRecordExists, s.PutInProgress(ctx, "key", now.Add(time.Minute)))
+	record, err := s.Get(ctx, "key")
+	assert.Nil(t, err)
+	assert.Equal(t, StatusInProgress, record.Status)
+
+	assert.Nil(t, s.Complete(ctx, "key", []byte("response"), now.Add(time.Hour)))
+	record, err = s.Get(ctx, "key")
+	assert.Nil(t, err)
+	assert.Equal(t, Record{
+		Key:       "key",
+		Status:    StatusCompleted,
+		Response:  []byte("response"),
+		ExpiresAt: now.Add(time.Hour),
+	}, record)
+
+	assert.Nil(t, s.Expire(ctx, "key"))
+	_, err = s.Get(ctx, "key")
+	assert.Equal(t, ErrRecordNotFound, err)
+
+	// expired records which are not yet deleted by TTL
+	assert.Nil(t, s.PutInProgress(ctx, "key", now.Add(time.Minute)))
+	now = now.Add(time.Minute)
+	_, err = s.Get(ctx, "key")
+	assert.Equal(t, ErrRecordNotFound, err)
+	assert.Nil(t, s.PutInProgress(ctx, "key", now.Add(time.Minute)))
+}
+
+func TestDynamoDBStore(t *testing.T) {
+	testDynamoDBStore(t, newFakeDynamoDB("id"), "idempotency")
+}
+
+func TestDynamoDBStore_DynamoDBLocal(t *testing.T) {
+	client, tableName := dynamoDBLocalClient(t)
+	testDynamoDBStore(t, client, tableName)
+}
+
+func TestDynamoDBStore_WithKeyAttribute(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeDynamoDB("pk")
+	s := NewDynamoDBStore(client, "idempotency", WithKeyAttribute("pk"))
+
+	assert.Nil(t, s.PutInProgress(ctx, "key", time.Now().Add(time.Minute)))
+
+	assert.Equal(t, "key", *client.items["key"]["pk"].S)
+	assert.Equal(t, string(StatusInProgress), *client.items["key"]["status"].S)
+}
+
+func TestDynamoDBStore_RoundsExpirationUp(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeDynamoDB("id")
+	s := NewDynamoDBStore(client, "idempotency")
+	expiresAt := time.Unix(1577836800, int64(100*time.Millisecond))
+
+	assert.Nil(t, s.PutInProgress(ctx, "key", expiresAt))
+
+	assert.Equal(t, "1577836801", *client.items["key"]["expiration"].N)
+}
+
+func TestDynamoDBStore_ClientErrors(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeDynamoDB("id")
+	client.err = awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "throttled", nil)
+	s := NewDynamoDBStore(client, "idempotency")
+
+	_, err := s.Get(ctx, "key")
+	assert.True(t, errors.Is(err, client.err))
+	err = s.PutInProgress(ctx, "key", time.Now().Add(time.Minute))
+	assert.True(t, errors.Is(err, client.err))
+	assert.False(t, errors.Is(err, ErrRecordExists))
+	err = s.Complete(ctx, "key", nil, time.Now().Add(time.Hour))
+	assert.True(t, errors.Is(err, client.err))
+	err = s.Expire(ctx, "key")
+	assert.True(t, errors.Is(err, client.err))
+}
+
+func TestDynamoDBStore_ReplaysResponse(t *testing.T) {
+	store := NewDynamoDBStore(newFakeDynamoDB("id"), "idempotency")
+	calls := 0
+	h := func() ([]byte, error) {
+		calls++
+		return []byte(`"response"`), nil
+	}
+	cfg := newConfig(store, nil)
+
+	assert.Nil(t, cfg.run(context.Background(), []byte(`{"id":1}`), h, nil))
+	var replayed []byte
+	assert.Nil(t, cfg.run(context.Background(), []byte(`{"id":1}`), h, func(response []byte) error {
+		replayed = response
+		return nil
+	}))
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, []byte(`"response"`), replayed)
+}
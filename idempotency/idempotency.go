@@ -0,0 +1,273 @@
+// Package idempotency provides middleware which makes handlers idempotent, so that
+// events delivered more than once, such as SQS messages, SNS notifications and
+// DynamoDB stream records, are only processed once.
+//
+// Each event is given an idempotency key, derived from its delivery identity, such
+// as an SQS message ID, from its payload, or from a part of its payload selected
+// with a JMESPath expression. The key is claimed in a
+// Store before the handler is called. A repeat of a completed event is skipped,
+// or for handlers with a response the cached response is replayed. A repeat of
+// an event which is still in progress is rejected with ErrInProgress.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/jmespath/go-jmespath"
+)
+
+// maxInProgressExpiry is the maximum Lambda timeout, used to expire in progress
+// records when the context has no deadline.
+const maxInProgressExpiry = 15 * time.Minute
+
+var (
+	// ErrInProgress is returned when an event with the same idempotency key is already
+	// being processed. Batch handlers fail the record, so it is retried later.
+	ErrInProgress = errors.New("idempotency key is already in progress")
+	// ErrMissingKey is returned when the key expression does not select a value
+	// from the payload
+	ErrMissingKey = errors.New("idempotency key expression did not match the payload")
+	// ErrKeyExpressionRequired is the panic of HTTP middleware configured without a
+	// key expression
+	ErrKeyExpressionRequired = errors.New(`idempotency key expression is required for HTTP handlers, for example headers."Idempotency-Key"`)
+)
+
+type config struct {
+	store            Store
+	keyExpression    *jmespath.JMESPath
+	keyPrefix        string
+	expiry           time.Duration
+	inProgressExpiry time.Duration
+	skipMissingKey   bool
+}
+
+type Option func(cfg *config) error
+
+// WithKeyExpression sets a JMESPath expression which selects the part of the JSON
+// payload to use as the idempotency key, for example `order_id` or `[customer, order_id]`.
+// By default the whole payload is used.
+func WithKeyExpression(expression string) Option {
+	return func(cfg *config) error {
+		compiled, err := jmespath.Compile(expression)
+		if err != nil {
+			return fmt.Errorf("invalid idempotency key expression '%s': %w", expression, err)
+		}
+		cfg.keyExpression = compiled
+		return nil
+	}
+}
+
+// WithKeyPrefix sets the prefix of idempotency keys, which should be unique to the
+// handler when a store is shared. By default the Lambda function name is used.
+func WithKeyPrefix(prefix string) Option {
+	return func(cfg *config) error {
+		cfg.keyPrefix = prefix
+		return nil
+	}
+}
+
+// WithExpiry sets how long completed records are kept for. The default is 1 hour.
+func WithExpiry(expiry time.Duration) Option {
+	return func(cfg *config) error {
+		cfg.expiry = expiry
+		return nil
+	}
+}
+
+// WithInProgressExpiry sets how long in progress records are kept for, after which
+// the event can be processed again, for example if the execution environment
+// crashed. By default this is the remaining time before the Lambda deadline.
+func WithInProgressExpiry(expiry time.Duration) Option {
+	return func(cfg *config) error {
+		cfg.inProgressExpiry = expiry
+		return nil
+	}
+}
+
+// WithSkipMissingKey processes events without idempotency when the key expression
+// does not select a value from the payload, rather than returning ErrMissingKey.
+func WithSkipMissingKey() Option {
+	return func(cfg *config) error {
+		cfg.skipMissingKey = true
+		return nil
+	}
+}
+
+// newConfig applies opts over the defaults, panicking if an option is invalid, as
+// middleware is configured when the handler is created.
+func newConfig(store Store, opts []Option) config {
+	cfg := config{
+		store:     store,
+		keyPrefix: lambdacontext.FunctionName,
+		expiry:    time.Hour,
+	}
+	for _, opt := range opts {
+		err := opt(&cfg)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return cfg
+}
+
+// newHTTPConfig is newConfig for HTTP handlers, which require a key expression, as
+// requests are only idempotent when the client sends a key. Requests without a key
+// are processed without idempotency.
+func newHTTPConfig(store Store, opts []Option) config {
+	cfg := newConfig(store, append([]Option{WithSkipMissingKey()}, opts...))
+	if cfg.keyExpression == nil {
+		panic(ErrKeyExpressionRequired)
+	}
+	return cfg
+}
+
+// isSafeMethod returns whether method is an HTTP method which does not change
+// state, so its requests are not made idempotent.
+func isSafeMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// key returns the idempotency key of payload, or an empty key if the expression
+// does not match and missing keys are skipped.
+func (cfg config) key(payload []byte) (string, error) {
+	keyData := payload
+	if cfg.keyExpression != nil {
+		var data interface{}
+		err := json.Unmarshal(payload, &data)
+		if err != nil {
+			return "", fmt.Errorf("idempotency key expression requires a JSON payload: %w", err)
+		}
+		result, err := cfg.keyExpression.Search(data)
+		if err != nil {
+			return "", err
+		}
+		if isEmpty(result) {
+			if cfg.skipMissingKey {
+				return "", nil
+			}
+			return "", ErrMissingKey
+		}
+		keyData, err = json.Marshal(result)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	hash := sha256.Sum256(keyData)
+	return cfg.keyPrefix + "#" + hex.EncodeToString(hash[:]), nil
+}
+
+func isEmpty(result interface{}) bool {
+	switch result := result.(type) {
+	case nil:
+		return true
+	case []interface{}:
+		for _, v := range result {
+			if v != nil {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// run calls fn unless payload has already been processed, in which case replay is
+// called with the cached response of fn. replay may be nil if there is no response.
+func (cfg config) run(ctx context.Context, payload []byte, fn func() ([]byte, error), replay func(response []byte) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	key, err := cfg.key(payload)
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		_, err := fn()
+		return err
+	}
+
+	err = cfg.store.PutInProgress(ctx, key, time.Now().Add(cfg.inProgressExpiryFor(ctx)))
+	if errors.Is(err, ErrRecordExists) {
+		return cfg.handleExisting(ctx, key, replay)
+	}
+	if err != nil {
+		return err
+	}
+
+	response, err := fn()
+	if err != nil {
+		expireErr := cfg.store.Expire(ctx, key)
+		if expireErr != nil {
+			return fmt.Errorf("%w, and failed to expire idempotency record: %s", err, expireErr.Error())
+		}
+		return err
+	}
+
+	return cfg.store.Complete(ctx, key, response, time.Now().Add(cfg.expiry))
+}
+
+// runDelivery is run for events with a delivery identity, such as an SQS message
+// ID, which is the key unless a key expression selects the key from payload.
+// Redeliveries of the event have the same identity, but separate sends of the same
+// payload do not.
+func (cfg config) runDelivery(ctx context.Context, id string, payload []byte, fn func() error) error {
+	run := func() ([]byte, error) {
+		return nil, fn()
+	}
+	if cfg.keyExpression != nil {
+		return cfg.run(ctx, payload, run, nil)
+	}
+	if id == "" {
+		if cfg.skipMissingKey {
+			return fn()
+		}
+		return ErrMissingKey
+	}
+	return cfg.run(ctx, []byte(id), run, nil)
+}
+
+func (cfg config) handleExisting(ctx context.Context, key string, replay func(response []byte) error) error {
+	record, err := cfg.store.Get(ctx, key)
+	if errors.Is(err, ErrRecordNotFound) {
+		// the record expired since it was claimed, so it is safe to retry later
+		return ErrInProgress
+	}
+	if err != nil {
+		return err
+	}
+
+	if record.Status != StatusCompleted {
+		return ErrInProgress
+	}
+	if replay != nil {
+		return replay(record.Response)
+	}
+	return nil
+}
+
+func (cfg config) inProgressExpiryFor(ctx context.Context) time.Duration {
+	if cfg.inProgressExpiry > 0 {
+		return cfg.inProgressExpiry
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		return time.Until(deadline)
+	}
+	return maxInProgressExpiry
+}
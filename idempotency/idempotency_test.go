@@ -0,0 +1,128 @@
+package idempotency
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Key(t *testing.T) {
+	cfg := newConfig(nil, []Option{WithKeyPrefix("orders")})
+
+	key, err := cfg.key([]byte(`{"order_id": 1}`))
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(key, "orders#"))
+	assert.Len(t, key, len("orders#")+64)
+
+	other, err := cfg.key([]byte(`{"order_id": 2}`))
+	assert.Nil(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestConfig_Key_Expression(t *testing.T) {
+	cfg := newConfig(nil, []Option{WithKeyExpression("[customer, order_id]")})
+
+	key, err := cfg.key([]byte(`{"customer": "dog", "order_id": 1, "sent_at": "2020-01-01"}`))
+	assert.Nil(t, err)
+	same, err := cfg.key([]byte(`{"order_id": 1, "customer": "dog", "sent_at": "2020-01-02"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, key, same)
+
+	_, err = cfg.key([]byte(`{"other": 1}`))
+	assert.Equal(t, ErrMissingKey, err)
+
+	_, err = cfg.key([]byte(`not json`))
+	assert.NotNil(t, err)
+}
+
+func TestConfig_Key_SkipMissingKey(t *testing.T) {
+	cfg := newConfig(nil, []Option{WithKeyExpression("order_id"), WithSkipMissingKey()})
+
+	key, err := cfg.key([]byte(`{"other": 1}`))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "", key)
+}
+
+func TestNewConfig_InvalidExpression(t *testing.T) {
+	assert.Panics(t, func() {
+		newConfig(nil, []Option{WithKeyExpression("[order_id")})
+	})
+}
+
+func TestConfig_Run(t *testing.T) {
+	store := NewMemoryStore()
+	cfg := newConfig(store, nil)
+	calls := 0
+	fn := func() ([]byte, error) {
+		calls++
+		return []byte("response"), nil
+	}
+	var replayed []byte
+	replay := func(response []byte) error {
+		replayed = response
+		return nil
+	}
+
+	assert.Nil(t, cfg.run(context.Background(), []byte("payload"), fn, replay))
+	assert.Equal(t, 1, calls)
+	assert.Nil(t, replayed)
+
+	assert.Nil(t, cfg.run(context.Background(), []byte("payload"), fn, replay))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, []byte("response"), replayed)
+
+	assert.Nil(t, cfg.run(context.Background(), []byte("other payload"), fn, replay))
+	assert.Equal(t, 2, calls)
+}
+
+func TestConfig_Run_Error(t *testing.T) {
+	store := NewMemoryStore()
+	cfg := newConfig(store, nil)
+	calls := 0
+	fn := func() ([]byte, error) {
+		calls++
+		if calls == 1 {
+			return nil, assert.AnError
+		}
+		return nil, nil
+	}
+
+	assert.Equal(t, assert.AnError, cfg.run(context.Background(), []byte("payload"), fn, nil))
+	assert.Nil(t, cfg.run(context.Background(), []byte("payload"), fn, nil))
+	assert.Equal(t, 2, calls)
+}
+
+func TestConfig_Run_InProgress(t *testing.T) {
+	store := NewMemoryStore()
+	cfg := newConfig(store, nil)
+	key, _ := cfg.key([]byte("payload"))
+	_ = store.PutInProgress(context.Background(), key, time.Now().Add(time.Minute))
+
+	err := cfg.run(context.Background(), []byte("payload"), func() ([]byte, error) {
+		t.Fatal("handler should not be called")
+		return nil, nil
+	}, nil)
+
+	assert.Equal(t, ErrInProgress, err)
+}
+
+func TestConfig_Run_InProgressExpiry(t *testing.T) {
+	store := NewMemoryStore()
+	cfg := newConfig(store, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var record Record
+	_ = cfg.run(ctx, []byte("payload"), func() ([]byte, error) {
+		key, _ := cfg.key([]byte("payload"))
+		record, _ = store.Get(ctx, key)
+		return nil, nil
+	}, nil)
+
+	assert.Equal(t, StatusInProgress, record.Status)
+	assert.WithinDuration(t, time.Now().Add(30*time.Second), record.ExpiresAt, time.Second)
+}
@@ -0,0 +1,83 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in memory Store. Records are only shared by invocations
+// handled by the same Lambda execution environment, so it is suited to tests and
+// to deduplicating records within a batch.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: make(map[string]Record),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.get(key)
+	if !ok {
+		return Record{}, ErrRecordNotFound
+	}
+	return record, nil
+}
+
+func (s *MemoryStore) PutInProgress(ctx context.Context, key string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.get(key); ok {
+		return ErrRecordExists
+	}
+	s.records[key] = Record{
+		Key:       key,
+		Status:    StatusInProgress,
+		ExpiresAt: expiresAt,
+	}
+	return nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, key string, response []byte, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = Record{
+		Key:       key,
+		Status:    StatusCompleted,
+		Response:  response,
+		ExpiresAt: expiresAt,
+	}
+	return nil
+}
+
+func (s *MemoryStore) Expire(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// get returns the unexpired record for key, deleting it if it has expired.
+func (s *MemoryStore) get(key string) (Record, bool) {
+	record, ok := s.records[key]
+	if !ok {
+		return Record{}, false
+	}
+	if !s.now().Before(record.ExpiresAt) {
+		delete(s.records, key)
+		return Record{}, false
+	}
+	return record, true
+}
@@ -0,0 +1,53 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	_, err := s.Get(ctx, "key")
+	assert.Equal(t, ErrRecordNotFound, err)
+
+	assert.Nil(t, s.PutInProgress(ctx, "key", now.Add(time.Minute)))
+	assert.Equal(t, ErrRecordExists, s.PutInProgress(ctx, "key", now.Add(time.Minute)))
+	record, err := s.Get(ctx, "key")
+	assert.Nil(t, err)
+	assert.Equal(t, StatusInProgress, record.Status)
+
+	assert.Nil(t, s.Complete(ctx, "key", []byte("response"), now.Add(time.Hour)))
+	record, err = s.Get(ctx, "key")
+	assert.Nil(t, err)
+	assert.Equal(t, Record{
+		Key:       "key",
+		Status:    StatusCompleted,
+		Response:  []byte("response"),
+		ExpiresAt: now.Add(time.Hour),
+	}, record)
+
+	assert.Nil(t, s.Expire(ctx, "key"))
+	_, err = s.Get(ctx, "key")
+	assert.Equal(t, ErrRecordNotFound, err)
+}
+
+func TestMemoryStore_ExpiredRecords(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	assert.Nil(t, s.PutInProgress(ctx, "key", now.Add(time.Minute)))
+	now = now.Add(time.Minute)
+
+	_, err := s.Get(ctx, "key")
+	assert.Equal(t, ErrRecordNotFound, err)
+	assert.Nil(t, s.PutInProgress(ctx, "key", now.Add(time.Minute)))
+}
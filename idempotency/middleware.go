@@ -0,0 +1,271 @@
+package idempotency
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/webbgeorge/lambdah/alb"
+	"github.com/webbgeorge/lambdah/api_gateway_proxy"
+	"github.com/webbgeorge/lambdah/api_gateway_v2"
+	"github.com/webbgeorge/lambdah/cloudwatch_events"
+	"github.com/webbgeorge/lambdah/dynamodb"
+	"github.com/webbgeorge/lambdah/generic"
+	"github.com/webbgeorge/lambdah/kinesis"
+	"github.com/webbgeorge/lambdah/s3"
+	"github.com/webbgeorge/lambdah/sns"
+	"github.com/webbgeorge/lambdah/sqs"
+)
+
+// SQSMiddleware makes an SQS handler idempotent. The key is derived from the
+// message ID, or with a key expression from the message body, which must be JSON.
+// Repeats of completed messages are skipped.
+func SQSMiddleware(store Store, opts ...Option) sqs.Middleware {
+	cfg := newConfig(store, opts)
+	return func(h sqs.HandlerFunc) sqs.HandlerFunc {
+		return func(c *sqs.Context) error {
+			return cfg.runDelivery(c.Context, c.Message.MessageId, []byte(c.Message.Body), func() error {
+				return h(c)
+			})
+		}
+	}
+}
+
+// SNSMiddleware makes an SNS handler idempotent. The key is derived from the SNS
+// message ID, or with a key expression from the SNS message, which must be JSON.
+// Repeats of completed messages are skipped.
+func SNSMiddleware(store Store, opts ...Option) sns.Middleware {
+	cfg := newConfig(store, opts)
+	return func(h sns.HandlerFunc) sns.HandlerFunc {
+		return func(c *sns.Context) error {
+			return cfg.runDelivery(c.Context, c.EventRecord.SNS.MessageID, []byte(c.EventRecord.SNS.Message), func() error {
+				return h(c)
+			})
+		}
+	}
+}
+
+// DynamoDBMiddleware makes a DynamoDB stream handler idempotent. The key is derived
+// from the stream record JSON, for example with the expression `eventID` or
+// `dynamodb.Keys.id.S`. Repeats of completed records are skipped.
+func DynamoDBMiddleware(store Store, opts ...Option) dynamodb.Middleware {
+	cfg := newConfig(store, opts)
+	return func(h dynamodb.HandlerFunc) dynamodb.HandlerFunc {
+		return func(c *dynamodb.Context) error {
+			payload, err := json.Marshal(c.EventRecord)
+			if err != nil {
+				return err
+			}
+			return cfg.run(c.Context, payload, func() ([]byte, error) {
+				return nil, h(c)
+			}, nil)
+		}
+	}
+}
+
+// KinesisMiddleware makes a Kinesis handler idempotent. The key is derived from
+// the record sequence number, or with a key expression from the record data,
+// which must be JSON. Repeats of completed records, such as records retried after
+// a later record failed, are skipped.
+func KinesisMiddleware(store Store, opts ...Option) kinesis.Middleware {
+	cfg := newConfig(store, opts)
+	return func(h kinesis.HandlerFunc) kinesis.HandlerFunc {
+		return func(c *kinesis.Context) error {
+			return cfg.runDelivery(c.Context, c.EventRecord.Kinesis.SequenceNumber, c.EventRecord.Kinesis.Data, func() error {
+				return h(c)
+			})
+		}
+	}
+}
+
+// S3Middleware makes an S3 event handler idempotent. The key is derived from the
+// event record JSON, for example with the expression `[s3.object.key, s3.object.sequencer]`.
+// Repeats of completed records are skipped.
+func S3Middleware(store Store, opts ...Option) s3.Middleware {
+	cfg := newConfig(store, opts)
+	return func(h s3.HandlerFunc) s3.HandlerFunc {
+		return func(c *s3.Context) error {
+			payload, err := json.Marshal(c.EventRecord)
+			if err != nil {
+				return err
+			}
+			return cfg.run(c.Context, payload, func() ([]byte, error) {
+				return nil, h(c)
+			}, nil)
+		}
+	}
+}
+
+// CloudWatchEventsMiddleware makes a CloudWatch events handler idempotent. The key
+// is derived from the event JSON, for example with the expression `id`. Repeats of
+// completed events are skipped.
+func CloudWatchEventsMiddleware(store Store, opts ...Option) cloudwatch_events.Middleware {
+	cfg := newConfig(store, opts)
+	return func(h cloudwatch_events.HandlerFunc) cloudwatch_events.HandlerFunc {
+		return func(c *cloudwatch_events.Context) error {
+			payload, err := json.Marshal(c.Event)
+			if err != nil {
+				return err
+			}
+			return cfg.run(c.Context, payload, func() ([]byte, error) {
+				return nil, h(c)
+			}, nil)
+		}
+	}
+}
+
+// GenericMiddleware makes a generic handler idempotent. The key is derived from
+// the event. Repeats of completed events replay the cached c.Response, which is
+// stored as JSON.
+func GenericMiddleware(store Store, opts ...Option) generic.Middleware {
+	cfg := newConfig(store, opts)
+	return func(h generic.HandlerFunc) generic.HandlerFunc {
+		return func(c *generic.Context) error {
+			return cfg.run(c.Context, c.Event, func() ([]byte, error) {
+				err := h(c)
+				if err != nil {
+					return nil, err
+				}
+				return json.Marshal(c.Response)
+			}, func(response []byte) error {
+				c.Response = json.RawMessage(response)
+				return nil
+			})
+		}
+	}
+}
+
+// APIGatewayProxyMiddleware makes an API Gateway proxy handler idempotent. The key
+// is selected from the request JSON by a key expression, which is required, for
+// example `headers."Idempotency-Key"`. Repeats of completed requests replay the
+// cached response.
+//
+// Requests without a key, and GET, HEAD and OPTIONS requests, are not made
+// idempotent, so that reads are never replayed. The key should identify the
+// caller as well as the request, for example
+// `[requestContext.authorizer.claims.sub, headers."Idempotency-Key"]`, so that
+// callers cannot replay each other's responses.
+//
+// Requests which are in progress return a 409 Error. Errors returned by the
+// handler are not cached, so this middleware should be called after the error
+// handler middleware.
+func APIGatewayProxyMiddleware(store Store, opts ...Option) api_gateway_proxy.Middleware {
+	cfg := newHTTPConfig(store, opts)
+	return func(h api_gateway_proxy.HandlerFunc) api_gateway_proxy.HandlerFunc {
+		return func(c *api_gateway_proxy.Context) error {
+			if isSafeMethod(c.Request.HTTPMethod) {
+				return h(c)
+			}
+			payload, err := json.Marshal(c.Request)
+			if err != nil {
+				return err
+			}
+			err = cfg.run(c.Context, payload, func() ([]byte, error) {
+				err := h(c)
+				if err != nil {
+					return nil, err
+				}
+				return json.Marshal(c.Response)
+			}, func(response []byte) error {
+				// replace the response, rather than merging the cached response into
+				// headers already set by the handler's middleware
+				var res events.APIGatewayProxyResponse
+				err := json.Unmarshal(response, &res)
+				if err != nil {
+					return err
+				}
+				c.Response = res
+				return nil
+			})
+			if errors.Is(err, ErrInProgress) {
+				return api_gateway_proxy.Error{
+					StatusCode: http.StatusConflict,
+					Message:    "Request already in progress",
+				}
+			}
+			return err
+		}
+	}
+}
+
+// APIGatewayV2Middleware makes an API Gateway HTTP API handler idempotent, see
+// APIGatewayProxyMiddleware. HTTP API header names are lower case, for example
+// `headers."idempotency-key"`.
+func APIGatewayV2Middleware(store Store, opts ...Option) api_gateway_v2.Middleware {
+	cfg := newHTTPConfig(store, opts)
+	return func(h api_gateway_v2.HandlerFunc) api_gateway_v2.HandlerFunc {
+		return func(c *api_gateway_v2.Context) error {
+			if isSafeMethod(c.Request.RequestContext.HTTP.Method) {
+				return h(c)
+			}
+			payload, err := json.Marshal(c.Request)
+			if err != nil {
+				return err
+			}
+			err = cfg.run(c.Context, payload, func() ([]byte, error) {
+				err := h(c)
+				if err != nil {
+					return nil, err
+				}
+				return json.Marshal(c.Response)
+			}, func(response []byte) error {
+				var res events.APIGatewayV2HTTPResponse
+				err := json.Unmarshal(response, &res)
+				if err != nil {
+					return err
+				}
+				c.Response = res
+				return nil
+			})
+			if errors.Is(err, ErrInProgress) {
+				return api_gateway_v2.Error{
+					StatusCode: http.StatusConflict,
+					Message:    "Request already in progress",
+				}
+			}
+			return err
+		}
+	}
+}
+
+// ALBMiddleware makes an Application Load Balancer handler idempotent, see
+// APIGatewayProxyMiddleware. ALB header names are lower case, for example
+// `headers."idempotency-key"`, or with multi-value headers enabled
+// `multiValueHeaders."idempotency-key"`.
+func ALBMiddleware(store Store, opts ...Option) alb.Middleware {
+	cfg := newHTTPConfig(store, opts)
+	return func(h alb.HandlerFunc) alb.HandlerFunc {
+		return func(c *alb.Context) error {
+			if isSafeMethod(c.Request.HTTPMethod) {
+				return h(c)
+			}
+			payload, err := json.Marshal(c.Request)
+			if err != nil {
+				return err
+			}
+			err = cfg.run(c.Context, payload, func() ([]byte, error) {
+				err := h(c)
+				if err != nil {
+					return nil, err
+				}
+				return json.Marshal(c.Response)
+			}, func(response []byte) error {
+				var res events.ALBTargetGroupResponse
+				err := json.Unmarshal(response, &res)
+				if err != nil {
+					return err
+				}
+				c.Response = res
+				return nil
+			})
+			if errors.Is(err, ErrInProgress) {
+				return alb.Error{
+					StatusCode: http.StatusConflict,
+					Message:    "Request already in progress",
+				}
+			}
+			return err
+		}
+	}
+}
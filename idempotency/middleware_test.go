@@ -0,0 +1,413 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/webbgeorge/lambdah/alb"
+	"github.com/webbgeorge/lambdah/api_gateway_proxy"
+	"github.com/webbgeorge/lambdah/api_gateway_v2"
+	"github.com/webbgeorge/lambdah/generic"
+	"github.com/webbgeorge/lambdah/kinesis"
+	"github.com/webbgeorge/lambdah/sns"
+	"github.com/webbgeorge/lambdah/sqs"
+)
+
+func TestSQSMiddleware(t *testing.T) {
+	processed := make([]string, 0)
+	h := sqs.HandlerFunc(func(c *sqs.Context) error {
+		processed = append(processed, c.Message.MessageId)
+		return nil
+	}).Middleware(SQSMiddleware(NewMemoryStore(), WithKeyExpression("order_id")))
+
+	err := h.ToLambdaHandler()(context.Background(), events.SQSEvent{
+		Records: []events.SQSMessage{
+			{MessageId: "message-1", Body: `{"order_id": 1, "attempt": 1}`},
+			{MessageId: "message-2", Body: `{"order_id": 2, "attempt": 1}`},
+			{MessageId: "message-3", Body: `{"order_id": 1, "attempt": 2}`},
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"message-1", "message-2"}, processed)
+}
+
+func TestSQSMiddleware_MessageIDKey(t *testing.T) {
+	processed := make([]string, 0)
+	h := sqs.HandlerFunc(func(c *sqs.Context) error {
+		processed = append(processed, c.Message.MessageId)
+		return nil
+	}).Middleware(SQSMiddleware(NewMemoryStore()))
+
+	err := h.ToLambdaHandler()(context.Background(), events.SQSEvent{
+		Records: []events.SQSMessage{
+			{MessageId: "message-1", Body: "same body"},
+			{MessageId: "message-2", Body: "same body"},
+			{MessageId: "message-1", Body: "same body"},
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"message-1", "message-2"}, processed)
+}
+
+func TestSQSMiddleware_MissingMessageID(t *testing.T) {
+	h := sqs.HandlerFunc(func(c *sqs.Context) error {
+		return nil
+	}).Middleware(SQSMiddleware(NewMemoryStore()))
+
+	err := h.ToLambdaHandler()(context.Background(), events.SQSEvent{
+		Records: []events.SQSMessage{{Body: "body"}},
+	})
+
+	assert.ErrorIs(t, err, ErrMissingKey)
+}
+
+func TestSNSMiddleware_MessageIDKey(t *testing.T) {
+	processed := make([]string, 0)
+	h := sns.HandlerFunc(func(c *sns.Context) error {
+		processed = append(processed, c.EventRecord.SNS.MessageID)
+		return nil
+	}).Middleware(SNSMiddleware(NewMemoryStore()))
+
+	err := h.ToLambdaHandler()(context.Background(), events.SNSEvent{
+		Records: []events.SNSEventRecord{
+			{SNS: events.SNSEntity{MessageID: "message-1", Message: "same message"}},
+			{SNS: events.SNSEntity{MessageID: "message-2", Message: "same message"}},
+			{SNS: events.SNSEntity{MessageID: "message-1", Message: "same message"}},
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"message-1", "message-2"}, processed)
+}
+
+func TestSQSMiddleware_InProgress(t *testing.T) {
+	store := NewMemoryStore()
+	mw := SQSMiddleware(store)
+	key, _ := newConfig(store, nil).key([]byte("message-1"))
+	_ = store.PutInProgress(context.Background(), key, time.Now().Add(time.Minute))
+
+	h := sqs.HandlerFunc(func(c *sqs.Context) error {
+		return nil
+	}).Middleware(mw)
+
+	res, err := h.ToLambdaPartialBatchHandler()(context.Background(), events.SQSEvent{
+		Records: []events.SQSMessage{
+			{MessageId: "message-1", Body: "body"},
+			{MessageId: "message-2", Body: "body"},
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []events.SQSBatchItemFailure{{ItemIdentifier: "message-1"}}, res.BatchItemFailures)
+}
+
+func TestKinesisMiddleware(t *testing.T) {
+	processed := make([]string, 0)
+	h := kinesis.HandlerFunc(func(c *kinesis.Context) error {
+		processed = append(processed, c.EventRecord.Kinesis.SequenceNumber)
+		return nil
+	}).Middleware(KinesisMiddleware(NewMemoryStore(), WithKeyExpression("order_id")))
+
+	res, err := h.ToLambdaPartialBatchHandler()(context.Background(), events.KinesisEvent{
+		Records: []events.KinesisEventRecord{
+			{Kinesis: events.KinesisRecord{SequenceNumber: "1", Data: []byte(`{"order_id": 1, "attempt": 1}`)}},
+			{Kinesis: events.KinesisRecord{SequenceNumber: "2", Data: []byte(`{"order_id": 2, "attempt": 1}`)}},
+			{Kinesis: events.KinesisRecord{SequenceNumber: "3", Data: []byte(`{"order_id": 1, "attempt": 2}`)}},
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Empty(t, res.BatchItemFailures)
+	assert.Equal(t, []string{"1", "2"}, processed)
+}
+
+func TestKinesisMiddleware_SequenceNumberKey(t *testing.T) {
+	processed := make([]string, 0)
+	h := kinesis.HandlerFunc(func(c *kinesis.Context) error {
+		processed = append(processed, c.EventRecord.Kinesis.SequenceNumber)
+		return nil
+	}).Middleware(KinesisMiddleware(NewMemoryStore()))
+
+	res, err := h.ToLambdaPartialBatchHandler()(context.Background(), events.KinesisEvent{
+		Records: []events.KinesisEventRecord{
+			{Kinesis: events.KinesisRecord{SequenceNumber: "1", Data: []byte("same data")}},
+			{Kinesis: events.KinesisRecord{SequenceNumber: "2", Data: []byte("same data")}},
+			{Kinesis: events.KinesisRecord{SequenceNumber: "1", Data: []byte("same data")}},
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Empty(t, res.BatchItemFailures)
+	assert.Equal(t, []string{"1", "2"}, processed)
+}
+
+func TestAPIGatewayProxyMiddleware(t *testing.T) {
+	store := NewMemoryStore()
+	calls := 0
+	h := api_gateway_proxy.HandlerFunc(func(c *api_gateway_proxy.Context) error {
+		calls++
+		return c.JSON(http.StatusCreated, map[string]int{"call": calls})
+	}).Middleware(
+		api_gateway_proxy.ErrorHandlerMiddleware(),
+		APIGatewayProxyMiddleware(store, WithKeyExpression(`headers."Idempotency-Key"`)),
+	)
+	awsHandler := h.ToLambdaHandler()
+
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/orders",
+		Headers:    map[string]string{"Idempotency-Key": "abc"},
+	}
+	res1, err := awsHandler(context.Background(), request)
+	assert.Nil(t, err)
+	res2, err := awsHandler(context.Background(), request)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 201, res2.StatusCode)
+	assert.Equal(t, `{"call":1}`, res2.Body)
+	assert.Equal(t, res1, res2)
+}
+
+func TestAPIGatewayProxyMiddleware_ReplayReplacesHeaders(t *testing.T) {
+	attempt := 0
+	h := api_gateway_proxy.HandlerFunc(func(c *api_gateway_proxy.Context) error {
+		c.Response.Headers["X-Handler"] = "1"
+		return c.JSON(http.StatusCreated, nil)
+	}).Middleware(
+		func(h api_gateway_proxy.HandlerFunc) api_gateway_proxy.HandlerFunc {
+			return func(c *api_gateway_proxy.Context) error {
+				attempt++
+				c.Response.Headers = map[string]string{"X-Handler": "0"}
+				if attempt == 2 {
+					c.Response.Headers["X-Retry"] = "true"
+				}
+				return h(c)
+			}
+		},
+		APIGatewayProxyMiddleware(NewMemoryStore(), WithKeyExpression(`headers."Idempotency-Key"`)),
+	)
+	awsHandler := h.ToLambdaHandler()
+
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Headers:    map[string]string{"Idempotency-Key": "abc"},
+	}
+	res1, err := awsHandler(context.Background(), request)
+	assert.Nil(t, err)
+	res2, err := awsHandler(context.Background(), request)
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string]string{"X-Handler": "1"}, res1.Headers)
+	assert.Equal(t, res1.Headers, res2.Headers)
+}
+
+func TestAPIGatewayProxyMiddleware_InProgress(t *testing.T) {
+	store := NewMemoryStore()
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	h := api_gateway_proxy.HandlerFunc(func(c *api_gateway_proxy.Context) error {
+		close(started)
+		<-finish
+		return c.String(http.StatusOK, "done")
+	}).Middleware(
+		api_gateway_proxy.ErrorHandlerMiddleware(),
+		APIGatewayProxyMiddleware(store, WithKeyExpression(`headers."Idempotency-Key"`)),
+	)
+	awsHandler := h.ToLambdaHandler()
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/orders",
+		Headers:    map[string]string{"Idempotency-Key": "abc"},
+		Body:       `{"order_id": 1}`,
+	}
+
+	done := make(chan events.APIGatewayProxyResponse)
+	go func() {
+		res, _ := awsHandler(context.Background(), request)
+		done <- res
+	}()
+	<-started
+
+	res, err := awsHandler(context.Background(), request)
+	assert.Nil(t, err)
+	assert.Equal(t, 409, res.StatusCode)
+	assert.Equal(t, `{"message":"Request already in progress"}`, res.Body)
+
+	close(finish)
+	assert.Equal(t, 200, (<-done).StatusCode)
+}
+
+func TestAPIGatewayProxyMiddleware_NotIdempotent(t *testing.T) {
+	testCases := []struct {
+		name    string
+		request events.APIGatewayProxyRequest
+	}{
+		{
+			name: "safe method",
+			request: events.APIGatewayProxyRequest{
+				HTTPMethod: http.MethodGet,
+				Path:       "/me",
+				Headers:    map[string]string{"Idempotency-Key": "abc"},
+			},
+		},
+		{
+			name: "missing key",
+			request: events.APIGatewayProxyRequest{
+				HTTPMethod: http.MethodPost,
+				Path:       "/orders",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			calls := 0
+			h := api_gateway_proxy.HandlerFunc(func(c *api_gateway_proxy.Context) error {
+				calls++
+				return c.JSON(http.StatusOK, map[string]int{"call": calls})
+			}).Middleware(
+				api_gateway_proxy.ErrorHandlerMiddleware(),
+				APIGatewayProxyMiddleware(store, WithKeyExpression(`headers."Idempotency-Key"`)),
+			)
+			awsHandler := h.ToLambdaHandler()
+
+			_, err := awsHandler(context.Background(), tc.request)
+			assert.Nil(t, err)
+			res, err := awsHandler(context.Background(), tc.request)
+			assert.Nil(t, err)
+
+			assert.Equal(t, 2, calls)
+			assert.Equal(t, `{"call":2}`, res.Body)
+			assert.Empty(t, store.records)
+		})
+	}
+}
+
+func TestHTTPMiddleware_RequiresKeyExpression(t *testing.T) {
+	store := NewMemoryStore()
+
+	assert.PanicsWithValue(t, ErrKeyExpressionRequired, func() { APIGatewayProxyMiddleware(store) })
+	assert.PanicsWithValue(t, ErrKeyExpressionRequired, func() { APIGatewayV2Middleware(store) })
+	assert.PanicsWithValue(t, ErrKeyExpressionRequired, func() { ALBMiddleware(store) })
+}
+
+func TestAPIGatewayV2Middleware(t *testing.T) {
+	store := NewMemoryStore()
+	calls := 0
+	h := api_gateway_v2.HandlerFunc(func(c *api_gateway_v2.Context) error {
+		calls++
+		return c.JSON(http.StatusCreated, map[string]int{"call": calls})
+	}).Middleware(
+		api_gateway_v2.ErrorHandlerMiddleware(),
+		APIGatewayV2Middleware(store, WithKeyExpression(`headers."idempotency-key"`)),
+	)
+	awsHandler := h.ToLambdaHandler()
+
+	post := events.APIGatewayV2HTTPRequest{
+		RawPath: "/orders",
+		Headers: map[string]string{"idempotency-key": "abc"},
+	}
+	post.RequestContext.HTTP.Method = http.MethodPost
+	get := post
+	get.RequestContext.HTTP.Method = http.MethodGet
+
+	_, _ = awsHandler(context.Background(), post)
+	res, err := awsHandler(context.Background(), post)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"call":1}`, res.Body)
+
+	res, err = awsHandler(context.Background(), get)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"call":2}`, res.Body)
+}
+
+func TestALBMiddleware(t *testing.T) {
+	store := NewMemoryStore()
+	calls := 0
+	h := alb.HandlerFunc(func(c *alb.Context) error {
+		calls++
+		return c.JSON(http.StatusCreated, map[string]int{"call": calls})
+	}).Middleware(
+		alb.ErrorHandlerMiddleware(),
+		ALBMiddleware(store, WithKeyExpression(`headers."idempotency-key"`)),
+	)
+	awsHandler := h.ToLambdaHandler()
+
+	post := events.ALBTargetGroupRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/orders",
+		Headers:    map[string]string{"idempotency-key": "abc"},
+	}
+	head := post
+	head.HTTPMethod = http.MethodHead
+
+	_, _ = awsHandler(context.Background(), post)
+	res, err := awsHandler(context.Background(), post)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"call":1}`, res.Body)
+
+	_, err = awsHandler(context.Background(), head)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestALBMiddleware_ReplayReplacesHeaders(t *testing.T) {
+	attempt := 0
+	h := alb.HandlerFunc(func(c *alb.Context) error {
+		c.Response.MultiValueHeaders["x-handler"] = []string{"1"}
+		return c.JSON(http.StatusCreated, nil)
+	}).Middleware(
+		func(h alb.HandlerFunc) alb.HandlerFunc {
+			return func(c *alb.Context) error {
+				attempt++
+				c.Response.MultiValueHeaders = map[string][]string{"x-handler": {"0"}}
+				if attempt == 2 {
+					c.Response.MultiValueHeaders["x-retry"] = []string{"true"}
+				}
+				return h(c)
+			}
+		},
+		ALBMiddleware(NewMemoryStore(), WithKeyExpression(`multiValueHeaders."idempotency-key"`)),
+	)
+	awsHandler := h.ToLambdaHandler()
+
+	request := events.ALBTargetGroupRequest{
+		HTTPMethod:        http.MethodPost,
+		MultiValueHeaders: map[string][]string{"idempotency-key": {"abc"}},
+	}
+	res1, err := awsHandler(context.Background(), request)
+	assert.Nil(t, err)
+	res2, err := awsHandler(context.Background(), request)
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string][]string{"x-handler": {"1"}}, res1.MultiValueHeaders)
+	assert.Equal(t, res1.MultiValueHeaders, res2.MultiValueHeaders)
+}
+
+func TestGenericMiddleware(t *testing.T) {
+	calls := 0
+	h := generic.HandlerFunc(func(c *generic.Context) error {
+		calls++
+		c.Response = map[string]int{"call": calls}
+		return nil
+	}).Middleware(GenericMiddleware(NewMemoryStore()))
+	awsHandler := h.ToLambdaHandler()
+
+	_, err := awsHandler(context.Background(), []byte(`{"order_id": 1}`))
+	assert.Nil(t, err)
+	res, err := awsHandler(context.Background(), []byte(`{"order_id": 1}`))
+	assert.Nil(t, err)
+
+	assert.Equal(t, 1, calls)
+	b, _ := json.Marshal(res)
+	assert.Equal(t, `{"call":1}`, string(b))
+}
@@ -0,0 +1,46 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusInProgress Status = "IN_PROGRESS"
+	StatusCompleted  Status = "COMPLETED"
+)
+
+var (
+	// ErrRecordNotFound is returned by Store.Get when there is no unexpired record for the key
+	ErrRecordNotFound = errors.New("idempotency record not found")
+	// ErrRecordExists is returned by Store.PutInProgress when there is already an
+	// unexpired record for the key
+	ErrRecordExists = errors.New("idempotency record already exists")
+)
+
+// Record is the idempotency record of a key. Response is the response of a completed
+// handler, if the handler type has one.
+type Record struct {
+	Key       string
+	Status    Status
+	Response  []byte
+	ExpiresAt time.Time
+}
+
+// Store persists idempotency records. Implementations must treat records which
+// have expired as if they do not exist.
+type Store interface {
+	// Get the record for key, or ErrRecordNotFound.
+	Get(ctx context.Context, key string) (Record, error)
+	// PutInProgress atomically claims key, creating an in progress record, or
+	// returns ErrRecordExists if there is already a record for key.
+	PutInProgress(ctx context.Context, key string, expiresAt time.Time) error
+	// Complete the record for key, storing the response.
+	Complete(ctx context.Context, key string, response []byte, expiresAt time.Time) error
+	// Expire the record for key, so that it can be claimed again, for example
+	// after the handler fails.
+	Expire(ctx context.Context, key string) error
+}
@@ -0,0 +1,44 @@
+package batch
+
+import (
+	"context"
+	"sync"
+)
+
+// Process calls fn once for each record index in [0, count), using a pool of at
+// most concurrency workers.
+//
+// The returned slice holds the error returned by fn for each record index. Once
+// ctx is done, no further records are started and the remaining records are given
+// the context's error instead. Records already in progress are allowed to finish.
+func Process(ctx context.Context, concurrency int, count int, fn func(i int) error) []error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	errs := make([]error, count)
+	indexes := make(chan int)
+
+	wg := sync.WaitGroup{}
+	for w := 0; w < concurrency && w < count; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				errs[i] = fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return errs
+}
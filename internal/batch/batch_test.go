@@ -0,0 +1,89 @@
+package batch
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcess_AllRecords(t *testing.T) {
+	var processed int32
+	errs := Process(context.Background(), 3, 10, func(i int) error {
+		atomic.AddInt32(&processed, 1)
+		if i%2 == 0 {
+			return assert.AnError
+		}
+		return nil
+	})
+
+	assert.Equal(t, int32(10), processed)
+	assert.Len(t, errs, 10)
+	for i, err := range errs {
+		if i%2 == 0 {
+			assert.Equal(t, assert.AnError, err)
+		} else {
+			assert.Nil(t, err)
+		}
+	}
+}
+
+func TestProcess_BoundedConcurrency(t *testing.T) {
+	var inFlight, maxInFlight int32
+	Process(context.Background(), 2, 10, func(i int) error {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return nil
+	})
+
+	assert.Equal(t, int32(2), maxInFlight)
+}
+
+func TestProcess_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := Process(ctx, 1, 3, func(i int) error {
+		cancel()
+		return nil
+	})
+
+	assert.Equal(t, []error{nil, context.Canceled, context.Canceled}, errs)
+}
+
+func TestProcess_InvalidConcurrency(t *testing.T) {
+	errs := Process(context.Background(), 0, 2, func(i int) error {
+		return nil
+	})
+
+	assert.Equal(t, []error{nil, nil}, errs)
+}
+
+type testContext struct {
+	Record int
+}
+
+func TestHandler_Process(t *testing.T) {
+	h := Handler[testContext]{
+		HandlerFunc: func(c *testContext) error {
+			if c.Record == 1 {
+				return assert.AnError
+			}
+			return nil
+		},
+		Concurrency: 2,
+	}
+
+	errs := h.Process(context.Background(), 3, func(i int) *testContext {
+		return &testContext{Record: i}
+	})
+
+	assert.Equal(t, []error{nil, assert.AnError, nil}, errs)
+}
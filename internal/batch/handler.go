@@ -0,0 +1,22 @@
+package batch
+
+import (
+	"context"
+)
+
+// Handler is the shared implementation of the ConcurrentHandler of each batch
+// handler package, which calls HandlerFunc with a new context of type C for each
+// record of an event, concurrently.
+type Handler[C any] struct {
+	HandlerFunc func(c *C) error
+	Concurrency int
+}
+
+// Process calls HandlerFunc with the context of each of count records, created by
+// newContext, using a pool of at most Concurrency workers. See Process for the
+// errors returned.
+func (h Handler[C]) Process(ctx context.Context, count int, newContext func(i int) *C) []error {
+	return Process(ctx, h.Concurrency, count, func(i int) error {
+		return h.HandlerFunc(newContext(i))
+	})
+}
@@ -0,0 +1,36 @@
+// Package clone copies the maps and slices of events and responses, so that the
+// copy of a handler Context given to a handler by TimeoutMiddleware shares none of
+// them with the Context of earlier middleware.
+package clone
+
+// Map returns a copy of m, or nil if m is nil.
+func Map[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return nil
+	}
+	c := make(map[K]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// Slice returns a copy of s, or nil if s is nil.
+func Slice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
+// MultiValueMap returns a copy of m, including its values.
+func MultiValueMap(m map[string][]string) map[string][]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string][]string, len(m))
+	for k, v := range m {
+		c[k] = Slice(v)
+	}
+	return c
+}
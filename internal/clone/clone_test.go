@@ -0,0 +1,37 @@
+package clone
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap(t *testing.T) {
+	m := map[string]int{"a": 1}
+
+	c := Map(m)
+	c["a"] = 2
+
+	assert.Equal(t, map[string]int{"a": 1}, m)
+	assert.Nil(t, Map[string, int](nil))
+}
+
+func TestSlice(t *testing.T) {
+	s := []byte("abc")
+
+	c := Slice(s)
+	c[0] = 'x'
+
+	assert.Equal(t, []byte("abc"), s)
+	assert.Nil(t, Slice[byte](nil))
+}
+
+func TestMultiValueMap(t *testing.T) {
+	m := map[string][]string{"Set-Cookie": {"a=1"}}
+
+	c := MultiValueMap(m)
+	c["Set-Cookie"][0] = "b=2"
+
+	assert.Equal(t, map[string][]string{"Set-Cookie": {"a=1"}}, m)
+	assert.Nil(t, MultiValueMap(nil))
+}
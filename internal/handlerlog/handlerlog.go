@@ -0,0 +1,39 @@
+// Package handlerlog builds the loggers of the LoggerMiddleware of each handler
+// package, which differ only in the fields of their events.
+package handlerlog
+
+import (
+	"context"
+	"io"
+
+	"github.com/webbgeorge/lambdah/log"
+
+	"github.com/rs/zerolog"
+)
+
+// New returns a logger writing to w, with fields, the handler type, the correlation
+// ID and trace fields of ctx, and then the fields of the event being handled.
+//
+// fields are copied, as they are shared by every invocation, and by records which
+// may be processed concurrently.
+func New(
+	ctx context.Context,
+	w io.Writer,
+	handlerType string,
+	fields map[string]string,
+	eventFields map[string]string,
+) *zerolog.Logger {
+	logFields := make(map[string]string, len(fields)+len(eventFields)+2)
+	for k, v := range fields {
+		logFields[k] = v
+	}
+	logFields["handler_type"] = handlerType
+	logFields["correlation_id"] = log.CorrelationIDFromContext(ctx)
+	for k, v := range log.TraceFields(ctx) {
+		logFields[k] = v
+	}
+	for k, v := range eventFields {
+		logFields[k] = v
+	}
+	return log.NewLogger(w, logFields)
+}
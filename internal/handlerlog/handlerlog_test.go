@@ -0,0 +1,31 @@
+package handlerlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/webbgeorge/lambdah/log"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	ctx := log.WithCorrelationID(context.Background(), "correlation-1")
+	ctx = log.WithTraceContext(ctx, log.TraceContext{TraceID: "0af7651916cd43dd8448eb211c80319c"})
+	fields := map[string]string{"service": "orders"}
+	buf := &bytes.Buffer{}
+
+	logger := New(ctx, buf, "sqs", fields, map[string]string{"queue_arn": "arn:aws:sqs:eu-west-1:123456789012:orders"})
+	logger.Info().Msg("msg")
+
+	var entry map[string]interface{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "orders", entry["service"])
+	assert.Equal(t, "sqs", entry["handler_type"])
+	assert.Equal(t, "correlation-1", entry["correlation_id"])
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", entry["trace_id"])
+	assert.Equal(t, "arn:aws:sqs:eu-west-1:123456789012:orders", entry["queue_arn"])
+	assert.Equal(t, map[string]string{"service": "orders"}, fields)
+}
@@ -0,0 +1,36 @@
+package timeout
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/webbgeorge/lambdah"
+)
+
+// Run calls fn using lambdah.WithTimeout, and reports whether fn returned before
+// the context given to it was done.
+//
+// Only if fn returned may the caller read the state fn was writing, such as the
+// copy of a handler Context given to it by TimeoutMiddleware. Otherwise fn is
+// still running in the background, whether the deadline was reached or the parent
+// context was cancelled.
+func Run(ctx context.Context, buffer time.Duration, fn func(ctx context.Context) error) (bool, error) {
+	returned := make(chan struct{})
+	err := lambdah.WithTimeout(ctx, buffer, func(ctx context.Context) error {
+		defer close(returned)
+		return fn(ctx)
+	})
+
+	// errors of the ctx.Done() branch of WithTimeout, which fn may have raced
+	var timeoutErr lambdah.TimeoutError
+	if errors.As(err, &timeoutErr) || errors.Is(err, context.Canceled) {
+		return false, err
+	}
+	select {
+	case <-returned:
+		return true, err
+	default:
+		return false, err
+	}
+}
@@ -0,0 +1,61 @@
+package timeout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/webbgeorge/lambdah"
+)
+
+func TestRun_Returned(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	returned, err := Run(ctx, 100*time.Millisecond, func(ctx context.Context) error {
+		return assert.AnError
+	})
+
+	assert.True(t, returned)
+	assert.Equal(t, assert.AnError, err)
+}
+
+func TestRun_NoDeadline(t *testing.T) {
+	returned, err := Run(context.Background(), 100*time.Millisecond, func(ctx context.Context) error {
+		return nil
+	})
+
+	assert.True(t, returned)
+	assert.Nil(t, err)
+}
+
+func TestRun_TimesOut(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	finish := make(chan struct{})
+	defer close(finish)
+
+	returned, err := Run(ctx, 150*time.Millisecond, func(ctx context.Context) error {
+		<-finish
+		return nil
+	})
+
+	assert.False(t, returned)
+	assert.IsType(t, lambdah.TimeoutError{}, err)
+}
+
+func TestRun_ParentCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	finish := make(chan struct{})
+	defer close(finish)
+
+	returned, err := Run(ctx, 100*time.Millisecond, func(ctx context.Context) error {
+		cancel()
+		<-finish
+		return nil
+	})
+
+	assert.False(t, returned)
+	assert.Equal(t, context.Canceled, err)
+}
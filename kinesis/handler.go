@@ -0,0 +1,196 @@
+package kinesis
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/webbgeorge/lambdah"
+	"github.com/webbgeorge/lambdah/internal/batch"
+	"github.com/webbgeorge/lambdah/internal/clone"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type Context struct {
+	Context     context.Context
+	EventRecord events.KinesisEventRecord
+}
+
+// Bind the data of the record, which is decoded from base64 by the event, as JSON.
+func (c *Context) Bind(v interface{}) error {
+	err := json.Unmarshal(c.EventRecord.Kinesis.Data, v)
+	if err != nil {
+		return err
+	}
+
+	return lambdah.Validate(v)
+}
+
+// ShardID returns the ID of the shard the record is from, which is the prefix of
+// its event ID, for example `shardId-000000000000`.
+func (c *Context) ShardID() string {
+	shardID, _, _ := strings.Cut(c.EventRecord.EventID, ":")
+	return shardID
+}
+
+type HandlerFunc func(c *Context) error
+
+func (hf HandlerFunc) Start() {
+	lambda.Start(hf.ToLambdaHandler())
+}
+
+// Apply middleware to the handler func.
+//
+// Middleware is called in the order it is given to this function.
+func (hf HandlerFunc) Middleware(middleware ...Middleware) HandlerFunc {
+	// apply middleware in reverse order
+	for i := len(middleware) - 1; i >= 0; i-- {
+		hf = middleware[i](hf)
+	}
+	return hf
+}
+
+// Get the AWS Lambda handler of the handler func.
+//
+// Useful if you need to call AWS lambda.Start(...) directly,
+// not required in most cases.
+func (hf HandlerFunc) ToLambdaHandler() func(ctx context.Context, event events.KinesisEvent) error {
+	return func(ctx context.Context, event events.KinesisEvent) error {
+		for _, eventRecord := range event.Records {
+			c := &Context{
+				Context:     ctx,
+				EventRecord: eventRecord,
+			}
+			err := hf(c)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Start the handler in partial batch response mode, see ToLambdaPartialBatchHandler.
+//
+// The Lambda event source mapping must have `ReportBatchItemFailures` enabled in
+// its FunctionResponseTypes for Kinesis to act on the returned failures.
+func (hf HandlerFunc) StartPartialBatch() {
+	lambda.Start(hf.ToLambdaPartialBatchHandler())
+}
+
+// Get the AWS Lambda handler of the handler func in partial batch response mode.
+//
+// Records are processed in order until one fails. Its sequence number is returned
+// as the only batch item failure, which Lambda uses as a checkpoint: the records
+// before it are not retried, and the shard is retried from the failed record.
+// Later records are not processed, as they will be retried with it.
+func (hf HandlerFunc) ToLambdaPartialBatchHandler() func(
+	ctx context.Context,
+	event events.KinesisEvent,
+) (events.KinesisEventResponse, error) {
+	return func(ctx context.Context, event events.KinesisEvent) (events.KinesisEventResponse, error) {
+		errs := make([]error, len(event.Records))
+		for i, eventRecord := range event.Records {
+			c := &Context{
+				Context:     ctx,
+				EventRecord: eventRecord,
+			}
+			errs[i] = hf(c)
+			if errs[i] != nil {
+				break
+			}
+		}
+		return batchItemFailures(event, errs), nil
+	}
+}
+
+// ConcurrentHandler processes the records of each event concurrently, see HandlerFunc.Concurrent.
+type ConcurrentHandler struct {
+	handler batch.Handler[Context]
+}
+
+// Process the records of each event concurrently, using a pool of at most
+// concurrency workers. Each record is given its own *Context. Records are not
+// processed in order, including records with the same partition key.
+//
+// All records are processed even if some fail, and the errors are returned
+// together as a lambdah.BatchError. If the Lambda deadline is reached, no further
+// records are started and each remaining record fails with the context's error.
+func (hf HandlerFunc) Concurrent(concurrency int) ConcurrentHandler {
+	return ConcurrentHandler{
+		handler: batch.Handler[Context]{HandlerFunc: hf, Concurrency: concurrency},
+	}
+}
+
+func (ch ConcurrentHandler) Start() {
+	lambda.Start(ch.ToLambdaHandler())
+}
+
+// Get the AWS Lambda handler of the concurrent handler.
+//
+// Useful if you need to call AWS lambda.Start(...) directly,
+// not required in most cases.
+func (ch ConcurrentHandler) ToLambdaHandler() func(ctx context.Context, event events.KinesisEvent) error {
+	return func(ctx context.Context, event events.KinesisEvent) error {
+		errs := ch.process(ctx, event)
+		return lambdah.NewBatchError(errs)
+	}
+}
+
+func (ch ConcurrentHandler) process(ctx context.Context, event events.KinesisEvent) []error {
+	return ch.handler.Process(ctx, len(event.Records), func(i int) *Context {
+		return &Context{
+			Context:     ctx,
+			EventRecord: event.Records[i],
+		}
+	})
+}
+
+// Start the concurrent handler in partial batch response mode, see
+// HandlerFunc.StartPartialBatch.
+func (ch ConcurrentHandler) StartPartialBatch() {
+	lambda.Start(ch.ToLambdaPartialBatchHandler())
+}
+
+// Get the AWS Lambda handler of the concurrent handler in partial batch response mode.
+//
+// The sequence number of the first failed record in the batch, including any not
+// started before the Lambda deadline, is returned as the checkpoint. Records after
+// it are retried, even if they succeeded.
+func (ch ConcurrentHandler) ToLambdaPartialBatchHandler() func(
+	ctx context.Context,
+	event events.KinesisEvent,
+) (events.KinesisEventResponse, error) {
+	return func(ctx context.Context, event events.KinesisEvent) (events.KinesisEventResponse, error) {
+		errs := ch.process(ctx, event)
+		return batchItemFailures(event, errs), nil
+	}
+}
+
+// batchItemFailures returns the sequence number of the first failed record, as
+// Lambda checkpoints Kinesis batches at the lowest reported sequence number.
+func batchItemFailures(event events.KinesisEvent, errs []error) events.KinesisEventResponse {
+	res := events.KinesisEventResponse{
+		BatchItemFailures: make([]events.KinesisBatchItemFailure, 0),
+	}
+	for i, err := range errs {
+		if err != nil {
+			res.BatchItemFailures = append(res.BatchItemFailures, events.KinesisBatchItemFailure{
+				ItemIdentifier: event.Records[i].Kinesis.SequenceNumber,
+			})
+			break
+		}
+	}
+	return res
+}
+
+// clone returns a copy of the context which shares no maps or slices with c, so
+// that a handler left running in the background after a timeout does not race
+// with earlier middleware.
+func (c *Context) clone() Context {
+	tc := *c
+	tc.EventRecord.Kinesis.Data = clone.Slice(c.EventRecord.Kinesis.Data)
+	return tc
+}
@@ -0,0 +1,315 @@
+package kinesis
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/webbgeorge/lambdah"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func record(sequenceNumber string, data string) events.KinesisEventRecord {
+	return events.KinesisEventRecord{
+		EventID: "shardId-000000000000:" + sequenceNumber,
+		Kinesis: events.KinesisRecord{
+			SequenceNumber: sequenceNumber,
+			Data:           []byte(data),
+		},
+	}
+}
+
+func TestKinesisHandler_Success_OneEvent(t *testing.T) {
+	callCount := 0
+	h := func(c *Context) error {
+		callCount++
+		assert.Equal(t, "1", c.EventRecord.Kinesis.SequenceNumber)
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).ToLambdaHandler()
+	err := awsHandler(
+		context.Background(),
+		events.KinesisEvent{Records: []events.KinesisEventRecord{record("1", "test data")}},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, callCount)
+}
+
+func TestKinesisHandler_Success_MultipleEvents(t *testing.T) {
+	sequenceNumbers := make([]string, 0)
+	h := func(c *Context) error {
+		sequenceNumbers = append(sequenceNumbers, c.EventRecord.Kinesis.SequenceNumber)
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).ToLambdaHandler()
+	err := awsHandler(
+		context.Background(),
+		events.KinesisEvent{Records: []events.KinesisEventRecord{
+			record("1", "test data 1"),
+			record("2", "test data 2"),
+		}},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "2"}, sequenceNumbers)
+}
+
+func TestKinesisHandler_Error(t *testing.T) {
+	callCount := 0
+	h := func(c *Context) error {
+		callCount++
+		return assert.AnError
+	}
+
+	awsHandler := HandlerFunc(h).ToLambdaHandler()
+	err := awsHandler(
+		context.Background(),
+		events.KinesisEvent{Records: []events.KinesisEventRecord{
+			record("1", "test data 1"),
+			record("2", "test data 2"),
+		}},
+	)
+
+	assert.Equal(t, assert.AnError, err)
+	assert.Equal(t, 1, callCount)
+}
+
+func TestKinesisConcurrentHandler_Success(t *testing.T) {
+	var callCount int32
+	h := func(c *Context) error {
+		atomic.AddInt32(&callCount, 1)
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).Concurrent(2).ToLambdaHandler()
+	err := awsHandler(
+		context.Background(),
+		events.KinesisEvent{Records: []events.KinesisEventRecord{
+			record("1", "test data 1"),
+			record("2", "test data 2"),
+			record("3", "test data 3"),
+		}},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), callCount)
+}
+
+func TestKinesisConcurrentHandler_Error(t *testing.T) {
+	var callCount int32
+	h := func(c *Context) error {
+		atomic.AddInt32(&callCount, 1)
+		return assert.AnError
+	}
+
+	awsHandler := HandlerFunc(h).Concurrent(2).ToLambdaHandler()
+	err := awsHandler(
+		context.Background(),
+		events.KinesisEvent{Records: []events.KinesisEventRecord{
+			record("1", "test data 1"),
+			record("2", "test data 2"),
+			record("3", "test data 3"),
+		}},
+	)
+
+	var batchErr lambdah.BatchError
+	assert.True(t, errors.As(err, &batchErr))
+	assert.Len(t, batchErr.Errors, 3)
+	assert.True(t, errors.Is(err, assert.AnError))
+	assert.Equal(t, int32(3), callCount)
+}
+
+func TestKinesisPartialBatchHandler_AllSuccess(t *testing.T) {
+	callCount := 0
+	h := func(c *Context) error {
+		callCount++
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).ToLambdaPartialBatchHandler()
+	res, err := awsHandler(
+		context.Background(),
+		events.KinesisEvent{Records: []events.KinesisEventRecord{
+			record("1", "test data 1"),
+			record("2", "test data 2"),
+		}},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, callCount)
+	assert.Empty(t, res.BatchItemFailures)
+}
+
+func TestKinesisPartialBatchHandler_ReportsFirstFailure(t *testing.T) {
+	sequenceNumbers := make([]string, 0)
+	h := func(c *Context) error {
+		sequenceNumbers = append(sequenceNumbers, c.EventRecord.Kinesis.SequenceNumber)
+		if string(c.EventRecord.Kinesis.Data) == "fail" {
+			return assert.AnError
+		}
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).ToLambdaPartialBatchHandler()
+	res, err := awsHandler(
+		context.Background(),
+		events.KinesisEvent{Records: []events.KinesisEventRecord{
+			record("1", "succeed"),
+			record("2", "fail"),
+			record("3", "succeed"),
+			record("4", "fail"),
+		}},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "2"}, sequenceNumbers)
+	assert.Equal(t, []events.KinesisBatchItemFailure{
+		{ItemIdentifier: "2"},
+	}, res.BatchItemFailures)
+}
+
+func TestKinesisConcurrentPartialBatchHandler_ReportsFirstFailure(t *testing.T) {
+	var callCount int32
+	h := func(c *Context) error {
+		atomic.AddInt32(&callCount, 1)
+		if string(c.EventRecord.Kinesis.Data) == "fail" {
+			return assert.AnError
+		}
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).Concurrent(2).ToLambdaPartialBatchHandler()
+	res, err := awsHandler(
+		context.Background(),
+		events.KinesisEvent{Records: []events.KinesisEventRecord{
+			record("1", "succeed"),
+			record("2", "fail"),
+			record("3", "succeed"),
+			record("4", "fail"),
+		}},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int32(4), callCount)
+	assert.Equal(t, []events.KinesisBatchItemFailure{
+		{ItemIdentifier: "2"},
+	}, res.BatchItemFailures)
+}
+
+func TestHandlerFunc_ToLambdaPartialBatchHandler_Timeout(t *testing.T) {
+	h := HandlerFunc(func(c *Context) error {
+		if string(c.EventRecord.Kinesis.Data) == "slow" {
+			<-c.Context.Done()
+		}
+		return nil
+	}).Middleware(TimeoutMiddleware(150 * time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	res, err := h.ToLambdaPartialBatchHandler()(ctx, events.KinesisEvent{
+		Records: []events.KinesisEventRecord{
+			record("1", "fast"),
+			record("2", "slow"),
+			record("3", "fast"),
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []events.KinesisBatchItemFailure{{ItemIdentifier: "2"}}, res.BatchItemFailures)
+}
+
+func TestKinesisContext_Bind_Success(t *testing.T) {
+	c := &Context{
+		EventRecord: record("1", `{"message": "hello"}`),
+	}
+
+	var data recordData
+	err := c.Bind(&data)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", data.Message)
+}
+
+func TestKinesisContext_Bind_InvalidJSON(t *testing.T) {
+	c := &Context{
+		EventRecord: record("1", `{"messag`),
+	}
+
+	var data recordData
+	err := c.Bind(&data)
+
+	assert.Error(t, err)
+}
+
+func TestKinesisContext_Bind_Invalid(t *testing.T) {
+	c := &Context{
+		EventRecord: record("1", `{"message": ""}`),
+	}
+
+	var data recordData
+	err := c.Bind(&data)
+
+	assert.EqualError(t, err, "invalid message")
+}
+
+func TestKinesisContext_ShardID(t *testing.T) {
+	c := &Context{
+		EventRecord: record("1", "test data"),
+	}
+
+	assert.Equal(t, "shardId-000000000000", c.ShardID())
+}
+
+func TestKinesisHandler_Middleware(t *testing.T) {
+	callOrder := make([]string, 0)
+
+	h := func(c *Context) error {
+		callOrder = append(callOrder, "handler")
+		return nil
+	}
+
+	mw1 := func(h HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			callOrder = append(callOrder, "mw1 in")
+			err := h(c)
+			callOrder = append(callOrder, "mw1 out")
+			return err
+		}
+	}
+
+	mw2 := func(h HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			callOrder = append(callOrder, "mw2 in")
+			err := h(c)
+			callOrder = append(callOrder, "mw2 out")
+			return err
+		}
+	}
+
+	awsHandler := HandlerFunc(h).Middleware(mw1, mw2).ToLambdaHandler()
+	err := awsHandler(
+		context.Background(),
+		events.KinesisEvent{Records: []events.KinesisEventRecord{record("1", "test data")}},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mw1 in", "mw2 in", "handler", "mw2 out", "mw1 out"}, callOrder)
+}
+
+type recordData struct {
+	Message string `json:"message"`
+}
+
+func (d *recordData) Validate() error {
+	if d.Message == "" {
+		return errors.New("invalid message")
+	}
+	return nil
+}
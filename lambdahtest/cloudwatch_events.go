@@ -0,0 +1,82 @@
+package lambdahtest
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// CloudWatchEventBuilder builds CloudWatch (EventBridge) events.
+type CloudWatchEventBuilder struct {
+	event events.CloudWatchEvent
+}
+
+// CloudWatchEvent returns a builder of a CloudWatch event with the source
+// `lambdah.test` and an empty detail.
+//
+//	lambdahtest.CloudWatchEvent().Source("com.example.orders").DetailType("Order Placed").Detail(order).Build()
+func CloudWatchEvent() *CloudWatchEventBuilder {
+	return &CloudWatchEventBuilder{
+		event: events.CloudWatchEvent{
+			Version:    "0",
+			ID:         newID(),
+			DetailType: "Test Event",
+			Source:     "lambdah.test",
+			AccountID:  AccountID,
+			Time:       time.Now().UTC().Truncate(time.Second),
+			Region:     Region,
+			Resources:  []string{},
+			Detail:     json.RawMessage("{}"),
+		},
+	}
+}
+
+// ScheduledEvent returns a builder of a scheduled event from the rule
+// `lambdah-test`, as sent by a CloudWatch Events schedule.
+func ScheduledEvent() *CloudWatchEventBuilder {
+	return CloudWatchEvent().
+		Source("aws.events").
+		DetailType("Scheduled Event").
+		Resources(arn("events", Region, "rule/lambdah-test"))
+}
+
+// Source sets the source of the event.
+func (b *CloudWatchEventBuilder) Source(source string) *CloudWatchEventBuilder {
+	b.event.Source = source
+	return b
+}
+
+// DetailType sets the detail type of the event.
+func (b *CloudWatchEventBuilder) DetailType(detailType string) *CloudWatchEventBuilder {
+	b.event.DetailType = detailType
+	return b
+}
+
+// Detail sets the detail of the event to v marshalled to JSON, or to v as is if it
+// is a json.RawMessage.
+func (b *CloudWatchEventBuilder) Detail(v interface{}) *CloudWatchEventBuilder {
+	if raw, ok := v.(json.RawMessage); ok {
+		b.event.Detail = raw
+		return b
+	}
+	b.event.Detail = json.RawMessage(mustMarshal(v))
+	return b
+}
+
+// Resources sets the ARNs of the resources of the event.
+func (b *CloudWatchEventBuilder) Resources(arns ...string) *CloudWatchEventBuilder {
+	b.event.Resources = arns
+	return b
+}
+
+// Region sets the AWS region of the event.
+func (b *CloudWatchEventBuilder) Region(region string) *CloudWatchEventBuilder {
+	b.event.Region = region
+	return b
+}
+
+// Build the event.
+func (b *CloudWatchEventBuilder) Build() events.CloudWatchEvent {
+	return b.event
+}
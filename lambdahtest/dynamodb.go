@@ -0,0 +1,196 @@
+package lambdahtest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// DynamoDB stream event names
+const (
+	DynamoDBInsert = "INSERT"
+	DynamoDBModify = "MODIFY"
+	DynamoDBRemove = "REMOVE"
+)
+
+// DynamoDBBuilder builds DynamoDB stream events. Images are given as structs or
+// maps, which are marshalled with dynamodbattribute, or as stream attribute maps.
+type DynamoDBBuilder struct {
+	table         string
+	region        string
+	streamLabel   string
+	keyAttributes []string
+	records       []events.DynamoDBEventRecord
+}
+
+// DynamoDB returns a builder of a DynamoDB stream event from the table
+// `lambdah-test`, which has the key attribute `id`.
+//
+//	lambdahtest.DynamoDB().Insert(item).Modify(oldItem, newItem).Build()
+func DynamoDB() *DynamoDBBuilder {
+	return &DynamoDBBuilder{
+		table:         "lambdah-test",
+		region:        Region,
+		streamLabel:   time.Now().UTC().Format("2006-01-02T15:04:05.000"),
+		keyAttributes: []string{"id"},
+	}
+}
+
+// Table sets the name of the table.
+func (b *DynamoDBBuilder) Table(name string) *DynamoDBBuilder {
+	b.table = name
+	return b
+}
+
+// Region sets the AWS region of the table.
+func (b *DynamoDBBuilder) Region(region string) *DynamoDBBuilder {
+	b.region = region
+	return b
+}
+
+// KeyAttributes sets the names of the table's key attributes, which are used for
+// the keys of records. The default is `id`.
+func (b *DynamoDBBuilder) KeyAttributes(names ...string) *DynamoDBBuilder {
+	b.keyAttributes = names
+	return b
+}
+
+// Insert adds an INSERT record of newImage.
+func (b *DynamoDBBuilder) Insert(newImage interface{}) *DynamoDBBuilder {
+	return b.Record(DynamoDBInsert, nil, newImage)
+}
+
+// Modify adds a MODIFY record from oldImage to newImage.
+func (b *DynamoDBBuilder) Modify(oldImage interface{}, newImage interface{}) *DynamoDBBuilder {
+	return b.Record(DynamoDBModify, oldImage, newImage)
+}
+
+// Remove adds a REMOVE record of oldImage.
+func (b *DynamoDBBuilder) Remove(oldImage interface{}) *DynamoDBBuilder {
+	return b.Record(DynamoDBRemove, oldImage, nil)
+}
+
+// Record adds a record with eventName, either of which image may be nil.
+func (b *DynamoDBBuilder) Record(eventName string, oldImage interface{}, newImage interface{}) *DynamoDBBuilder {
+	change := events.DynamoDBStreamRecord{
+		ApproximateCreationDateTime: events.SecondsEpochTime{Time: time.Now().UTC().Truncate(time.Second)},
+		OldImage:                    streamImage(oldImage),
+		NewImage:                    streamImage(newImage),
+		SequenceNumber:              nextSequenceNumber(21),
+		StreamViewType:              "NEW_AND_OLD_IMAGES",
+	}
+	for _, image := range []map[string]events.DynamoDBAttributeValue{change.OldImage, change.NewImage} {
+		if image != nil {
+			change.SizeBytes += int64(len(mustMarshal(image)))
+		}
+	}
+
+	b.records = append(b.records, events.DynamoDBEventRecord{
+		Change:       change,
+		EventID:      randomHex(16),
+		EventName:    eventName,
+		EventSource:  "aws:dynamodb",
+		EventVersion: "1.1",
+	})
+	return b
+}
+
+// Keys sets the keys of the most recently added record, instead of using the key
+// attributes of its image.
+func (b *DynamoDBBuilder) Keys(keys interface{}) *DynamoDBBuilder {
+	if len(b.records) == 0 {
+		panic("lambdahtest: DynamoDBBuilder.Keys called before adding a record")
+	}
+	b.records[len(b.records)-1].Change.Keys = streamImage(keys)
+	return b
+}
+
+// Build the event. If no records were added, the event has one INSERT record with
+// the image `{"id": "1"}`.
+func (b *DynamoDBBuilder) Build() events.DynamoDBEvent {
+	if len(b.records) == 0 {
+		b.Insert(map[string]string{"id": "1"})
+	}
+	sourceARN := arn("dynamodb", b.region, "table/"+b.table+"/stream/"+b.streamLabel)
+	records := make([]events.DynamoDBEventRecord, len(b.records))
+	for i, record := range b.records {
+		record.AWSRegion = b.region
+		record.EventSourceArn = sourceARN
+		if record.Change.Keys == nil {
+			record.Change.Keys = b.keys(record.Change)
+		}
+		records[i] = record
+	}
+	return events.DynamoDBEvent{Records: records}
+}
+
+func (b *DynamoDBBuilder) keys(change events.DynamoDBStreamRecord) map[string]events.DynamoDBAttributeValue {
+	image := change.NewImage
+	if image == nil {
+		image = change.OldImage
+	}
+	keys := make(map[string]events.DynamoDBAttributeValue)
+	for _, name := range b.keyAttributes {
+		if v, ok := image[name]; ok {
+			keys[name] = v
+		}
+	}
+	return keys
+}
+
+// streamImage converts v to a stream image, panicking if it cannot be marshalled.
+func streamImage(v interface{}) map[string]events.DynamoDBAttributeValue {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case map[string]events.DynamoDBAttributeValue:
+		return v
+	}
+
+	item, err := dynamodbattribute.MarshalMap(v)
+	if err != nil {
+		panic(fmt.Sprintf("lambdahtest: failed to marshal DynamoDB image: %v", err))
+	}
+	image := make(map[string]events.DynamoDBAttributeValue, len(item))
+	for k, av := range item {
+		image[k] = streamAttributeValue(av)
+	}
+	return image
+}
+
+func streamAttributeValue(av *dynamodb.AttributeValue) events.DynamoDBAttributeValue {
+	switch {
+	case av.S != nil:
+		return events.NewStringAttribute(*av.S)
+	case av.N != nil:
+		return events.NewNumberAttribute(*av.N)
+	case av.B != nil:
+		return events.NewBinaryAttribute(av.B)
+	case av.BOOL != nil:
+		return events.NewBooleanAttribute(*av.BOOL)
+	case av.L != nil:
+		list := make([]events.DynamoDBAttributeValue, len(av.L))
+		for i, v := range av.L {
+			list[i] = streamAttributeValue(v)
+		}
+		return events.NewListAttribute(list)
+	case av.M != nil:
+		m := make(map[string]events.DynamoDBAttributeValue, len(av.M))
+		for k, v := range av.M {
+			m[k] = streamAttributeValue(v)
+		}
+		return events.NewMapAttribute(m)
+	case av.SS != nil:
+		return events.NewStringSetAttribute(aws.StringValueSlice(av.SS))
+	case av.NS != nil:
+		return events.NewNumberSetAttribute(aws.StringValueSlice(av.NS))
+	case av.BS != nil:
+		return events.NewBinarySetAttribute(av.BS)
+	default:
+		return events.NewNullAttribute()
+	}
+}
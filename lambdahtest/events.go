@@ -0,0 +1,83 @@
+package lambdahtest
+
+import (
+	"crypto/md5" // #nosec G501 -- S3 ETags and SQS message digests are MD5
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Defaults used by the event builders, unless the builder is given other values
+const (
+	Region    = "us-east-1"
+	AccountID = "123456789012"
+)
+
+// sequence is used for the sequence numbers of FIFO messages and stream records,
+// which increase across all events built by the process
+var sequence int64 = 1884949646046769612
+
+func nextSequenceNumber(digits int) string {
+	n := atomic.AddInt64(&sequence, 1)
+	return fmt.Sprintf("%0*d", digits, n)
+}
+
+func newID() string {
+	return uuid.New().String()
+}
+
+// randomHex returns n random bytes as lower case hex.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// randomBase64 returns n random bytes as base64.
+func randomBase64(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// randomUpper returns n random upper case letters and digits, as used in AWS IDs.
+func randomUpper(n int) string {
+	const chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	b := make([]byte, n)
+	for i := range b {
+		j, _ := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+		b[i] = chars[j.Int64()]
+	}
+	return string(b)
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s)) // #nosec G401 -- not used for security, see the import
+	return hex.EncodeToString(sum[:])
+}
+
+// epochMillis returns t in epoch milliseconds, as a string.
+func epochMillis(t time.Time) string {
+	return fmt.Sprintf("%d", t.UnixNano()/int64(time.Millisecond))
+}
+
+// mustMarshal returns v as a JSON string, panicking if v cannot be marshalled, as
+// builders are used with values known by the test.
+func mustMarshal(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("lambdahtest: failed to marshal JSON: %v", err))
+	}
+	return string(b)
+}
+
+func arn(service string, region string, resource string) string {
+	return fmt.Sprintf("arn:aws:%s:%s:%s:%s", service, region, AccountID, resource)
+}
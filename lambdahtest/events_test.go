@@ -0,0 +1,350 @@
+package lambdahtest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQS(t *testing.T) {
+	event := SQS().
+		Queue("orders").
+		Message(`{"id":1}`).Attr("correlation_id", "abc-123").
+		JSONMessage(map[string]int{"id": 2}).SystemAttr("AWSTraceHeader", "Root=1-5759e988-bd862e3fe1be46a994272793").
+		Build()
+
+	assert.Len(t, event.Records, 2)
+	msg := event.Records[0]
+	assert.Len(t, msg.MessageId, 36)
+	assert.NotEmpty(t, msg.ReceiptHandle)
+	assert.Equal(t, `{"id":1}`, msg.Body)
+	assert.Equal(t, md5Hex(`{"id":1}`), msg.Md5OfBody)
+	assert.Equal(t, "abc-123", *msg.MessageAttributes["correlation_id"].StringValue)
+	assert.Equal(t, "String", msg.MessageAttributes["correlation_id"].DataType)
+	assert.Equal(t, "1", msg.Attributes["ApproximateReceiveCount"])
+	assert.NotEmpty(t, msg.Attributes["SentTimestamp"])
+	assert.Equal(t, "arn:aws:sqs:us-east-1:123456789012:orders", msg.EventSourceARN)
+	assert.Equal(t, "aws:sqs", msg.EventSource)
+	assert.Equal(t, "us-east-1", msg.AWSRegion)
+	assert.Equal(t, `{"id":2}`, event.Records[1].Body)
+	assert.Equal(t, "Root=1-5759e988-bd862e3fe1be46a994272793", event.Records[1].Attributes["AWSTraceHeader"])
+	assert.NotEqual(t, msg.MessageId, event.Records[1].MessageId)
+}
+
+func TestSQS_FIFO(t *testing.T) {
+	event := SQS().
+		Message("first").FIFO("group-1").
+		Message("second").FIFO("group-1").
+		Build()
+
+	first, second := event.Records[0], event.Records[1]
+	assert.Equal(t, "arn:aws:sqs:us-east-1:123456789012:lambdah-test.fifo", first.EventSourceARN)
+	assert.Equal(t, "group-1", first.Attributes["MessageGroupId"])
+	assert.Len(t, first.Attributes["MessageDeduplicationId"], 64)
+	assert.Len(t, first.Attributes["SequenceNumber"], 20)
+	assert.True(t, first.Attributes["SequenceNumber"] < second.Attributes["SequenceNumber"])
+}
+
+func TestSQS_Default(t *testing.T) {
+	event := SQS().Build()
+
+	assert.Len(t, event.Records, 1)
+	assert.Equal(t, "{}", event.Records[0].Body)
+	assert.Equal(t, "arn:aws:sqs:us-east-1:123456789012:lambdah-test", event.Records[0].EventSourceARN)
+}
+
+func TestSNS(t *testing.T) {
+	event := SNS().
+		Topic("orders").
+		Region("eu-west-1").
+		JSONMessage(map[string]int{"id": 1}).
+		Subject("Order placed").
+		Attr("correlation_id", "abc-123").
+		Build()
+
+	assert.Len(t, event.Records, 1)
+	record := event.Records[0]
+	assert.Equal(t, "aws:sns", record.EventSource)
+	assert.Contains(t, record.EventSubscriptionArn, "arn:aws:sns:eu-west-1:123456789012:orders:")
+	assert.Equal(t, "arn:aws:sns:eu-west-1:123456789012:orders", record.SNS.TopicArn)
+	assert.Equal(t, `{"id":1}`, record.SNS.Message)
+	assert.Equal(t, "Order placed", record.SNS.Subject)
+	assert.Equal(t, "Notification", record.SNS.Type)
+	assert.Equal(t, map[string]interface{}{"Type": "String", "Value": "abc-123"}, record.SNS.MessageAttributes["correlation_id"])
+	assert.WithinDuration(t, time.Now(), record.SNS.Timestamp, time.Minute)
+}
+
+func TestS3(t *testing.T) {
+	event := S3().
+		Bucket("uploads").
+		Object("images/my cat.png").Size(2048).
+		Object("images/old.png").EventName("s3:ObjectRemoved:Delete").
+		Build()
+
+	assert.Len(t, event.Records, 2)
+	created, removed := event.Records[0], event.Records[1]
+	assert.Equal(t, "ObjectCreated:Put", created.EventName)
+	assert.Equal(t, "aws:s3", created.EventSource)
+	assert.Equal(t, "us-east-1", created.AWSRegion)
+	assert.Equal(t, "uploads", created.S3.Bucket.Name)
+	assert.Equal(t, "arn:aws:s3:::uploads", created.S3.Bucket.Arn)
+	assert.Equal(t, "images/my+cat.png", created.S3.Object.Key)
+	assert.Equal(t, "images/my cat.png", created.S3.Object.URLDecodedKey)
+	assert.Equal(t, int64(2048), created.S3.Object.Size)
+	assert.NotEmpty(t, created.S3.Object.ETag)
+	assert.NotEmpty(t, created.S3.Object.Sequencer)
+	assert.Equal(t, "ObjectRemoved:Delete", removed.EventName)
+	assert.Equal(t, int64(0), removed.S3.Object.Size)
+	assert.Empty(t, removed.S3.Object.ETag)
+}
+
+func TestKinesis(t *testing.T) {
+	event := Kinesis().
+		Stream("orders").
+		Shard("shardId-000000000006").
+		Record([]byte("first")).PartitionKey("order-1").
+		JSONRecord(map[string]int{"id": 2}).
+		Build()
+
+	assert.Len(t, event.Records, 2)
+	first, second := event.Records[0], event.Records[1]
+	assert.Equal(t, "aws:kinesis", first.EventSource)
+	assert.Equal(t, "aws:kinesis:record", first.EventName)
+	assert.Equal(t, "us-east-1", first.AwsRegion)
+	assert.Equal(t, "arn:aws:kinesis:us-east-1:123456789012:stream/orders", first.EventSourceArn)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/lambdah-test", first.InvokeIdentityArn)
+	assert.Equal(t, "shardId-000000000006:"+first.Kinesis.SequenceNumber, first.EventID)
+	assert.Equal(t, []byte("first"), first.Kinesis.Data)
+	assert.Equal(t, "order-1", first.Kinesis.PartitionKey)
+	assert.Len(t, first.Kinesis.SequenceNumber, 56)
+	assert.True(t, first.Kinesis.SequenceNumber < second.Kinesis.SequenceNumber)
+	assert.Equal(t, []byte(`{"id":2}`), second.Kinesis.Data)
+	assert.Len(t, second.Kinesis.PartitionKey, 36)
+}
+
+func TestKinesis_JSONRoundTrip(t *testing.T) {
+	event := Kinesis().Record([]byte("data")).Build()
+
+	b, err := json.Marshal(event)
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `"data":"`+base64.StdEncoding.EncodeToString([]byte("data"))+`"`)
+
+	var decoded events.KinesisEvent
+	assert.Nil(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, []byte("data"), decoded.Records[0].Kinesis.Data)
+}
+
+func TestFirehose(t *testing.T) {
+	event := Firehose().
+		DeliveryStream("orders").
+		Region("eu-west-1").
+		Record([]byte("first")).
+		JSONRecord(map[string]int{"id": 2}).
+		Build()
+
+	assert.NotEmpty(t, event.InvocationID)
+	assert.Equal(t, "arn:aws:firehose:eu-west-1:123456789012:deliverystream/orders", event.DeliveryStreamArn)
+	assert.Equal(t, "eu-west-1", event.Region)
+	assert.Empty(t, event.SourceKinesisStreamArn)
+	assert.Len(t, event.Records, 2)
+	first, second := event.Records[0], event.Records[1]
+	assert.Equal(t, []byte("first"), first.Data)
+	assert.Equal(t, []byte(`{"id":2}`), second.Data)
+	assert.True(t, first.RecordID < second.RecordID)
+	assert.Empty(t, first.KinesisFirehoseRecordMetadata.SequenceNumber)
+}
+
+func TestFirehose_SourceStream(t *testing.T) {
+	event := Firehose().SourceStream("orders").Build()
+
+	assert.Equal(t, "arn:aws:kinesis:us-east-1:123456789012:stream/orders", event.SourceKinesisStreamArn)
+	assert.Len(t, event.Records, 1)
+	record := event.Records[0]
+	assert.Equal(t, []byte("{}"), record.Data)
+	assert.Equal(t, "shardId-000000000000", record.KinesisFirehoseRecordMetadata.ShardID)
+	assert.Len(t, record.KinesisFirehoseRecordMetadata.SequenceNumber, 56)
+}
+
+type dynamoDBItem struct {
+	ID   string   `dynamodbav:"id"`
+	Name string   `dynamodbav:"name"`
+	Age  int      `dynamodbav:"age"`
+	Tags []string `dynamodbav:"tags,stringset"`
+}
+
+func TestDynamoDB(t *testing.T) {
+	event := DynamoDB().
+		Table("users").
+		Insert(dynamoDBItem{ID: "1", Name: "Dave", Age: 42, Tags: []string{"admin"}}).
+		Modify(map[string]interface{}{"id": "1", "name": "Dave"}, map[string]interface{}{"id": "1", "name": "David"}).
+		Remove(map[string]interface{}{"id": "1"}).
+		Build()
+
+	assert.Len(t, event.Records, 3)
+	insert := event.Records[0]
+	assert.Equal(t, DynamoDBInsert, insert.EventName)
+	assert.Equal(t, "aws:dynamodb", insert.EventSource)
+	assert.Contains(t, insert.EventSourceArn, "arn:aws:dynamodb:us-east-1:123456789012:table/users/stream/")
+	assert.Len(t, insert.EventID, 32)
+	assert.Equal(t, "NEW_AND_OLD_IMAGES", insert.Change.StreamViewType)
+	assert.Nil(t, insert.Change.OldImage)
+	assert.Equal(t, map[string]events.DynamoDBAttributeValue{
+		"id":   events.NewStringAttribute("1"),
+		"name": events.NewStringAttribute("Dave"),
+		"age":  events.NewNumberAttribute("42"),
+		"tags": events.NewStringSetAttribute([]string{"admin"}),
+	}, insert.Change.NewImage)
+	assert.Equal(t, map[string]events.DynamoDBAttributeValue{"id": events.NewStringAttribute("1")}, insert.Change.Keys)
+	assert.True(t, insert.Change.SizeBytes > 0)
+
+	modify := event.Records[1]
+	assert.Equal(t, DynamoDBModify, modify.EventName)
+	assert.Equal(t, "Dave", modify.Change.OldImage["name"].String())
+	assert.Equal(t, "David", modify.Change.NewImage["name"].String())
+	assert.True(t, insert.Change.SequenceNumber < modify.Change.SequenceNumber)
+
+	remove := event.Records[2]
+	assert.Equal(t, DynamoDBRemove, remove.EventName)
+	assert.Nil(t, remove.Change.NewImage)
+	assert.Equal(t, map[string]events.DynamoDBAttributeValue{"id": events.NewStringAttribute("1")}, remove.Change.Keys)
+}
+
+func TestDynamoDB_Keys(t *testing.T) {
+	event := DynamoDB().
+		KeyAttributes("pk", "sk").
+		Insert(map[string]string{"pk": "user#1", "sk": "profile", "name": "Dave"}).
+		Insert(map[string]string{"name": "Dave"}).Keys(map[string]string{"pk": "user#2", "sk": "profile"}).
+		Build()
+
+	assert.Equal(t, map[string]events.DynamoDBAttributeValue{
+		"pk": events.NewStringAttribute("user#1"),
+		"sk": events.NewStringAttribute("profile"),
+	}, event.Records[0].Change.Keys)
+	assert.Equal(t, "user#2", event.Records[1].Change.Keys["pk"].String())
+}
+
+func TestDynamoDB_JSONRoundTrip(t *testing.T) {
+	event := DynamoDB().Insert(map[string]interface{}{
+		"id":     "1",
+		"nested": map[string]interface{}{"list": []interface{}{1, "two", true, nil}},
+	}).Build()
+
+	b, err := json.Marshal(event)
+	assert.Nil(t, err)
+	var decoded events.DynamoDBEvent
+	assert.Nil(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, event.Records[0].Change.NewImage, decoded.Records[0].Change.NewImage)
+}
+
+func TestCloudWatchEvent(t *testing.T) {
+	event := CloudWatchEvent().
+		Source("com.example.orders").
+		DetailType("Order Placed").
+		Detail(map[string]int{"id": 1}).
+		Build()
+
+	assert.Equal(t, "0", event.Version)
+	assert.Len(t, event.ID, 36)
+	assert.Equal(t, "com.example.orders", event.Source)
+	assert.Equal(t, "Order Placed", event.DetailType)
+	assert.Equal(t, AccountID, event.AccountID)
+	assert.Equal(t, Region, event.Region)
+	assert.JSONEq(t, `{"id":1}`, string(event.Detail))
+	assert.Equal(t, []string{}, event.Resources)
+}
+
+func TestScheduledEvent(t *testing.T) {
+	event := ScheduledEvent().Build()
+
+	assert.Equal(t, "aws.events", event.Source)
+	assert.Equal(t, "Scheduled Event", event.DetailType)
+	assert.Equal(t, []string{"arn:aws:events:us-east-1:123456789012:rule/lambdah-test"}, event.Resources)
+	assert.JSONEq(t, `{}`, string(event.Detail))
+}
+
+func TestHTTPRequest_APIGatewayProxy(t *testing.T) {
+	req := HTTPRequest("post", "/books/123?draft=true").
+		Route("/books/{id}").
+		PathParam("id", "123").
+		Query("tag", "a").
+		Query("tag", "b").
+		Header("Authorization", "Bearer token").
+		StageVariable("env", "dev").
+		Claim("sub", "user-1").
+		JSONBody(map[string]string{"title": "Go"}).
+		APIGatewayProxy()
+
+	assert.Equal(t, "POST", req.HTTPMethod)
+	assert.Equal(t, "/books/123", req.Path)
+	assert.Equal(t, "/books/{id}", req.Resource)
+	assert.Equal(t, map[string]string{"id": "123"}, req.PathParameters)
+	assert.Equal(t, map[string]string{"draft": "true", "tag": "b"}, req.QueryStringParameters)
+	assert.Equal(t, []string{"a", "b"}, req.MultiValueQueryStringParameters["tag"])
+	assert.Equal(t, "Bearer token", req.Headers["Authorization"])
+	assert.Equal(t, "application/json", req.Headers["Content-Type"])
+	assert.Equal(t, "lambdahtest", req.Headers["User-Agent"])
+	assert.Equal(t, []string{"Bearer token"}, req.MultiValueHeaders["Authorization"])
+	assert.Equal(t, map[string]string{"env": "dev"}, req.StageVariables)
+	assert.Equal(t, `{"title":"Go"}`, req.Body)
+	assert.False(t, req.IsBase64Encoded)
+
+	rc := req.RequestContext
+	assert.Equal(t, AccountID, rc.AccountID)
+	assert.Equal(t, "test", rc.Stage)
+	assert.Equal(t, "/test/books/123", rc.Path)
+	assert.Equal(t, "/books/{id}", rc.ResourcePath)
+	assert.Equal(t, "POST", rc.HTTPMethod)
+	assert.Len(t, rc.RequestID, 36)
+	assert.Equal(t, "127.0.0.1", rc.Identity.SourceIP)
+	assert.Equal(t, map[string]interface{}{"claims": map[string]interface{}{"sub": "user-1"}}, rc.Authorizer)
+	assert.Equal(t, rc.DomainName, req.Headers["Host"])
+	assert.WithinDuration(t, time.Now(), time.Unix(0, rc.RequestTimeEpoch*int64(time.Millisecond)), time.Minute)
+}
+
+func TestHTTPRequest_APIGatewayV2(t *testing.T) {
+	req := HTTPRequest("GET", "/books/123").
+		Route("/books/{id}").
+		PathParam("id", "123").
+		Query("tag", "a").
+		Query("tag", "b").
+		Header("Cookie", "a=1; b=2").
+		Claim("sub", "user-1").
+		BinaryBody([]byte{0xff, 0x00}).
+		APIGatewayV2()
+
+	assert.Equal(t, "2.0", req.Version)
+	assert.Equal(t, "GET /books/{id}", req.RouteKey)
+	assert.Equal(t, "/books/123", req.RawPath)
+	assert.Equal(t, "tag=a&tag=b", req.RawQueryString)
+	assert.Equal(t, map[string]string{"tag": "a,b"}, req.QueryStringParameters)
+	assert.Equal(t, []string{"a=1", "b=2"}, req.Cookies)
+	assert.NotContains(t, req.Headers, "cookie")
+	assert.Equal(t, "lambdahtest", req.Headers["user-agent"])
+	assert.Equal(t, map[string]string{"id": "123"}, req.PathParameters)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte{0xff, 0x00}), req.Body)
+	assert.True(t, req.IsBase64Encoded)
+
+	rc := req.RequestContext
+	assert.Equal(t, "$default", rc.Stage)
+	assert.Equal(t, "GET", rc.HTTP.Method)
+	assert.Equal(t, "/books/123", rc.HTTP.Path)
+	assert.Equal(t, "127.0.0.1", rc.HTTP.SourceIP)
+	assert.Equal(t, map[string]string{"sub": "user-1"}, rc.Authorizer.JWT.Claims)
+}
+
+func TestHTTPRequest_ALB(t *testing.T) {
+	req := HTTPRequest("GET", "/health?verbose=1").
+		Header("X-Custom", "value").
+		ALB()
+
+	assert.Equal(t, "GET", req.HTTPMethod)
+	assert.Equal(t, "/health", req.Path)
+	assert.Equal(t, map[string]string{"verbose": "1"}, req.QueryStringParameters)
+	assert.Equal(t, "value", req.Headers["x-custom"])
+	assert.Equal(t, "http", req.Headers["x-forwarded-proto"])
+	assert.Contains(t, req.RequestContext.ELB.TargetGroupArn, "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/lambdah-test/")
+}
@@ -0,0 +1,92 @@
+package lambdahtest
+
+import (
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// FirehoseBuilder builds the events of Kinesis Data Firehose transformations.
+type FirehoseBuilder struct {
+	deliveryStream string
+	region         string
+	sourceStream   string
+	records        []events.KinesisFirehoseEventRecord
+}
+
+// Firehose returns a builder of a Firehose transformation event from the delivery
+// stream `lambdah-test`.
+//
+//	lambdahtest.Firehose().Record(data).JSONRecord(order).Build()
+func Firehose() *FirehoseBuilder {
+	return &FirehoseBuilder{
+		deliveryStream: "lambdah-test",
+		region:         Region,
+	}
+}
+
+// DeliveryStream sets the name of the delivery stream the records are from.
+func (b *FirehoseBuilder) DeliveryStream(name string) *FirehoseBuilder {
+	b.deliveryStream = name
+	return b
+}
+
+// Region sets the AWS region of the delivery stream.
+func (b *FirehoseBuilder) Region(region string) *FirehoseBuilder {
+	b.region = region
+	return b
+}
+
+// SourceStream sets the name of the Kinesis stream which is the source of the
+// delivery stream, which adds the Kinesis metadata of each record.
+func (b *FirehoseBuilder) SourceStream(name string) *FirehoseBuilder {
+	b.sourceStream = name
+	return b
+}
+
+// Record adds a record with data.
+func (b *FirehoseBuilder) Record(data []byte) *FirehoseBuilder {
+	// record IDs are the sequence number of the record in the delivery stream,
+	// followed by a subsequence number
+	sequenceNumber := "49" + nextSequenceNumber(54)
+	b.records = append(b.records, events.KinesisFirehoseEventRecord{
+		RecordID:                    sequenceNumber + "000000",
+		ApproximateArrivalTimestamp: events.MilliSecondsEpochTime{Time: time.Now().UTC().Truncate(time.Millisecond)},
+		Data:                        data,
+	})
+	return b
+}
+
+// JSONRecord adds a record with v marshalled to JSON as its data.
+func (b *FirehoseBuilder) JSONRecord(v interface{}) *FirehoseBuilder {
+	return b.Record([]byte(mustMarshal(v)))
+}
+
+// Build the event. If no records were added, the event has one record with empty
+// JSON data.
+func (b *FirehoseBuilder) Build() events.KinesisFirehoseEvent {
+	if len(b.records) == 0 {
+		b.Record([]byte("{}"))
+	}
+	event := events.KinesisFirehoseEvent{
+		InvocationID:      newID(),
+		DeliveryStreamArn: arn("firehose", b.region, "deliverystream/"+b.deliveryStream),
+		Region:            b.region,
+		Records:           make([]events.KinesisFirehoseEventRecord, len(b.records)),
+	}
+	if b.sourceStream != "" {
+		event.SourceKinesisStreamArn = arn("kinesis", b.region, "stream/"+b.sourceStream)
+	}
+	for i, record := range b.records {
+		if b.sourceStream != "" {
+			record.KinesisFirehoseRecordMetadata = events.KinesisFirehoseRecordMetadata{
+				ShardID:                     "shardId-000000000000",
+				PartitionKey:                newID(),
+				SequenceNumber:              record.RecordID[:56],
+				ApproximateArrivalTimestamp: record.ApproximateArrivalTimestamp,
+			}
+		}
+		event.Records[i] = record
+	}
+	return event
+}
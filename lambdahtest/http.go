@@ -0,0 +1,336 @@
+package lambdahtest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	requestTimeFormat = "02/Jan/2006:15:04:05 -0700"
+	defaultSourceIP   = "127.0.0.1"
+	defaultUserAgent  = "lambdahtest"
+)
+
+// HTTPRequestBuilder builds the events of HTTP requests, from API Gateway REST APIs
+// with APIGatewayProxy, API Gateway HTTP APIs with APIGatewayV2, and Application
+// Load Balancers with ALB.
+type HTTPRequestBuilder struct {
+	method         string
+	path           string
+	route          string
+	header         http.Header
+	query          url.Values
+	pathParameters map[string]string
+	stageVariables map[string]string
+	stage          string
+	claims         map[string]string
+	sourceIP       string
+	body           string
+	base64         bool
+	time           time.Time
+}
+
+// HTTPRequest returns a builder of an HTTP request event. The target is a path,
+// and may have a query string.
+//
+//	lambdahtest.HTTPRequest("POST", "/books?draft=true").JSONBody(book).APIGatewayProxy()
+func HTTPRequest(method string, target string) *HTTPRequestBuilder {
+	b := &HTTPRequestBuilder{
+		method:         strings.ToUpper(method),
+		path:           target,
+		header:         http.Header{},
+		query:          url.Values{},
+		pathParameters: map[string]string{},
+		stageVariables: map[string]string{},
+		sourceIP:       defaultSourceIP,
+		time:           time.Now().UTC(),
+	}
+	if u, err := url.Parse(target); err == nil {
+		b.path = u.Path
+		b.query = u.Query()
+	}
+	if b.path == "" {
+		b.path = "/"
+	}
+	return b
+}
+
+// Header adds a request header.
+func (b *HTTPRequestBuilder) Header(name string, value string) *HTTPRequestBuilder {
+	b.header.Add(name, value)
+	return b
+}
+
+// Query adds a query string parameter.
+func (b *HTTPRequestBuilder) Query(name string, value string) *HTTPRequestBuilder {
+	b.query.Add(name, value)
+	return b
+}
+
+// Route sets the route of the request, such as `/books/{id}`, which is the API
+// Gateway resource or route key.
+func (b *HTTPRequestBuilder) Route(route string) *HTTPRequestBuilder {
+	b.route = route
+	return b
+}
+
+// PathParam sets a path parameter of the route.
+func (b *HTTPRequestBuilder) PathParam(name string, value string) *HTTPRequestBuilder {
+	b.pathParameters[name] = value
+	return b
+}
+
+// Stage sets the API Gateway stage. The default is `test` for REST APIs, and
+// `$default` for HTTP APIs.
+func (b *HTTPRequestBuilder) Stage(stage string) *HTTPRequestBuilder {
+	b.stage = stage
+	return b
+}
+
+// StageVariable sets an API Gateway stage variable.
+func (b *HTTPRequestBuilder) StageVariable(name string, value string) *HTTPRequestBuilder {
+	b.stageVariables[name] = value
+	return b
+}
+
+// Claim sets a claim of the authorizer, as set by Cognito user pool or JWT authorizers.
+func (b *HTTPRequestBuilder) Claim(name string, value string) *HTTPRequestBuilder {
+	if b.claims == nil {
+		b.claims = map[string]string{}
+	}
+	b.claims[name] = value
+	return b
+}
+
+// SourceIP sets the IP address of the client.
+func (b *HTTPRequestBuilder) SourceIP(ip string) *HTTPRequestBuilder {
+	b.sourceIP = ip
+	return b
+}
+
+// Body sets the request body.
+func (b *HTTPRequestBuilder) Body(body string) *HTTPRequestBuilder {
+	b.body = body
+	b.base64 = false
+	return b
+}
+
+// JSONBody sets the request body to v marshalled to JSON, and sets the
+// Content-Type header.
+func (b *HTTPRequestBuilder) JSONBody(v interface{}) *HTTPRequestBuilder {
+	b.header.Set("Content-Type", "application/json")
+	return b.Body(mustMarshal(v))
+}
+
+// BinaryBody sets the request body, base64 encoded as it is for binary media types.
+func (b *HTTPRequestBuilder) BinaryBody(body []byte) *HTTPRequestBuilder {
+	b.body = base64.StdEncoding.EncodeToString(body)
+	b.base64 = true
+	return b
+}
+
+// APIGatewayProxy builds an API Gateway REST API proxy request.
+func (b *HTTPRequestBuilder) APIGatewayProxy() events.APIGatewayProxyRequest {
+	apiID := strings.ToLower(randomUpper(10))
+	domain := apiID + ".execute-api." + Region + ".amazonaws.com"
+	header := b.headers(domain, "https", "443")
+	route := b.route
+	if route == "" {
+		route = b.path
+	}
+	stage := b.stage
+	if stage == "" {
+		stage = "test"
+	}
+
+	var authorizer map[string]interface{}
+	if b.claims != nil {
+		claims := make(map[string]interface{}, len(b.claims))
+		for k, v := range b.claims {
+			claims[k] = v
+		}
+		authorizer = map[string]interface{}{"claims": claims}
+	}
+
+	return events.APIGatewayProxyRequest{
+		Resource:                        route,
+		Path:                            b.path,
+		HTTPMethod:                      b.method,
+		Headers:                         lastValues(header),
+		MultiValueHeaders:               header,
+		QueryStringParameters:           nilIfEmpty(lastValues(b.query)),
+		MultiValueQueryStringParameters: b.multiValueQuery(),
+		PathParameters:                  nilIfEmpty(b.pathParameters),
+		StageVariables:                  nilIfEmpty(b.stageVariables),
+		RequestContext: events.APIGatewayProxyRequestContext{
+			AccountID:         AccountID,
+			ResourceID:        strings.ToLower(randomUpper(6)),
+			Stage:             stage,
+			DomainName:        domain,
+			DomainPrefix:      apiID,
+			RequestID:         newID(),
+			ExtendedRequestID: randomBase64(12),
+			Protocol:          "HTTP/1.1",
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  b.sourceIP,
+				UserAgent: header.Get("User-Agent"),
+			},
+			ResourcePath:     route,
+			Path:             "/" + stage + b.path,
+			Authorizer:       authorizer,
+			HTTPMethod:       b.method,
+			RequestTime:      b.time.Format(requestTimeFormat),
+			RequestTimeEpoch: b.time.UnixNano() / int64(time.Millisecond),
+			APIID:            apiID,
+		},
+		Body:            b.body,
+		IsBase64Encoded: b.base64,
+	}
+}
+
+// APIGatewayV2 builds an API Gateway HTTP API request, with payload format 2.0.
+func (b *HTTPRequestBuilder) APIGatewayV2() events.APIGatewayV2HTTPRequest {
+	apiID := strings.ToLower(randomUpper(10))
+	domain := apiID + ".execute-api." + Region + ".amazonaws.com"
+	header := b.headers(domain, "https", "443")
+	routeKey := "$default"
+	if b.route != "" {
+		routeKey = b.method + " " + b.route
+	}
+
+	var cookies []string
+	for _, v := range header.Values("Cookie") {
+		cookies = append(cookies, strings.Split(v, "; ")...)
+	}
+	header.Del("Cookie")
+	headers := make(map[string]string, len(header))
+	for k, v := range header {
+		headers[strings.ToLower(k)] = strings.Join(v, ",")
+	}
+	query := make(map[string]string, len(b.query))
+	for k, v := range b.query {
+		query[k] = strings.Join(v, ",")
+	}
+
+	var authorizer *events.APIGatewayV2HTTPRequestContextAuthorizerDescription
+	if b.claims != nil {
+		authorizer = &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+			JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{Claims: b.claims},
+		}
+	}
+
+	stage := b.stage
+	if stage == "" {
+		stage = "$default"
+	}
+
+	return events.APIGatewayV2HTTPRequest{
+		Version:               "2.0",
+		RouteKey:              routeKey,
+		RawPath:               b.path,
+		RawQueryString:        b.query.Encode(),
+		Cookies:               cookies,
+		Headers:               headers,
+		QueryStringParameters: nilIfEmpty(query),
+		PathParameters:        nilIfEmpty(b.pathParameters),
+		StageVariables:        nilIfEmpty(b.stageVariables),
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			RouteKey:     routeKey,
+			AccountID:    AccountID,
+			Stage:        stage,
+			RequestID:    randomBase64(12),
+			Authorizer:   authorizer,
+			APIID:        apiID,
+			DomainName:   domain,
+			DomainPrefix: apiID,
+			Time:         b.time.Format(requestTimeFormat),
+			TimeEpoch:    b.time.UnixNano() / int64(time.Millisecond),
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+				Method:    b.method,
+				Path:      b.path,
+				Protocol:  "HTTP/1.1",
+				SourceIP:  b.sourceIP,
+				UserAgent: header.Get("User-Agent"),
+			},
+		},
+		Body:            b.body,
+		IsBase64Encoded: b.base64,
+	}
+}
+
+// ALB builds an Application Load Balancer request, for a target group without
+// multi value headers enabled.
+func (b *HTTPRequestBuilder) ALB() events.ALBTargetGroupRequest {
+	header := b.headers("lambdah-test-"+strings.ToLower(randomUpper(9))+"."+Region+".elb.amazonaws.com", "http", "80")
+	headers := make(map[string]string, len(header))
+	for k, v := range header {
+		headers[strings.ToLower(k)] = v[len(v)-1]
+	}
+
+	return events.ALBTargetGroupRequest{
+		HTTPMethod:            b.method,
+		Path:                  b.path,
+		QueryStringParameters: lastValues(b.query),
+		Headers:               headers,
+		RequestContext: events.ALBTargetGroupRequestContext{
+			ELB: events.ELBContext{
+				TargetGroupArn: arn("elasticloadbalancing", Region, "targetgroup/lambdah-test/"+randomHex(8)),
+			},
+		},
+		IsBase64Encoded: b.base64,
+		Body:            b.body,
+	}
+}
+
+// headers returns the request headers, with the defaults added by the client and
+// the load balancer or API Gateway.
+func (b *HTTPRequestBuilder) headers(host string, proto string, port string) http.Header {
+	header := b.header.Clone()
+	defaults := map[string]string{
+		"Accept":            "*/*",
+		"Host":              host,
+		"User-Agent":        defaultUserAgent,
+		"X-Amzn-Trace-Id":   fmt.Sprintf("Root=1-%08x-%s", b.time.Unix(), randomHex(12)),
+		"X-Forwarded-For":   b.sourceIP,
+		"X-Forwarded-Port":  port,
+		"X-Forwarded-Proto": proto,
+	}
+	for k, v := range defaults {
+		if header.Get(k) == "" {
+			header.Set(k, v)
+		}
+	}
+	return header
+}
+
+func (b *HTTPRequestBuilder) multiValueQuery() map[string][]string {
+	if len(b.query) == 0 {
+		return nil
+	}
+	return b.query
+}
+
+// lastValues returns the last value of each key, as API Gateway and ALB do.
+func lastValues(values map[string][]string) map[string]string {
+	m := make(map[string]string, len(values))
+	for k, v := range values {
+		if len(v) > 0 {
+			m[k] = v[len(v)-1]
+		}
+	}
+	return m
+}
+
+// nilIfEmpty returns nil for empty maps, which API Gateway sends as null.
+func nilIfEmpty(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
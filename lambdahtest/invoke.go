@@ -0,0 +1,117 @@
+package lambdahtest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambda/messages"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/google/uuid"
+	"github.com/webbgeorge/lambdah"
+	"github.com/webbgeorge/lambdah/log"
+)
+
+// Invoke calls a handler in process with event, which is marshalled to JSON and
+// unmarshalled to the handler's event type, as it is by the Lambda runtime. The
+// handler may be a Lambdah HandlerFunc, or any handler accepted by lambda.Start,
+// such as the result of ToLambdaHandler.
+//
+//	result, err := lambdahtest.Invoke(sqs.HandlerFunc(handler), lambdahtest.SQS().Message(body).Build())
+//
+// The handler is given a context with a deadline and Lambda context, set with
+// invoke options as for Runtime.Invoke, where WithTraceID sets the log package's
+// trace context. Errors of the handler are set on the Result, and panics are
+// recovered as a lambdah.PanicError. An error is only returned if event cannot be
+// marshalled.
+func Invoke(handler interface{}, event interface{}, opts ...InvokeOption) (*Result, error) {
+	payload, err := marshalEvent(event)
+	if err != nil {
+		return nil, err
+	}
+	inv := &invocation{
+		requestID:   uuid.New().String(),
+		timeout:     DefaultTimeout,
+		functionARN: DefaultFunctionARN,
+	}
+	for _, opt := range opts {
+		opt(inv)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), inv.timeout)
+	defer cancel()
+	inv.deadline, _ = ctx.Deadline()
+
+	lc := &lambdacontext.LambdaContext{
+		AwsRequestID:       inv.requestID,
+		InvokedFunctionArn: inv.functionARN,
+	}
+	if inv.clientContext != nil {
+		lc.ClientContext = *inv.clientContext
+	}
+	if inv.cognitoIdentity != nil {
+		lc.Identity = *inv.cognitoIdentity
+	}
+	ctx = lambdacontext.NewContext(ctx, lc)
+	if inv.traceID != "" {
+		if tc, ok := log.ParseXRayTraceHeader(inv.traceID); ok {
+			ctx = log.WithTraceContext(ctx, tc)
+		}
+	}
+
+	start := time.Now()
+	response, invokeErr := call(ctx, lambdaHandler(handler), payload)
+	result := &Result{
+		RequestID: inv.requestID,
+		Deadline:  inv.deadline,
+		Duration:  time.Since(start),
+		Err:       invokeErr,
+	}
+	if invokeErr != nil {
+		result.Error = functionError(invokeErr)
+		return result, nil
+	}
+	result.Payload = response
+	return result, nil
+}
+
+// lambdaHandler returns the Lambda handler of a Lambdah HandlerFunc, which has a
+// ToLambdaHandler method, or handler as is.
+func lambdaHandler(handler interface{}) interface{} {
+	method := reflect.ValueOf(handler).MethodByName("ToLambdaHandler")
+	if method.IsValid() && method.Type().NumIn() == 0 && method.Type().NumOut() == 1 {
+		return method.Call(nil)[0].Interface()
+	}
+	return handler
+}
+
+func call(ctx context.Context, handler interface{}, payload []byte) (response []byte, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = lambdah.PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return lambda.NewHandler(handler).Invoke(ctx, payload)
+}
+
+// functionError returns the error as reported to the Runtime API by the Lambda runtime.
+func functionError(err error) *FunctionError {
+	if ive, ok := err.(messages.InvokeResponse_Error); ok {
+		return &FunctionError{Message: ive.Message, Type: ive.Type}
+	}
+	if panicErr, ok := err.(lambdah.PanicError); ok {
+		return &FunctionError{Message: fmt.Sprintf("%v", panicErr.Value), Type: errorType(panicErr.Value)}
+	}
+	return &FunctionError{Message: err.Error(), Type: errorType(err)}
+}
+
+func errorType(v interface{}) string {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		return t.Elem().Name()
+	}
+	return t.Name()
+}
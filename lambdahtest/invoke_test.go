@@ -0,0 +1,113 @@
+package lambdahtest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/webbgeorge/lambdah"
+	"github.com/webbgeorge/lambdah/api_gateway_proxy"
+	"github.com/webbgeorge/lambdah/log"
+	"github.com/webbgeorge/lambdah/sqs"
+)
+
+func TestInvoke_HandlerFunc(t *testing.T) {
+	var bodies []string
+	h := sqs.HandlerFunc(func(c *sqs.Context) error {
+		bodies = append(bodies, c.Message.Body)
+		return nil
+	})
+
+	result, err := Invoke(h, SQS().Message("one").Message("two").Build())
+
+	assert.Nil(t, err)
+	assert.Nil(t, result.Error)
+	assert.Nil(t, result.Err)
+	assert.Equal(t, []string{"one", "two"}, bodies)
+}
+
+func TestInvoke_HTTPHandler(t *testing.T) {
+	h := api_gateway_proxy.HandlerFunc(func(c *api_gateway_proxy.Context) error {
+		return c.JSON(http.StatusCreated, map[string]string{"id": c.Request.PathParameters["id"]})
+	})
+
+	result, err := Invoke(h, HTTPRequest("PUT", "/books/123").PathParam("id", "123").APIGatewayProxy())
+
+	assert.Nil(t, err)
+	var res events.APIGatewayProxyResponse
+	assert.Nil(t, result.Unmarshal(&res))
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.JSONEq(t, `{"id":"123"}`, res.Body)
+}
+
+func TestInvoke_LambdaHandler(t *testing.T) {
+	h := func(ctx context.Context, event events.CloudWatchEvent) (string, error) {
+		lc, _ := lambdacontext.FromContext(ctx)
+		tc, _ := log.TraceContextFromContext(ctx)
+		deadline, _ := ctx.Deadline()
+		assert.Equal(t, "request-1", lc.AwsRequestID)
+		assert.Equal(t, DefaultFunctionARN, lc.InvokedFunctionArn)
+		assert.Equal(t, "5759e988bd862e3fe1be46a994272793", tc.TraceID)
+		assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+		return event.DetailType, nil
+	}
+
+	result, err := Invoke(
+		h,
+		ScheduledEvent().Build(),
+		WithRequestID("request-1"),
+		WithTimeout(time.Second),
+		WithTraceID("Root=1-5759e988-bd862e3fe1be46a994272793;Sampled=1"),
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "request-1", result.RequestID)
+	assert.Equal(t, `"Scheduled Event"`, string(result.Payload))
+}
+
+func TestInvoke_Error(t *testing.T) {
+	h := sqs.HandlerFunc(func(c *sqs.Context) error {
+		return assert.AnError
+	})
+
+	result, err := Invoke(h, SQS().Build())
+
+	assert.Nil(t, err)
+	assert.Equal(t, assert.AnError, result.Err)
+	assert.Equal(t, &FunctionError{Message: assert.AnError.Error(), Type: "errorString"}, result.Error)
+}
+
+func TestInvoke_Panic(t *testing.T) {
+	h := sqs.HandlerFunc(func(c *sqs.Context) error {
+		panic("something went wrong")
+	})
+
+	result, err := Invoke(h, SQS().Build())
+
+	assert.Nil(t, err)
+	var panicErr lambdah.PanicError
+	assert.True(t, errors.As(result.Err, &panicErr))
+	assert.Equal(t, "something went wrong", panicErr.Value)
+	assert.Equal(t, &FunctionError{Message: "something went wrong", Type: "string"}, result.Error)
+}
+
+func TestInvoke_BatchItemFailures(t *testing.T) {
+	event := SQS().Message("ok").Message("fail").Build()
+	h := sqs.HandlerFunc(func(c *sqs.Context) error {
+		if c.Message.Body == "fail" {
+			return assert.AnError
+		}
+		return nil
+	})
+
+	result, err := Invoke(h.ToLambdaPartialBatchHandler(), event)
+
+	assert.Nil(t, err)
+	assert.Nil(t, result.Error)
+	assert.Equal(t, []string{event.Records[1].MessageId}, result.BatchItemFailures())
+}
@@ -0,0 +1,99 @@
+package lambdahtest
+
+import (
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// KinesisBuilder builds Kinesis Data Streams events. Methods which modify a record
+// apply to the most recently added record, adding a record with empty JSON data if
+// there is none.
+type KinesisBuilder struct {
+	stream  string
+	region  string
+	shardID string
+	records []events.KinesisEventRecord
+}
+
+// Kinesis returns a builder of a Kinesis event from the shard `shardId-000000000000`
+// of the stream `lambdah-test`.
+//
+//	lambdahtest.Kinesis().Record(data).PartitionKey("order-1").JSONRecord(order).Build()
+func Kinesis() *KinesisBuilder {
+	return &KinesisBuilder{
+		stream:  "lambdah-test",
+		region:  Region,
+		shardID: "shardId-000000000000",
+	}
+}
+
+// Stream sets the name of the stream the records are from.
+func (b *KinesisBuilder) Stream(name string) *KinesisBuilder {
+	b.stream = name
+	return b
+}
+
+// Region sets the AWS region of the stream.
+func (b *KinesisBuilder) Region(region string) *KinesisBuilder {
+	b.region = region
+	return b
+}
+
+// Shard sets the ID of the shard the records are from, such as
+// `shardId-000000000001`. Lambda invokes a function with records of one shard.
+func (b *KinesisBuilder) Shard(shardID string) *KinesisBuilder {
+	b.shardID = shardID
+	return b
+}
+
+// Record adds a record with data, which has a random partition key.
+func (b *KinesisBuilder) Record(data []byte) *KinesisBuilder {
+	b.records = append(b.records, events.KinesisEventRecord{
+		EventName:    "aws:kinesis:record",
+		EventSource:  "aws:kinesis",
+		EventVersion: "1.0",
+		Kinesis: events.KinesisRecord{
+			ApproximateArrivalTimestamp: events.SecondsEpochTime{Time: time.Now().UTC().Truncate(time.Millisecond)},
+			Data:                        data,
+			PartitionKey:                newID(),
+			// sequence numbers of Kinesis records have 56 digits
+			SequenceNumber:       "49" + nextSequenceNumber(54),
+			KinesisSchemaVersion: "1.0",
+		},
+	})
+	return b
+}
+
+// JSONRecord adds a record with v marshalled to JSON as its data.
+func (b *KinesisBuilder) JSONRecord(v interface{}) *KinesisBuilder {
+	return b.Record([]byte(mustMarshal(v)))
+}
+
+// PartitionKey sets the partition key of the record.
+func (b *KinesisBuilder) PartitionKey(key string) *KinesisBuilder {
+	b.last().Kinesis.PartitionKey = key
+	return b
+}
+
+// Build the event. If no records were added, the event has one record with empty
+// JSON data.
+func (b *KinesisBuilder) Build() events.KinesisEvent {
+	b.last()
+	records := make([]events.KinesisEventRecord, len(b.records))
+	for i, record := range b.records {
+		record.EventID = b.shardID + ":" + record.Kinesis.SequenceNumber
+		record.EventSourceArn = arn("kinesis", b.region, "stream/"+b.stream)
+		record.InvokeIdentityArn = arn("iam", "", "role/lambdah-test")
+		record.AwsRegion = b.region
+		records[i] = record
+	}
+	return events.KinesisEvent{Records: records}
+}
+
+func (b *KinesisBuilder) last() *events.KinesisEventRecord {
+	if len(b.records) == 0 {
+		b.Record([]byte("{}"))
+	}
+	return &b.records[len(b.records)-1]
+}
@@ -0,0 +1,593 @@
+// Package lambdahtest provides utilities for testing Lambdah handlers.
+package lambdahtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/google/uuid"
+)
+
+const (
+	// RuntimeAPIEnv is the environment variable read by lambda.Start for the address
+	// of the Lambda Runtime API
+	RuntimeAPIEnv = "AWS_LAMBDA_RUNTIME_API"
+
+	// DefaultTimeout is the default function timeout of Lambda
+	DefaultTimeout = 3 * time.Second
+	// DefaultFunctionARN is the function ARN of invocations, unless WithFunctionARN is used
+	DefaultFunctionARN = "arn:aws:lambda:" + Region + ":" + AccountID + ":function:lambdah-test"
+
+	// ErrorTypeTimeout is the error type of invocations which exceed their deadline
+	ErrorTypeTimeout = "Sandbox.Timedout"
+	// ErrorTypeExit is the error type of invocations during which the function
+	// process exits without responding
+	ErrorTypeExit = "Runtime.ExitError"
+
+	runtimeAPIPrefix = "/2018-06-01/runtime/"
+	startTimeout     = 10 * time.Second
+	closeTimeout     = 10 * time.Second
+)
+
+// Headers of the Lambda Runtime API
+const (
+	HeaderRequestID          = "Lambda-Runtime-Aws-Request-Id"
+	HeaderDeadlineMS         = "Lambda-Runtime-Deadline-Ms"
+	HeaderInvokedFunctionARN = "Lambda-Runtime-Invoked-Function-Arn"
+	HeaderTraceID            = "Lambda-Runtime-Trace-Id"
+	HeaderClientContext      = "Lambda-Runtime-Client-Context"
+	HeaderCognitoIdentity    = "Lambda-Runtime-Cognito-Identity"
+	HeaderFunctionErrorType  = "Lambda-Runtime-Function-Error-Type"
+)
+
+var (
+	// ErrRuntimeClosed is returned when invoking a Runtime which has been closed
+	ErrRuntimeClosed = errors.New("lambda runtime emulator is closed")
+
+	// ErrFunctionExited is returned when invoking a Runtime whose function process,
+	// started with StartProcess, has exited before receiving the invocation
+	ErrFunctionExited = errors.New("lambda function process exited")
+
+	// startMu serialises Runtime.Start, as the runtime API address is set in the
+	// process environment
+	startMu sync.Mutex
+)
+
+// FunctionError is an error reported by the function to the Runtime API, either
+// for an invocation or during initialisation.
+type FunctionError struct {
+	Message    string        `json:"errorMessage"`
+	Type       string        `json:"errorType"`
+	StackTrace []interface{} `json:"stackTrace,omitempty"`
+}
+
+func (e *FunctionError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Type, e.Message)
+}
+
+// Result is the outcome of an invocation. Error is set if the function reported
+// an error or timed out, otherwise Payload is the function's response.
+type Result struct {
+	RequestID string
+	Deadline  time.Time
+	Duration  time.Duration
+	Payload   []byte
+	Error     *FunctionError
+	// Headers sent by the function with its response or error, only set by Runtime
+	Headers http.Header
+	// Err is the error returned by the handler, only set by Invoke
+	Err error
+}
+
+// Unmarshal the JSON response payload into v.
+func (r *Result) Unmarshal(v interface{}) error {
+	return json.Unmarshal(r.Payload, v)
+}
+
+// BatchItemFailures returns the item identifiers of a partial batch response,
+// such as the message IDs of failed SQS messages.
+func (r *Result) BatchItemFailures() []string {
+	var res struct {
+		BatchItemFailures []struct {
+			ItemIdentifier string `json:"itemIdentifier"`
+		} `json:"batchItemFailures"`
+	}
+	if err := json.Unmarshal(r.Payload, &res); err != nil {
+		return nil
+	}
+	ids := make([]string, len(res.BatchItemFailures))
+	for i, failure := range res.BatchItemFailures {
+		ids[i] = failure.ItemIdentifier
+	}
+	return ids
+}
+
+type invocation struct {
+	requestID       string
+	payload         []byte
+	timeout         time.Duration
+	functionARN     string
+	traceID         string
+	clientContext   *lambdacontext.ClientContext
+	cognitoIdentity *lambdacontext.CognitoIdentity
+
+	// set when the function receives the invocation
+	deadline time.Time
+	started  chan struct{}
+	done     chan *Result
+}
+
+type InvokeOption func(inv *invocation)
+
+// WithTimeout sets the function timeout of the invocation, from which its deadline
+// is set when the function receives it. The default is 3 seconds.
+func WithTimeout(timeout time.Duration) InvokeOption {
+	return func(inv *invocation) {
+		inv.timeout = timeout
+	}
+}
+
+// WithRequestID sets the request ID of the invocation. By default a random UUID is used.
+func WithRequestID(requestID string) InvokeOption {
+	return func(inv *invocation) {
+		inv.requestID = requestID
+	}
+}
+
+// WithFunctionARN sets the invoked function ARN of the invocation.
+func WithFunctionARN(arn string) InvokeOption {
+	return func(inv *invocation) {
+		inv.functionARN = arn
+	}
+}
+
+// WithTraceID sets the X-Ray trace header of the invocation, which the function
+// sets as the `_X_AMZN_TRACE_ID` environment variable.
+func WithTraceID(traceID string) InvokeOption {
+	return func(inv *invocation) {
+		inv.traceID = traceID
+	}
+}
+
+// WithClientContext sets the client context of the invocation.
+func WithClientContext(cc lambdacontext.ClientContext) InvokeOption {
+	return func(inv *invocation) {
+		inv.clientContext = &cc
+	}
+}
+
+// WithCognitoIdentity sets the Cognito identity of the invocation.
+func WithCognitoIdentity(identity lambdacontext.CognitoIdentity) InvokeOption {
+	return func(inv *invocation) {
+		inv.cognitoIdentity = &identity
+	}
+}
+
+// Runtime is an in process emulator of the Lambda Runtime API, which lets tests run
+// a handler's Start function, as it runs in Lambda, and invoke it.
+//
+//	rt := lambdahtest.NewRuntime()
+//	defer rt.Close()
+//	err := rt.Start(handler.Start)
+//	result, err := rt.Invoke(ctx, event)
+//
+// As in Lambda, a panic which is not recovered by the handler exits the process.
+type Runtime struct {
+	server *httptest.Server
+
+	queue chan *invocation
+
+	mu          sync.Mutex
+	invocations map[string]*invocation
+	initErr     *FunctionError
+
+	ready      chan struct{}
+	readyOnce  sync.Once
+	initFail   chan struct{}
+	closed     chan struct{}
+	closeOnce  sync.Once
+	parked     chan struct{}
+	parkedOnce sync.Once
+	hijacked   []net.Conn
+
+	// set by StartProcess
+	cmd     *exec.Cmd
+	exited  chan struct{}
+	waitErr error
+}
+
+// NewRuntime starts a Runtime API emulator on a local port. It should be closed
+// with Close.
+func NewRuntime() *Runtime {
+	r := &Runtime{
+		queue:       make(chan *invocation),
+		invocations: make(map[string]*invocation),
+		ready:       make(chan struct{}),
+		initFail:    make(chan struct{}),
+		closed:      make(chan struct{}),
+		parked:      make(chan struct{}),
+	}
+	r.server = httptest.NewServer(http.HandlerFunc(r.serveHTTP))
+	return r
+}
+
+// Address returns the host and port of the Runtime API, as used by the
+// AWS_LAMBDA_RUNTIME_API environment variable.
+func (r *Runtime) Address() string {
+	return r.server.Listener.Addr().String()
+}
+
+// Start calls start, which should call lambda.Start, such as a handler's Start
+// method, in a new goroutine with AWS_LAMBDA_RUNTIME_API set to the emulator's
+// address. It returns once the function is ready for its first invocation.
+//
+// The function cannot be stopped, as lambda.Start never returns, so each Runtime
+// should only be started once.
+func (r *Runtime) Start(start func()) error {
+	startMu.Lock()
+	defer startMu.Unlock()
+
+	previous, hadPrevious := os.LookupEnv(RuntimeAPIEnv)
+	_ = os.Setenv(RuntimeAPIEnv, r.Address())
+	defer func() {
+		if hadPrevious {
+			_ = os.Setenv(RuntimeAPIEnv, previous)
+		} else {
+			_ = os.Unsetenv(RuntimeAPIEnv)
+		}
+	}()
+
+	go start()
+
+	select {
+	case <-r.ready:
+		return nil
+	case <-r.initFail:
+		return r.initError()
+	case <-time.After(startTimeout):
+		return fmt.Errorf("function did not request an invocation within %s", startTimeout)
+	}
+}
+
+// StartProcess starts cmd, a compiled Lambda function, with AWS_LAMBDA_RUNTIME_API
+// set to the emulator's address. It returns once the function is ready for its
+// first invocation, and fails if the process exits before then.
+//
+// Unlike Start, invocations fail with ErrFunctionExited once the process has exited,
+// such as after an unrecovered panic, and Close kills the process.
+func (r *Runtime) StartProcess(cmd *exec.Cmd) error {
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, RuntimeAPIEnv+"="+r.Address())
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	r.cmd = cmd
+	r.exited = make(chan struct{})
+	go func() {
+		r.waitErr = cmd.Wait()
+		close(r.exited)
+	}()
+
+	select {
+	case <-r.ready:
+		return nil
+	case <-r.initFail:
+		return r.initError()
+	case <-r.exited:
+		if err := r.initError(); err != nil {
+			return err
+		}
+		return fmt.Errorf("function exited before requesting an invocation: %v", r.waitErr)
+	case <-time.After(startTimeout):
+		return fmt.Errorf("function did not request an invocation within %s", startTimeout)
+	}
+}
+
+// Exited returns a channel which is closed when the function process started with
+// StartProcess exits. It returns nil, which is never closed, for functions started
+// with Start.
+func (r *Runtime) Exited() <-chan struct{} {
+	return r.exited
+}
+
+// Invoke the function with event, which is sent as is if it is a []byte or
+// json.RawMessage, and otherwise as JSON. It blocks until the function responds,
+// reports an error, or reaches its deadline.
+//
+// An error is returned if the function failed to initialise, the context is done
+// or the Runtime is closed; errors of the invocation are set on the Result.
+func (r *Runtime) Invoke(ctx context.Context, event interface{}, opts ...InvokeOption) (*Result, error) {
+	payload, err := marshalEvent(event)
+	if err != nil {
+		return nil, err
+	}
+	inv := &invocation{
+		requestID:   uuid.New().String(),
+		payload:     payload,
+		timeout:     DefaultTimeout,
+		functionARN: DefaultFunctionARN,
+		started:     make(chan struct{}),
+		done:        make(chan *Result, 1),
+	}
+	for _, opt := range opts {
+		opt(inv)
+	}
+
+	select {
+	case <-r.closed:
+		return nil, ErrRuntimeClosed
+	default:
+	}
+
+	select {
+	case r.queue <- inv:
+	case <-r.initFail:
+		return nil, r.initError()
+	case <-r.exited:
+		return nil, ErrFunctionExited
+	case <-r.closed:
+		return nil, ErrRuntimeClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	<-inv.started
+	timer := time.NewTimer(time.Until(inv.deadline))
+	defer timer.Stop()
+
+	select {
+	case result := <-inv.done:
+		return result, nil
+	case <-timer.C:
+		return &Result{
+			RequestID: inv.requestID,
+			Deadline:  inv.deadline,
+			Duration:  inv.timeout,
+			Error: &FunctionError{
+				Message: fmt.Sprintf("Task timed out after %.2f seconds", inv.timeout.Seconds()),
+				Type:    ErrorTypeTimeout,
+			},
+		}, nil
+	case <-r.exited:
+		// the function may have responded before exiting
+		select {
+		case result := <-inv.done:
+			return result, nil
+		default:
+		}
+		return &Result{
+			RequestID: inv.requestID,
+			Deadline:  inv.deadline,
+			Duration:  inv.timeout - time.Until(inv.deadline),
+			Error: &FunctionError{
+				Message: fmt.Sprintf("Runtime exited with error: %v", r.waitErr),
+				Type:    ErrorTypeExit,
+			},
+		}, nil
+	case <-r.closed:
+		return nil, ErrRuntimeClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// InitError returns the error reported by the function during initialisation, if any.
+func (r *Runtime) InitError() *FunctionError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.initErr
+}
+
+// Close the Runtime. The Lambda runtime exits the process if the Runtime API is
+// unavailable, so a started function is left waiting for its next invocation. If
+// the function is still handling an invocation, Close waits for it to finish, and
+// leaves the server running if it takes longer than 10 seconds. A function process
+// started with StartProcess is killed instead.
+func (r *Runtime) Close() {
+	r.closeOnce.Do(func() {
+		close(r.closed)
+		if r.cmd != nil {
+			_ = r.cmd.Process.Kill()
+			<-r.exited
+			r.server.Close()
+			return
+		}
+		select {
+		case <-r.ready:
+			select {
+			case <-r.parked:
+			case <-time.After(closeTimeout):
+				return
+			}
+		default:
+		}
+		r.server.Close()
+	})
+}
+
+func (r *Runtime) initError() error {
+	if err := r.InitError(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Runtime) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	path := strings.TrimPrefix(req.URL.Path, runtimeAPIPrefix)
+	switch {
+	case path == "invocation/next" && req.Method == http.MethodGet:
+		r.next(w, req)
+	case path == "init/error" && req.Method == http.MethodPost:
+		r.postInitError(w, req)
+	case strings.HasPrefix(path, "invocation/") && req.Method == http.MethodPost:
+		parts := strings.Split(strings.TrimPrefix(path, "invocation/"), "/")
+		if len(parts) != 2 || (parts[1] != "response" && parts[1] != "error") {
+			writeError(w, http.StatusNotFound, "InvalidRequest", "unknown path "+req.URL.Path)
+			return
+		}
+		r.postResult(w, req, parts[0], parts[1] == "error")
+	default:
+		writeError(w, http.StatusNotFound, "InvalidRequest", "unknown path "+req.URL.Path)
+	}
+}
+
+func (r *Runtime) next(w http.ResponseWriter, req *http.Request) {
+	r.readyOnce.Do(func() { close(r.ready) })
+
+	select {
+	case <-r.closed:
+		r.hijack(w)
+		return
+	default:
+	}
+
+	var inv *invocation
+	select {
+	case inv = <-r.queue:
+	case <-req.Context().Done():
+		return
+	case <-r.closed:
+		r.hijack(w)
+		return
+	}
+
+	inv.deadline = time.Now().Add(inv.timeout)
+	r.mu.Lock()
+	r.invocations[inv.requestID] = inv
+	r.mu.Unlock()
+	close(inv.started)
+
+	h := w.Header()
+	h.Set("Content-Type", "application/json")
+	h.Set(HeaderRequestID, inv.requestID)
+	h.Set(HeaderDeadlineMS, strconv.FormatInt(inv.deadline.UnixNano()/int64(time.Millisecond), 10))
+	h.Set(HeaderInvokedFunctionARN, inv.functionARN)
+	if inv.traceID != "" {
+		h.Set(HeaderTraceID, inv.traceID)
+	}
+	if inv.clientContext != nil {
+		b, _ := json.Marshal(inv.clientContext)
+		h.Set(HeaderClientContext, string(b))
+	}
+	if inv.cognitoIdentity != nil {
+		b, _ := json.Marshal(inv.cognitoIdentity)
+		h.Set(HeaderCognitoIdentity, string(b))
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(inv.payload)
+}
+
+// hijack takes over the connection of a request for the next invocation, leaving
+// it open so that the server can close without the function exiting the process.
+func (r *Runtime) hijack(w http.ResponseWriter) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	r.hijacked = append(r.hijacked, conn)
+	r.mu.Unlock()
+	r.parkedOnce.Do(func() { close(r.parked) })
+}
+
+func (r *Runtime) postResult(w http.ResponseWriter, req *http.Request, requestID string, isError bool) {
+	r.mu.Lock()
+	inv, ok := r.invocations[requestID]
+	delete(r.invocations, requestID)
+	r.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusBadRequest, "InvalidRequestID", "unknown request ID "+requestID)
+		return
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+
+	result := &Result{
+		RequestID: requestID,
+		Deadline:  inv.deadline,
+		Duration:  inv.timeout - time.Until(inv.deadline),
+		Headers:   req.Header.Clone(),
+	}
+	if isError {
+		result.Error = parseFunctionError(body, req.Header)
+	} else {
+		result.Payload = body
+	}
+	// the result is dropped if the invocation has already timed out
+	inv.done <- result
+
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write([]byte(`{"status":"OK"}`))
+}
+
+func (r *Runtime) postInitError(w http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+
+	r.mu.Lock()
+	first := r.initErr == nil
+	if first {
+		r.initErr = parseFunctionError(body, req.Header)
+	}
+	r.mu.Unlock()
+	if first {
+		close(r.initFail)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write([]byte(`{"status":"OK"}`))
+}
+
+func parseFunctionError(body []byte, header http.Header) *FunctionError {
+	fnErr := &FunctionError{}
+	if err := json.Unmarshal(body, fnErr); err != nil || fnErr.Type == "" && fnErr.Message == "" {
+		fnErr.Message = string(body)
+	}
+	if fnErr.Type == "" {
+		fnErr.Type = header.Get(HeaderFunctionErrorType)
+	}
+	return fnErr
+}
+
+func writeError(w http.ResponseWriter, status int, errorType string, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(FunctionError{Message: message, Type: errorType})
+}
+
+func marshalEvent(event interface{}) ([]byte, error) {
+	switch event := event.(type) {
+	case []byte:
+		return event, nil
+	case json.RawMessage:
+		return event, nil
+	default:
+		return json.Marshal(event)
+	}
+}
@@ -0,0 +1,288 @@
+package lambdahtest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/webbgeorge/lambdah/generic"
+)
+
+type invocationInfo struct {
+	Name          string            `json:"name"`
+	RequestID     string            `json:"request_id"`
+	FunctionARN   string            `json:"function_arn"`
+	DeadlineMS    int64             `json:"deadline_ms"`
+	TraceID       string            `json:"trace_id"`
+	ClientContext map[string]string `json:"client_context"`
+}
+
+func startRuntime(t *testing.T, h generic.HandlerFunc) *Runtime {
+	rt := NewRuntime()
+	t.Cleanup(rt.Close)
+	assert.Nil(t, rt.Start(h.Start))
+	return rt
+}
+
+func infoHandler(c *generic.Context) error {
+	var event struct {
+		Name  string `json:"name"`
+		Sleep int    `json:"sleep"`
+	}
+	err := c.Bind(&event)
+	if err != nil {
+		return err
+	}
+	time.Sleep(time.Duration(event.Sleep) * time.Millisecond)
+
+	lc, _ := lambdacontext.FromContext(c.Context)
+	deadline, _ := c.Context.Deadline()
+	c.Response = invocationInfo{
+		Name:          event.Name,
+		RequestID:     lc.AwsRequestID,
+		FunctionARN:   lc.InvokedFunctionArn,
+		DeadlineMS:    deadline.UnixNano() / int64(time.Millisecond),
+		TraceID:       os.Getenv("_X_AMZN_TRACE_ID"),
+		ClientContext: lc.ClientContext.Custom,
+	}
+	return nil
+}
+
+func TestRuntime_Invoke(t *testing.T) {
+	rt := startRuntime(t, infoHandler)
+
+	result, err := rt.Invoke(
+		context.Background(),
+		map[string]string{"name": "Dave"},
+		WithRequestID("request-1"),
+		WithTimeout(time.Minute),
+	)
+
+	assert.Nil(t, err)
+	assert.Nil(t, result.Error)
+	assert.Equal(t, "request-1", result.RequestID)
+	assert.Equal(t, "application/json", result.Headers.Get("Content-Type"))
+	assert.InDelta(t, time.Now().Add(time.Minute).UnixNano(), result.Deadline.UnixNano(), float64(time.Second))
+	var info invocationInfo
+	assert.Nil(t, result.Unmarshal(&info))
+	assert.Equal(t, invocationInfo{
+		Name:        "Dave",
+		RequestID:   "request-1",
+		FunctionARN: DefaultFunctionARN,
+		DeadlineMS:  result.Deadline.UnixNano() / int64(time.Millisecond),
+	}, info)
+}
+
+func TestRuntime_Invoke_Headers(t *testing.T) {
+	rt := startRuntime(t, infoHandler)
+	traceHeader := "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"
+
+	result, err := rt.Invoke(
+		context.Background(),
+		[]byte(`{"name":"Dave"}`),
+		WithFunctionARN("arn:aws:lambda:eu-west-1:123456789012:function:my-function"),
+		WithTraceID(traceHeader),
+		WithClientContext(lambdacontext.ClientContext{Custom: map[string]string{"app": "test"}}),
+	)
+
+	assert.Nil(t, err)
+	var info invocationInfo
+	assert.Nil(t, result.Unmarshal(&info))
+	assert.Equal(t, "arn:aws:lambda:eu-west-1:123456789012:function:my-function", info.FunctionARN)
+	assert.Equal(t, traceHeader, info.TraceID)
+	assert.Equal(t, map[string]string{"app": "test"}, info.ClientContext)
+}
+
+func TestRuntime_Invoke_FunctionError(t *testing.T) {
+	rt := startRuntime(t, func(c *generic.Context) error {
+		return errors.New("something went wrong")
+	})
+
+	result, err := rt.Invoke(context.Background(), map[string]string{})
+
+	assert.Nil(t, err)
+	assert.Nil(t, result.Payload)
+	assert.Equal(t, "something went wrong", result.Error.Message)
+	assert.Equal(t, "errorString", result.Error.Type)
+	assert.Equal(t, "application/json", result.Headers.Get("Content-Type"))
+}
+
+func TestRuntime_Invoke_Timeout(t *testing.T) {
+	rt := startRuntime(t, infoHandler)
+
+	result, err := rt.Invoke(context.Background(), map[string]interface{}{"sleep": 200}, WithTimeout(50*time.Millisecond))
+
+	assert.Nil(t, err)
+	assert.Equal(t, &FunctionError{
+		Message: "Task timed out after 0.05 seconds",
+		Type:    ErrorTypeTimeout,
+	}, result.Error)
+
+	// the function handles the next invocation once the timed out invocation returns
+	result, err = rt.Invoke(context.Background(), map[string]string{"name": "Dave"})
+
+	assert.Nil(t, err)
+	assert.Nil(t, result.Error)
+	assert.Contains(t, string(result.Payload), `"name":"Dave"`)
+}
+
+func TestRuntime_Invoke_SequentialRequests(t *testing.T) {
+	rt := startRuntime(t, infoHandler)
+
+	for _, name := range []string{"Dave", "Alice", "Bob"} {
+		result, err := rt.Invoke(context.Background(), map[string]string{"name": name})
+		assert.Nil(t, err)
+		var info invocationInfo
+		assert.Nil(t, result.Unmarshal(&info))
+		assert.Equal(t, name, info.Name)
+		assert.Equal(t, result.RequestID, info.RequestID)
+	}
+}
+
+func TestRuntime_Invoke_ContextDone(t *testing.T) {
+	rt := NewRuntime()
+	defer rt.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := rt.Invoke(ctx, map[string]string{})
+
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestRuntime_Invoke_Closed(t *testing.T) {
+	rt := startRuntime(t, infoHandler)
+	rt.Close()
+
+	_, err := rt.Invoke(context.Background(), map[string]string{})
+
+	assert.Equal(t, ErrRuntimeClosed, err)
+}
+
+func TestRuntime_InitError(t *testing.T) {
+	rt := NewRuntime()
+	defer rt.Close()
+
+	resp, err := http.Post(
+		"http://"+rt.Address()+"/2018-06-01/runtime/init/error",
+		"application/json",
+		strings.NewReader(`{"errorMessage":"missing config","errorType":"InitError"}`),
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	expected := &FunctionError{Message: "missing config", Type: "InitError"}
+	assert.Equal(t, expected, rt.InitError())
+	_, err = rt.Invoke(context.Background(), map[string]string{})
+	assert.Equal(t, expected, err)
+}
+
+func TestRuntime_UnknownRequestID(t *testing.T) {
+	rt := NewRuntime()
+	defer rt.Close()
+
+	resp, err := http.Post(
+		"http://"+rt.Address()+"/2018-06-01/runtime/invocation/unknown/response",
+		"application/json",
+		strings.NewReader(`{}`),
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+// TestHelperProcess is run as the function process of StartProcess tests
+func TestHelperProcess(t *testing.T) {
+	switch os.Getenv("LAMBDAHTEST_HELPER_PROCESS") {
+	case "info":
+		generic.HandlerFunc(infoHandler).Start()
+	case "panic":
+		generic.HandlerFunc(func(c *generic.Context) error {
+			panic("something went wrong")
+		}).Start()
+	case "exit":
+		os.Exit(1)
+	case "exit-during-invocation":
+		generic.HandlerFunc(func(c *generic.Context) error {
+			os.Exit(2)
+			return nil
+		}).Start()
+	}
+}
+
+func helperProcess(name string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+	cmd.Env = append(os.Environ(), "LAMBDAHTEST_HELPER_PROCESS="+name)
+	return cmd
+}
+
+func TestRuntime_StartProcess(t *testing.T) {
+	rt := NewRuntime()
+	defer rt.Close()
+
+	err := rt.StartProcess(helperProcess("info"))
+
+	assert.Nil(t, err)
+	result, err := rt.Invoke(context.Background(), map[string]string{"name": "Dave"}, WithRequestID("request-1"))
+	assert.Nil(t, err)
+	var info invocationInfo
+	assert.Nil(t, result.Unmarshal(&info))
+	assert.Equal(t, "Dave", info.Name)
+	assert.Equal(t, "request-1", info.RequestID)
+}
+
+func TestRuntime_StartProcess_Exited(t *testing.T) {
+	rt := NewRuntime()
+	defer rt.Close()
+	assert.Nil(t, rt.StartProcess(helperProcess("panic")))
+
+	result, err := rt.Invoke(context.Background(), map[string]string{})
+
+	// the runtime reports the panic before exiting
+	assert.Nil(t, err)
+	assert.Equal(t, "something went wrong", result.Error.Message)
+	<-rt.Exited()
+	_, err = rt.Invoke(context.Background(), map[string]string{})
+	assert.Equal(t, ErrFunctionExited, err)
+}
+
+func TestRuntime_StartProcess_ExitDuringInvocation(t *testing.T) {
+	rt := NewRuntime()
+	defer rt.Close()
+	assert.Nil(t, rt.StartProcess(helperProcess("exit-during-invocation")))
+
+	result, err := rt.Invoke(context.Background(), map[string]string{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, &FunctionError{
+		Message: "Runtime exited with error: exit status 2",
+		Type:    ErrorTypeExit,
+	}, result.Error)
+}
+
+func TestRuntime_StartProcess_ExitBeforeReady(t *testing.T) {
+	rt := NewRuntime()
+	defer rt.Close()
+
+	err := rt.StartProcess(helperProcess("exit"))
+
+	assert.Contains(t, err.Error(), "function exited before requesting an invocation: exit status 1")
+}
+
+func TestRuntime_Close_KillsProcess(t *testing.T) {
+	rt := NewRuntime()
+	assert.Nil(t, rt.StartProcess(helperProcess("info")))
+
+	rt.Close()
+
+	<-rt.Exited()
+	_, err := rt.Invoke(context.Background(), map[string]string{})
+	assert.Equal(t, ErrRuntimeClosed, err)
+}
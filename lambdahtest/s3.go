@@ -0,0 +1,116 @@
+package lambdahtest
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// S3Builder builds S3 events. Methods which modify an object apply to the most
+// recently added object, adding the object `test-key` if there is none.
+type S3Builder struct {
+	bucket  string
+	region  string
+	records []events.S3EventRecord
+}
+
+// S3 returns a builder of an S3 event from the bucket `lambdah-test`.
+//
+//	lambdahtest.S3().Bucket("uploads").Object("images/cat.png").Size(2048).Build()
+func S3() *S3Builder {
+	return &S3Builder{
+		bucket: "lambdah-test",
+		region: Region,
+	}
+}
+
+// Bucket sets the name of the bucket.
+func (b *S3Builder) Bucket(name string) *S3Builder {
+	b.bucket = name
+	return b
+}
+
+// Region sets the AWS region of the bucket.
+func (b *S3Builder) Region(region string) *S3Builder {
+	b.region = region
+	return b
+}
+
+// Object adds an `ObjectCreated:Put` record of the object key. The key is URL
+// encoded in the event, as it is by S3.
+func (b *S3Builder) Object(key string) *S3Builder {
+	b.records = append(b.records, events.S3EventRecord{
+		EventVersion:      "2.1",
+		EventSource:       "aws:s3",
+		EventTime:         time.Now().UTC(),
+		EventName:         "ObjectCreated:Put",
+		PrincipalID:       events.S3UserIdentity{PrincipalID: "AWS:AIDA" + randomUpper(17)},
+		RequestParameters: events.S3RequestParameters{SourceIPAddress: "127.0.0.1"},
+		ResponseElements: map[string]string{
+			"x-amz-request-id": randomUpper(16),
+			"x-amz-id-2":       randomBase64(48),
+		},
+		S3: events.S3Entity{
+			SchemaVersion:   "1.0",
+			ConfigurationID: "lambdah-test",
+			Object: events.S3Object{
+				Key:           strings.ReplaceAll(url.QueryEscape(key), "%2F", "/"),
+				Size:          1024,
+				URLDecodedKey: key,
+				ETag:          md5Hex(key),
+				Sequencer:     strings.ToUpper(randomHex(8)),
+			},
+		},
+	})
+	return b
+}
+
+// EventName sets the event name of the object, such as `ObjectCreated:Copy` or
+// `ObjectRemoved:Delete`. Objects in `ObjectRemoved` events have no size or ETag.
+func (b *S3Builder) EventName(name string) *S3Builder {
+	record := b.last()
+	record.EventName = strings.TrimPrefix(name, "s3:")
+	if strings.HasPrefix(record.EventName, "ObjectRemoved") {
+		record.S3.Object.Size = 0
+		record.S3.Object.ETag = ""
+	}
+	return b
+}
+
+// Size sets the size of the object in bytes.
+func (b *S3Builder) Size(size int64) *S3Builder {
+	b.last().S3.Object.Size = size
+	return b
+}
+
+// VersionID sets the version ID of the object, for buckets with versioning.
+func (b *S3Builder) VersionID(versionID string) *S3Builder {
+	b.last().S3.Object.VersionID = versionID
+	return b
+}
+
+// Build the event. If no objects were added, the event has one record of the
+// object `test-key`.
+func (b *S3Builder) Build() events.S3Event {
+	b.last()
+	records := make([]events.S3EventRecord, len(b.records))
+	for i, record := range b.records {
+		record.AWSRegion = b.region
+		record.S3.Bucket = events.S3Bucket{
+			Name:          b.bucket,
+			OwnerIdentity: events.S3UserIdentity{PrincipalID: "A" + randomUpper(13)},
+			Arn:           "arn:aws:s3:::" + b.bucket,
+		}
+		records[i] = record
+	}
+	return events.S3Event{Records: records}
+}
+
+func (b *S3Builder) last() *events.S3EventRecord {
+	if len(b.records) == 0 {
+		b.Object("test-key")
+	}
+	return &b.records[len(b.records)-1]
+}
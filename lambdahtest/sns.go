@@ -0,0 +1,98 @@
+package lambdahtest
+
+import (
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// SNSBuilder builds SNS events. Methods which modify a message apply to the most
+// recently added message, adding a message with an empty JSON body if there is none.
+type SNSBuilder struct {
+	topic   string
+	region  string
+	records []events.SNSEventRecord
+}
+
+// SNS returns a builder of an SNS event from the topic `lambdah-test`.
+//
+//	lambdahtest.SNS().Message(body).Subject("Order placed").Attr("correlation_id", id).Build()
+func SNS() *SNSBuilder {
+	return &SNSBuilder{
+		topic:  "lambdah-test",
+		region: Region,
+	}
+}
+
+// Topic sets the name of the topic the messages are from.
+func (b *SNSBuilder) Topic(name string) *SNSBuilder {
+	b.topic = name
+	return b
+}
+
+// Region sets the AWS region of the topic.
+func (b *SNSBuilder) Region(region string) *SNSBuilder {
+	b.region = region
+	return b
+}
+
+// Message adds a notification with message.
+func (b *SNSBuilder) Message(message string) *SNSBuilder {
+	b.records = append(b.records, events.SNSEventRecord{
+		EventVersion: "1.0",
+		EventSource:  "aws:sns",
+		SNS: events.SNSEntity{
+			Signature:         randomBase64(256),
+			MessageID:         newID(),
+			Type:              "Notification",
+			MessageAttributes: map[string]interface{}{},
+			SignatureVersion:  "1",
+			Timestamp:         time.Now().UTC(),
+			Message:           message,
+		},
+	})
+	return b
+}
+
+// JSONMessage adds a notification with v marshalled to JSON as its message.
+func (b *SNSBuilder) JSONMessage(v interface{}) *SNSBuilder {
+	return b.Message(mustMarshal(v))
+}
+
+// Subject sets the subject of the message.
+func (b *SNSBuilder) Subject(subject string) *SNSBuilder {
+	b.last().SNS.Subject = subject
+	return b
+}
+
+// Attr sets a string message attribute of the message.
+func (b *SNSBuilder) Attr(name string, value string) *SNSBuilder {
+	b.last().SNS.MessageAttributes[name] = map[string]interface{}{
+		"Type":  "String",
+		"Value": value,
+	}
+	return b
+}
+
+// Build the event. If no messages were added, the event has one message with an
+// empty JSON body.
+func (b *SNSBuilder) Build() events.SNSEvent {
+	b.last()
+	topicARN := arn("sns", b.region, b.topic)
+	records := make([]events.SNSEventRecord, len(b.records))
+	for i, record := range b.records {
+		record.EventSubscriptionArn = topicARN + ":" + newID()
+		record.SNS.TopicArn = topicARN
+		record.SNS.SigningCertURL = "https://sns." + b.region + ".amazonaws.com/SimpleNotificationService-" + randomHex(16) + ".pem"
+		record.SNS.UnsubscribeURL = "https://sns." + b.region + ".amazonaws.com/?Action=Unsubscribe&SubscriptionArn=" + record.EventSubscriptionArn
+		records[i] = record
+	}
+	return events.SNSEvent{Records: records}
+}
+
+func (b *SNSBuilder) last() *events.SNSEventRecord {
+	if len(b.records) == 0 {
+		b.Message("{}")
+	}
+	return &b.records[len(b.records)-1]
+}
@@ -0,0 +1,121 @@
+package lambdahtest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// SQSBuilder builds SQS events. Methods which modify a message apply to the most
+// recently added message, adding a message with an empty JSON body if there is none.
+type SQSBuilder struct {
+	queue    string
+	region   string
+	fifo     bool
+	messages []events.SQSMessage
+}
+
+// SQS returns a builder of an SQS event from the queue `lambdah-test`.
+//
+//	lambdahtest.SQS().Message(body).Attr("correlation_id", id).FIFO(group).Build()
+func SQS() *SQSBuilder {
+	return &SQSBuilder{
+		queue:  "lambdah-test",
+		region: Region,
+	}
+}
+
+// Queue sets the name of the queue the messages are from.
+func (b *SQSBuilder) Queue(name string) *SQSBuilder {
+	b.queue = strings.TrimSuffix(name, ".fifo")
+	if strings.HasSuffix(name, ".fifo") {
+		b.fifo = true
+	}
+	return b
+}
+
+// Region sets the AWS region of the queue.
+func (b *SQSBuilder) Region(region string) *SQSBuilder {
+	b.region = region
+	return b
+}
+
+// Message adds a message with body.
+func (b *SQSBuilder) Message(body string) *SQSBuilder {
+	now := time.Now()
+	b.messages = append(b.messages, events.SQSMessage{
+		MessageId:     newID(),
+		ReceiptHandle: "AQEB" + randomBase64(96),
+		Body:          body,
+		Md5OfBody:     md5Hex(body),
+		Attributes: map[string]string{
+			"ApproximateReceiveCount":          "1",
+			"SentTimestamp":                    epochMillis(now),
+			"SenderId":                         "AIDA" + randomUpper(17),
+			"ApproximateFirstReceiveTimestamp": epochMillis(now),
+		},
+		MessageAttributes: map[string]events.SQSMessageAttribute{},
+		EventSource:       "aws:sqs",
+	})
+	return b
+}
+
+// JSONMessage adds a message with v marshalled to JSON as its body.
+func (b *SQSBuilder) JSONMessage(v interface{}) *SQSBuilder {
+	return b.Message(mustMarshal(v))
+}
+
+// Attr sets a string message attribute of the message.
+func (b *SQSBuilder) Attr(name string, value string) *SQSBuilder {
+	b.last().MessageAttributes[name] = events.SQSMessageAttribute{
+		StringValue: &value,
+		DataType:    "String",
+	}
+	return b
+}
+
+// SystemAttr sets a system attribute of the message, such as `AWSTraceHeader` or
+// `ApproximateReceiveCount`.
+func (b *SQSBuilder) SystemAttr(name string, value string) *SQSBuilder {
+	b.last().Attributes[name] = value
+	return b
+}
+
+// FIFO makes the queue a FIFO queue, and sets the message group ID, deduplication
+// ID and sequence number of the message.
+func (b *SQSBuilder) FIFO(group string) *SQSBuilder {
+	b.fifo = true
+	msg := b.last()
+	hash := sha256.Sum256([]byte(msg.Body))
+	msg.Attributes["MessageGroupId"] = group
+	msg.Attributes["MessageDeduplicationId"] = hex.EncodeToString(hash[:])
+	msg.Attributes["SequenceNumber"] = nextSequenceNumber(20)
+	return b
+}
+
+// Build the event. If no messages were added, the event has one message with an
+// empty JSON body.
+func (b *SQSBuilder) Build() events.SQSEvent {
+	b.last()
+	queue := b.queue
+	if b.fifo {
+		queue += ".fifo"
+	}
+	records := make([]events.SQSMessage, len(b.messages))
+	for i, msg := range b.messages {
+		msg.EventSourceARN = arn("sqs", b.region, queue)
+		msg.AWSRegion = b.region
+		records[i] = msg
+	}
+	return events.SQSEvent{Records: records}
+}
+
+func (b *SQSBuilder) last() *events.SQSMessage {
+	if len(b.messages) == 0 {
+		b.Message("{}")
+	}
+	return &b.messages[len(b.messages)-1]
+}
@@ -0,0 +1,209 @@
+package log
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	// XRayTraceHeader is the HTTP header used by AWS X-Ray
+	XRayTraceHeader = "X-Amzn-Trace-Id"
+	// XRayTraceEnv is the environment variable set by Lambda to the X-Ray trace
+	// header of the current invocation
+	XRayTraceEnv = "_X_AMZN_TRACE_ID"
+	// SQSTraceAttribute is the SQS system attribute holding the X-Ray trace header
+	SQSTraceAttribute = "AWSTraceHeader"
+	// TraceparentHeader is the W3C trace context header
+	TraceparentHeader = "traceparent"
+	// TracestateHeader is the W3C trace context vendor state header
+	TracestateHeader = "tracestate"
+)
+
+var (
+	traceIDPattern     = regexp.MustCompile(`^[0-9a-f]{32}$`)
+	spanIDPattern      = regexp.MustCompile(`^[0-9a-f]{16}$`)
+	xrayRootPattern    = regexp.MustCompile(`^1-([0-9a-f]{8})-([0-9a-f]{24})$`)
+	traceparentPattern = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})`)
+)
+
+// TraceContext identifies the trace an event is part of, from an AWS X-Ray trace
+// header or a W3C trace context traceparent header, which use the same trace and
+// span ID sizes. TraceID is 32 and SpanID 16 lowercase hex characters. SpanID is
+// the ID of the parent span of the invocation, and may be empty.
+type TraceContext struct {
+	TraceID    string
+	SpanID     string
+	Sampled    bool
+	TraceState string
+}
+
+// ParseXRayTraceHeader parses an X-Ray trace header, for example
+// `Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1`
+func ParseXRayTraceHeader(header string) (TraceContext, bool) {
+	var tc TraceContext
+	for _, part := range strings.Split(header, ";") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "Root":
+			match := xrayRootPattern.FindStringSubmatch(kv[1])
+			if match == nil {
+				return TraceContext{}, false
+			}
+			tc.TraceID = match[1] + match[2]
+		case "Parent":
+			if spanIDPattern.MatchString(kv[1]) {
+				tc.SpanID = kv[1]
+			}
+		case "Sampled":
+			tc.Sampled = kv[1] == "1"
+		}
+	}
+	return tc, tc.TraceID != ""
+}
+
+// ParseTraceparent parses W3C trace context traceparent and tracestate headers, for
+// example `00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01`
+func ParseTraceparent(traceparent string, tracestate string) (TraceContext, bool) {
+	match := traceparentPattern.FindStringSubmatch(strings.TrimSpace(traceparent))
+	if match == nil || match[1] == "ff" {
+		return TraceContext{}, false
+	}
+	if match[2] == strings.Repeat("0", 32) || match[3] == strings.Repeat("0", 16) {
+		return TraceContext{}, false
+	}
+	return TraceContext{
+		TraceID:    match[2],
+		SpanID:     match[3],
+		Sampled:    match[4] == "01",
+		TraceState: strings.TrimSpace(tracestate),
+	}, true
+}
+
+// ExtractTraceContext returns the trace context from the given headers, any of
+// which may be empty. The W3C traceparent header takes precedence over the X-Ray
+// trace header.
+func ExtractTraceContext(xrayHeader string, traceparent string, tracestate string) (TraceContext, bool) {
+	if tc, ok := ParseTraceparent(traceparent, tracestate); ok {
+		return tc, true
+	}
+	return ParseXRayTraceHeader(xrayHeader)
+}
+
+// TraceContextFromEnv returns the trace context of the current invocation from the
+// `_X_AMZN_TRACE_ID` environment variable, which is set by Lambda when X-Ray
+// tracing is active.
+func TraceContextFromEnv() (TraceContext, bool) {
+	return ParseXRayTraceHeader(os.Getenv(XRayTraceEnv))
+}
+
+// XRayTraceID returns the trace ID in the X-Ray format, for example
+// `1-5759e988-bd862e3fe1be46a994272793`
+func (tc TraceContext) XRayTraceID() string {
+	if !traceIDPattern.MatchString(tc.TraceID) {
+		return ""
+	}
+	return "1-" + tc.TraceID[:8] + "-" + tc.TraceID[8:]
+}
+
+// XRayTraceHeader returns the trace context as an X-Ray trace header.
+func (tc TraceContext) XRayTraceHeader() string {
+	root := tc.XRayTraceID()
+	if root == "" {
+		return ""
+	}
+	header := "Root=" + root
+	if tc.SpanID != "" {
+		header += ";Parent=" + tc.SpanID
+	}
+	if tc.Sampled {
+		return header + ";Sampled=1"
+	}
+	return header + ";Sampled=0"
+}
+
+// Traceparent returns the trace context as a W3C traceparent header. This is empty
+// if there is no span ID, which is optional in X-Ray but required by W3C.
+func (tc TraceContext) Traceparent() string {
+	if !traceIDPattern.MatchString(tc.TraceID) || !spanIDPattern.MatchString(tc.SpanID) {
+		return ""
+	}
+	flags := "00"
+	if tc.Sampled {
+		flags = "01"
+	}
+	return "00-" + tc.TraceID + "-" + tc.SpanID + "-" + flags
+}
+
+type traceContextKey struct{}
+
+func WithTraceContext(c context.Context, tc TraceContext) context.Context {
+	return context.WithValue(c, traceContextKey{}, tc)
+}
+
+func TraceContextFromContext(c context.Context) (TraceContext, bool) {
+	if c == nil {
+		return TraceContext{}, false
+	}
+	tc, ok := c.Value(traceContextKey{}).(TraceContext)
+	return tc, ok
+}
+
+// currentTraceContext returns the trace context from c, or from the environment if
+// c has none.
+func currentTraceContext(c context.Context) (TraceContext, bool) {
+	if tc, ok := TraceContextFromContext(c); ok {
+		return tc, true
+	}
+	return TraceContextFromEnv()
+}
+
+// TraceFields returns the log fields `trace_id`, `span_id` and `xray_trace_id` of
+// the trace context in c, or in the environment, and is used by LoggerMiddleware.
+func TraceFields(c context.Context) map[string]string {
+	fields := make(map[string]string)
+	tc, ok := currentTraceContext(c)
+	if !ok {
+		return fields
+	}
+	fields["trace_id"] = tc.TraceID
+	fields["xray_trace_id"] = tc.XRayTraceID()
+	if tc.SpanID != "" {
+		fields["span_id"] = tc.SpanID
+	}
+	return fields
+}
+
+// TraceHeaders returns the headers which propagate the trace context in c, or in
+// the environment, to other services. This includes both X-Ray and W3C headers.
+// They can be set as HTTP headers, or as SQS or SNS message attributes.
+func TraceHeaders(c context.Context) map[string]string {
+	headers := make(map[string]string)
+	tc, ok := currentTraceContext(c)
+	if !ok {
+		return headers
+	}
+	if xray := tc.XRayTraceHeader(); xray != "" {
+		headers[XRayTraceHeader] = xray
+	}
+	if traceparent := tc.Traceparent(); traceparent != "" {
+		headers[TraceparentHeader] = traceparent
+		if tc.TraceState != "" {
+			headers[TracestateHeader] = tc.TraceState
+		}
+	}
+	return headers
+}
+
+// InjectTraceHeaders sets the trace headers from TraceHeaders on an outgoing
+// HTTP request's headers.
+func InjectTraceHeaders(c context.Context, header http.Header) {
+	for k, v := range TraceHeaders(c) {
+		header.Set(k, v)
+	}
+}
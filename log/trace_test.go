@@ -0,0 +1,140 @@
+package log
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseXRayTraceHeader(t *testing.T) {
+	tc, ok := ParseXRayTraceHeader("Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1")
+	assert.True(t, ok)
+	assert.Equal(t, TraceContext{
+		TraceID: "5759e988bd862e3fe1be46a994272793",
+		SpanID:  "53995c3f42cd8ad8",
+		Sampled: true,
+	}, tc)
+
+	tc, ok = ParseXRayTraceHeader("Root=1-5759e988-bd862e3fe1be46a994272793;Sampled=0;Lineage=a87bd80c:0")
+	assert.True(t, ok)
+	assert.Equal(t, TraceContext{TraceID: "5759e988bd862e3fe1be46a994272793"}, tc)
+
+	_, ok = ParseXRayTraceHeader("Root=not-a-trace-id")
+	assert.False(t, ok)
+	_, ok = ParseXRayTraceHeader("")
+	assert.False(t, ok)
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tc, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "congo=t61rcWkgMzE")
+	assert.True(t, ok)
+	assert.Equal(t, TraceContext{
+		TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:     "00f067aa0ba902b7",
+		Sampled:    true,
+		TraceState: "congo=t61rcWkgMzE",
+	}, tc)
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+	} {
+		_, ok := ParseTraceparent(invalid, "")
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestExtractTraceContext(t *testing.T) {
+	xray := "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tc, ok := ExtractTraceContext(xray, traceparent, "")
+	assert.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tc.TraceID)
+
+	tc, ok = ExtractTraceContext(xray, "invalid", "")
+	assert.True(t, ok)
+	assert.Equal(t, "5759e988bd862e3fe1be46a994272793", tc.TraceID)
+
+	_, ok = ExtractTraceContext("", "", "")
+	assert.False(t, ok)
+}
+
+func TestTraceContext_Headers(t *testing.T) {
+	tc := TraceContext{
+		TraceID:    "5759e988bd862e3fe1be46a994272793",
+		SpanID:     "53995c3f42cd8ad8",
+		Sampled:    true,
+		TraceState: "congo=t61rcWkgMzE",
+	}
+
+	assert.Equal(t, "1-5759e988-bd862e3fe1be46a994272793", tc.XRayTraceID())
+	assert.Equal(t, "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1", tc.XRayTraceHeader())
+	assert.Equal(t, "00-5759e988bd862e3fe1be46a994272793-53995c3f42cd8ad8-01", tc.Traceparent())
+
+	tc = TraceContext{TraceID: "5759e988bd862e3fe1be46a994272793"}
+	assert.Equal(t, "Root=1-5759e988-bd862e3fe1be46a994272793;Sampled=0", tc.XRayTraceHeader())
+	assert.Equal(t, "", tc.Traceparent())
+}
+
+func TestTraceContextFromContext(t *testing.T) {
+	// nil context
+	_, ok := TraceContextFromContext(nil)
+	assert.False(t, ok)
+	// without trace context
+	_, ok = TraceContextFromContext(context.Background())
+	assert.False(t, ok)
+	// with trace context
+	c := WithTraceContext(context.Background(), TraceContext{TraceID: "5759e988bd862e3fe1be46a994272793"})
+	tc, ok := TraceContextFromContext(c)
+	assert.True(t, ok)
+	assert.Equal(t, "5759e988bd862e3fe1be46a994272793", tc.TraceID)
+}
+
+func TestTraceFields(t *testing.T) {
+	c := WithTraceContext(context.Background(), TraceContext{
+		TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:  "00f067aa0ba902b7",
+	})
+
+	assert.Equal(t, map[string]string{
+		"trace_id":      "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":       "00f067aa0ba902b7",
+		"xray_trace_id": "1-4bf92f35-77b34da6a3ce929d0e0e4736",
+	}, TraceFields(c))
+}
+
+func TestTraceFields_FromEnv(t *testing.T) {
+	defer os.Unsetenv(XRayTraceEnv)
+	assert.Equal(t, map[string]string{}, TraceFields(context.Background()))
+
+	_ = os.Setenv(XRayTraceEnv, "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1")
+	assert.Equal(t, map[string]string{
+		"trace_id":      "5759e988bd862e3fe1be46a994272793",
+		"span_id":       "53995c3f42cd8ad8",
+		"xray_trace_id": "1-5759e988-bd862e3fe1be46a994272793",
+	}, TraceFields(context.Background()))
+}
+
+func TestInjectTraceHeaders(t *testing.T) {
+	c := WithTraceContext(context.Background(), TraceContext{
+		TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:     "00f067aa0ba902b7",
+		Sampled:    true,
+		TraceState: "congo=t61rcWkgMzE",
+	})
+	header := http.Header{}
+
+	InjectTraceHeaders(c, header)
+
+	assert.Equal(t, "Root=1-4bf92f35-77b34da6a3ce929d0e0e4736;Parent=00f067aa0ba902b7;Sampled=1", header.Get("X-Amzn-Trace-Id"))
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", header.Get("traceparent"))
+	assert.Equal(t, "congo=t61rcWkgMzE", header.Get("tracestate"))
+}
@@ -0,0 +1,244 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxMetricsPerDocument is the maximum number of metrics CloudWatch accepts in a
+// single EMF document, larger sets of metrics are split into multiple documents.
+const maxMetricsPerDocument = 100
+
+type Unit string
+
+const (
+	Seconds      Unit = "Seconds"
+	Microseconds Unit = "Microseconds"
+	Milliseconds Unit = "Milliseconds"
+	Bytes        Unit = "Bytes"
+	Kilobytes    Unit = "Kilobytes"
+	Megabytes    Unit = "Megabytes"
+	Gigabytes    Unit = "Gigabytes"
+	Bits         Unit = "Bits"
+	Percent      Unit = "Percent"
+	Count        Unit = "Count"
+	BytesSecond  Unit = "Bytes/Second"
+	CountSecond  Unit = "Count/Second"
+	None         Unit = "None"
+)
+
+// Resolution is the storage resolution of a metric in seconds.
+type Resolution int
+
+const (
+	StandardResolution Resolution = 60
+	HighResolution     Resolution = 1
+)
+
+type metric struct {
+	unit       Unit
+	resolution Resolution
+	values     []float64
+}
+
+// Metrics collects metrics, dimensions and properties, which are written to w in
+// the CloudWatch Embedded Metric Format (EMF) when flushed. When w is the Lambda
+// function's stdout, CloudWatch extracts the metrics from the function's logs,
+// without any calls to the CloudWatch API.
+//
+// Metrics is safe for concurrent use.
+type Metrics struct {
+	mu          sync.Mutex
+	w           io.Writer
+	namespace   string
+	dimensions  map[string]string
+	properties  map[string]interface{}
+	metrics     map[string]*metric
+	metricNames []string
+}
+
+func New(w io.Writer, namespace string) *Metrics {
+	return &Metrics{
+		w:          w,
+		namespace:  namespace,
+		dimensions: make(map[string]string),
+		properties: make(map[string]interface{}),
+		metrics:    make(map[string]*metric),
+	}
+}
+
+// AddDimension adds a dimension to all metrics. CloudWatch allows up to 30 dimensions.
+func (m *Metrics) AddDimension(name string, value string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dimensions[name] = value
+}
+
+// SetProperty sets a property, which is written with the metrics but is not a
+// dimension, so it can be searched in CloudWatch Logs Insights without creating
+// new metrics. Useful for high cardinality values such as request IDs.
+func (m *Metrics) SetProperty(name string, value interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.properties[name] = value
+}
+
+// Add a value to the named metric, with the standard storage resolution of 60 seconds.
+// A metric may be added many times before it is flushed.
+func (m *Metrics) Add(name string, value float64, unit Unit) {
+	m.add(name, value, unit, StandardResolution)
+}
+
+// AddHighResolution adds a value to the named metric, with a high storage
+// resolution of 1 second.
+func (m *Metrics) AddHighResolution(name string, value float64, unit Unit) {
+	m.add(name, value, unit, HighResolution)
+}
+
+func (m *Metrics) add(name string, value float64, unit Unit, resolution Resolution) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mt, ok := m.metrics[name]
+	if !ok {
+		// the unit and resolution of a metric are set when it is first added
+		mt = &metric{unit: unit, resolution: resolution}
+		m.metrics[name] = mt
+		m.metricNames = append(m.metricNames, name)
+	}
+	mt.values = append(mt.values, value)
+}
+
+// Flush writes the metrics to w as EMF JSON, one document per line, and clears the
+// metrics and properties so that m can be reused. Dimensions are kept. Nothing is
+// written if no metrics have been added.
+func (m *Metrics) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.metricNames) == 0 {
+		return nil
+	}
+
+	timestamp := time.Now().UnixNano() / int64(time.Millisecond)
+	for start := 0; start < len(m.metricNames); start += maxMetricsPerDocument {
+		end := start + maxMetricsPerDocument
+		if end > len(m.metricNames) {
+			end = len(m.metricNames)
+		}
+
+		b, err := json.Marshal(m.document(m.metricNames[start:end], timestamp))
+		if err != nil {
+			return err
+		}
+		_, err = m.w.Write(append(b, '\n'))
+		if err != nil {
+			return err
+		}
+	}
+
+	m.properties = make(map[string]interface{})
+	m.metrics = make(map[string]*metric)
+	m.metricNames = nil
+	return nil
+}
+
+func (m *Metrics) document(names []string, timestamp int64) map[string]interface{} {
+	doc := make(map[string]interface{}, len(m.properties)+len(m.dimensions)+len(names)+1)
+	for name, value := range m.properties {
+		doc[name] = value
+	}
+
+	dimensionNames := make([]string, 0, len(m.dimensions))
+	for name, value := range m.dimensions {
+		dimensionNames = append(dimensionNames, name)
+		doc[name] = value
+	}
+	sort.Strings(dimensionNames)
+
+	definitions := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		mt := m.metrics[name]
+		definition := map[string]interface{}{
+			"Name": name,
+			"Unit": mt.unit,
+		}
+		if mt.resolution == HighResolution {
+			definition["StorageResolution"] = mt.resolution
+		}
+		definitions = append(definitions, definition)
+
+		if len(mt.values) == 1 {
+			doc[name] = mt.values[0]
+		} else {
+			doc[name] = mt.values
+		}
+	}
+
+	doc["_aws"] = map[string]interface{}{
+		"Timestamp": timestamp,
+		"CloudWatchMetrics": []map[string]interface{}{
+			{
+				"Namespace":  m.namespace,
+				"Dimensions": [][]string{dimensionNames},
+				"Metrics":    definitions,
+			},
+		},
+	}
+	return doc
+}
+
+// RecordInvocation adds the standard metrics for a handler invocation, and is used
+// by the MetricsMiddleware of each handler package.
+//
+// The metrics are `Invocations`, `Duration` (in milliseconds), `Errors`, and
+// `ColdStart` which is only added on a cold start.
+func (m *Metrics) RecordInvocation(duration time.Duration, err error, coldStart bool) {
+	m.Add("Invocations", 1, Count)
+	m.Add("Duration", float64(duration)/float64(time.Millisecond), Milliseconds)
+	if err != nil {
+		m.Add("Errors", 1, Count)
+	} else {
+		m.Add("Errors", 0, Count)
+	}
+	if coldStart {
+		m.Add("ColdStart", 1, Count)
+	}
+}
+
+// RecordStatusCode adds the `4XXError` and `5XXError` metrics, and the `StatusCode`
+// property, for an HTTP response. It is used by the MetricsMiddleware of the HTTP
+// handler packages.
+func (m *Metrics) RecordStatusCode(statusCode int) {
+	m.SetProperty("StatusCode", statusCode)
+	m.Add("4XXError", boolToFloat(statusCode >= 400 && statusCode < 500), Count)
+	m.Add("5XXError", boolToFloat(statusCode >= 500), Count)
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+type metricsContextKey struct{}
+
+func WithMetrics(c context.Context, metrics *Metrics) context.Context {
+	return context.WithValue(c, metricsContextKey{}, metrics)
+}
+
+func MetricsFromContext(c context.Context) *Metrics {
+	if c == nil {
+		return nil
+	}
+	metrics, ok := c.Value(metricsContextKey{}).(*Metrics)
+	if !ok {
+		return nil
+	}
+	return metrics
+}
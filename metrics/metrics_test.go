@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeDocuments(t *testing.T, b *bytes.Buffer) []map[string]interface{} {
+	docs := make([]map[string]interface{}, 0)
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		var doc map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(line), &doc))
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+func TestMetrics_Flush(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(buf, "MyApp")
+	m.AddDimension("Service", "orders")
+	m.AddDimension("Environment", "prod")
+	m.SetProperty("request_id", "abc-123")
+	m.Add("OrdersPlaced", 1, Count)
+	m.Add("OrdersPlaced", 2, Count)
+	m.AddHighResolution("Latency", 12.5, Milliseconds)
+
+	err := m.Flush()
+
+	assert.Nil(t, err)
+	docs := decodeDocuments(t, buf)
+	assert.Len(t, docs, 1)
+	doc := docs[0]
+	aws := doc["_aws"].(map[string]interface{})
+	assert.InDelta(t, float64(time.Now().UnixNano()/int64(time.Millisecond)), aws["Timestamp"], 1000)
+	delete(aws, "Timestamp")
+	assert.Equal(t, map[string]interface{}{
+		"_aws": map[string]interface{}{
+			"CloudWatchMetrics": []interface{}{
+				map[string]interface{}{
+					"Namespace":  "MyApp",
+					"Dimensions": []interface{}{[]interface{}{"Environment", "Service"}},
+					"Metrics": []interface{}{
+						map[string]interface{}{"Name": "OrdersPlaced", "Unit": "Count"},
+						map[string]interface{}{"Name": "Latency", "Unit": "Milliseconds", "StorageResolution": float64(1)},
+					},
+				},
+			},
+		},
+		"Service":      "orders",
+		"Environment":  "prod",
+		"request_id":   "abc-123",
+		"OrdersPlaced": []interface{}{float64(1), float64(2)},
+		"Latency":      12.5,
+	}, doc)
+}
+
+func TestMetrics_Flush_ClearsMetrics(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(buf, "MyApp")
+	m.AddDimension("Service", "orders")
+	m.SetProperty("request_id", "abc-123")
+	m.Add("OrdersPlaced", 1, Count)
+	assert.Nil(t, m.Flush())
+
+	buf.Reset()
+	assert.Nil(t, m.Flush())
+	assert.Equal(t, "", buf.String())
+
+	m.Add("OrdersShipped", 1, Count)
+	assert.Nil(t, m.Flush())
+	doc := decodeDocuments(t, buf)[0]
+	assert.Equal(t, "orders", doc["Service"])
+	assert.NotContains(t, doc, "request_id")
+	assert.NotContains(t, doc, "OrdersPlaced")
+	assert.Equal(t, float64(1), doc["OrdersShipped"])
+}
+
+func TestMetrics_Flush_SplitsLargeDocuments(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(buf, "MyApp")
+	for i := 0; i < 150; i++ {
+		m.Add(fmt.Sprintf("Metric%d", i), 1, Count)
+	}
+
+	assert.Nil(t, m.Flush())
+
+	docs := decodeDocuments(t, buf)
+	assert.Len(t, docs, 2)
+	assert.Contains(t, docs[0], "Metric99")
+	assert.NotContains(t, docs[0], "Metric100")
+	assert.Contains(t, docs[1], "Metric149")
+}
+
+func TestMetrics_RecordInvocation(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(buf, "MyApp")
+
+	m.RecordInvocation(1500*time.Microsecond, assert.AnError, true)
+	m.RecordStatusCode(502)
+	assert.Nil(t, m.Flush())
+
+	doc := decodeDocuments(t, buf)[0]
+	assert.Equal(t, float64(1), doc["Invocations"])
+	assert.Equal(t, 1.5, doc["Duration"])
+	assert.Equal(t, float64(1), doc["Errors"])
+	assert.Equal(t, float64(1), doc["ColdStart"])
+	assert.Equal(t, float64(0), doc["4XXError"])
+	assert.Equal(t, float64(1), doc["5XXError"])
+	assert.Equal(t, float64(502), doc["StatusCode"])
+}
+
+func TestMetrics_RecordInvocation_WarmStart(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(buf, "MyApp")
+
+	m.RecordInvocation(time.Millisecond, nil, false)
+	assert.Nil(t, m.Flush())
+
+	doc := decodeDocuments(t, buf)[0]
+	assert.Equal(t, float64(0), doc["Errors"])
+	assert.NotContains(t, doc, "ColdStart")
+}
+
+func TestMetricsFromContext(t *testing.T) {
+	// nil context
+	assert.Nil(t, MetricsFromContext(nil))
+	// without metrics
+	assert.Nil(t, MetricsFromContext(context.Background()))
+	// with metrics
+	c := WithMetrics(context.Background(), New(ioutil.Discard, "MyApp"))
+	assert.NotNil(t, MetricsFromContext(c))
+}
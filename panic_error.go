@@ -0,0 +1,57 @@
+package lambdah
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/webbgeorge/lambdah/log"
+)
+
+// PanicError is returned by RecoverMiddleware when a handler panics. Value is the
+// value passed to panic, and Stack is the stack trace of the panicking goroutine.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (err PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", err.Value)
+}
+
+// Unwrap returns the value passed to panic if it is an error.
+func (err PanicError) Unwrap() error {
+	if valueErr, ok := err.Value.(error); ok {
+		return valueErr
+	}
+	return nil
+}
+
+// Recover calls fn, returning a PanicError if fn panics.
+func Recover(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = PanicError{
+				Value: r,
+				Stack: debug.Stack(),
+			}
+		}
+	}()
+	return fn()
+}
+
+// RecoverAndLog calls fn as Recover does, and logs a panic and its stack trace
+// with the logger of ctx, if it has one. It is used by the RecoverMiddleware of
+// each handler package.
+func RecoverAndLog(ctx context.Context, fn func() error) error {
+	err := Recover(fn)
+	if panicErr, ok := err.(PanicError); ok {
+		logger := log.LoggerFromContext(ctx)
+		if logger != nil {
+			logger.Error().
+				Str("stack", string(panicErr.Stack)).
+				Msgf("Recovered from panic: %v", panicErr.Value)
+		}
+	}
+	return err
+}
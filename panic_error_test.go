@@ -0,0 +1,75 @@
+package lambdah
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/webbgeorge/lambdah/log"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecover_NoPanic(t *testing.T) {
+	assert.Nil(t, Recover(func() error {
+		return nil
+	}))
+	assert.Equal(t, assert.AnError, Recover(func() error {
+		return assert.AnError
+	}))
+}
+
+func TestRecover_Panic(t *testing.T) {
+	err := Recover(func() error {
+		panic("oh no")
+	})
+
+	panicErr, ok := err.(PanicError)
+	assert.True(t, ok)
+	assert.Equal(t, "oh no", panicErr.Value)
+	assert.Contains(t, string(panicErr.Stack), "TestRecover_Panic")
+	assert.EqualError(t, err, "panic: oh no")
+	assert.Nil(t, errors.Unwrap(err))
+}
+
+func TestRecover_PanicWithError(t *testing.T) {
+	err := Recover(func() error {
+		panic(assert.AnError)
+	})
+
+	assert.True(t, errors.Is(err, assert.AnError))
+}
+
+func TestRecoverAndLog_Panic(t *testing.T) {
+	logBuffer := &bytes.Buffer{}
+	ctx := log.WithLogger(context.Background(), log.NewLogger(logBuffer, nil))
+
+	err := RecoverAndLog(ctx, func() error {
+		panic("oh no")
+	})
+
+	assert.EqualError(t, err, "panic: oh no")
+	assert.Contains(t, logBuffer.String(), "Recovered from panic: oh no")
+	assert.Contains(t, logBuffer.String(), `"stack":`)
+}
+
+func TestRecoverAndLog_NoLogger(t *testing.T) {
+	err := RecoverAndLog(context.Background(), func() error {
+		panic("oh no")
+	})
+
+	assert.EqualError(t, err, "panic: oh no")
+}
+
+func TestRecoverAndLog_NoPanic(t *testing.T) {
+	logBuffer := &bytes.Buffer{}
+	ctx := log.WithLogger(context.Background(), log.NewLogger(logBuffer, nil))
+
+	err := RecoverAndLog(ctx, func() error {
+		return assert.AnError
+	})
+
+	assert.Equal(t, assert.AnError, err)
+	assert.Empty(t, logBuffer.String())
+}
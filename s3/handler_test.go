@@ -2,8 +2,12 @@ package s3
 
 import (
 	"context"
+	"errors"
+	"sync/atomic"
 	"testing"
 
+	"github.com/webbgeorge/lambdah"
+
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/stretchr/testify/assert"
 )
@@ -105,6 +109,77 @@ func TestS3Handler_Error(t *testing.T) {
 	assert.Equal(t, 1, callCount)
 }
 
+func TestS3ConcurrentHandler_Success(t *testing.T) {
+	var callCount int32
+	h := func(c *Context) error {
+		atomic.AddInt32(&callCount, 1)
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).Concurrent(2).ToLambdaHandler()
+	err := awsHandler(
+		context.Background(),
+		events.S3Event{Records: []events.S3EventRecord{
+			{EventName: "s3:test:event:1"},
+			{EventName: "s3:test:event:2"},
+			{EventName: "s3:test:event:3"},
+		}},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), callCount)
+}
+
+func TestS3ConcurrentHandler_Error(t *testing.T) {
+	var callCount int32
+	h := func(c *Context) error {
+		atomic.AddInt32(&callCount, 1)
+		return assert.AnError
+	}
+
+	awsHandler := HandlerFunc(h).Concurrent(2).ToLambdaHandler()
+	err := awsHandler(
+		context.Background(),
+		events.S3Event{Records: []events.S3EventRecord{
+			{EventName: "s3:test:event:1"},
+			{EventName: "s3:test:event:2"},
+			{EventName: "s3:test:event:3"},
+		}},
+	)
+
+	var batchErr lambdah.BatchError
+	assert.True(t, errors.As(err, &batchErr))
+	assert.Len(t, batchErr.Errors, 3)
+	assert.True(t, errors.Is(err, assert.AnError))
+	assert.Equal(t, int32(3), callCount)
+}
+
+func TestS3ConcurrentHandler_DeadlineExceeded(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var callCount int32
+	h := func(c *Context) error {
+		atomic.AddInt32(&callCount, 1)
+		cancel()
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).Concurrent(1).ToLambdaHandler()
+	err := awsHandler(
+		ctx,
+		events.S3Event{Records: []events.S3EventRecord{
+			{EventName: "s3:test:event:1"},
+			{EventName: "s3:test:event:2"},
+			{EventName: "s3:test:event:3"},
+		}},
+	)
+
+	var batchErr lambdah.BatchError
+	assert.True(t, errors.As(err, &batchErr))
+	assert.Len(t, batchErr.Errors, 2)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, int32(1), callCount)
+}
+
 func TestS3Handler_Middleware(t *testing.T) {
 	callOrder := make([]string, 0)
 
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 
 	"github.com/webbgeorge/lambdah"
+	"github.com/webbgeorge/lambdah/internal/batch"
+	"github.com/webbgeorge/lambdah/internal/clone"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -21,11 +23,7 @@ func (c *Context) Bind(v interface{}) error {
 		return err
 	}
 
-	if validatable, ok := v.(lambdah.Validatable); ok {
-		return validatable.Validate()
-	}
-
-	return nil
+	return lambdah.Validate(v)
 }
 
 type HandlerFunc func(c *Context) error
@@ -64,3 +62,53 @@ func (hf HandlerFunc) ToLambdaHandler() func(ctx context.Context, event events.S
 		return nil
 	}
 }
+
+// ConcurrentHandler processes the records of each event concurrently, see HandlerFunc.Concurrent.
+type ConcurrentHandler struct {
+	handler batch.Handler[Context]
+}
+
+// Process the records of each event concurrently, using a pool of at most
+// concurrency workers. Each record is given its own *Context.
+//
+// All records are processed even if some fail, and the errors are returned
+// together as a lambdah.BatchError. If the Lambda deadline is reached, no further
+// records are started and each remaining record fails with the context's error.
+func (hf HandlerFunc) Concurrent(concurrency int) ConcurrentHandler {
+	return ConcurrentHandler{
+		handler: batch.Handler[Context]{HandlerFunc: hf, Concurrency: concurrency},
+	}
+}
+
+func (ch ConcurrentHandler) Start() {
+	lambda.Start(ch.ToLambdaHandler())
+}
+
+// Get the AWS Lambda handler of the concurrent handler.
+//
+// Useful if you need to call AWS lambda.Start(...) directly,
+// not required in most cases.
+func (ch ConcurrentHandler) ToLambdaHandler() func(ctx context.Context, event events.SNSEvent) error {
+	return func(ctx context.Context, event events.SNSEvent) error {
+		errs := ch.process(ctx, event)
+		return lambdah.NewBatchError(errs)
+	}
+}
+
+func (ch ConcurrentHandler) process(ctx context.Context, event events.SNSEvent) []error {
+	return ch.handler.Process(ctx, len(event.Records), func(i int) *Context {
+		return &Context{
+			Context:     ctx,
+			EventRecord: event.Records[i],
+		}
+	})
+}
+
+// clone returns a copy of the context which shares no maps or slices with c, so
+// that a handler left running in the background after a timeout does not race
+// with earlier middleware.
+func (c *Context) clone() Context {
+	tc := *c
+	tc.EventRecord.SNS.MessageAttributes = clone.Map(c.EventRecord.SNS.MessageAttributes)
+	return tc
+}
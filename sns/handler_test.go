@@ -3,8 +3,11 @@ package sns
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"testing"
 
+	"github.com/webbgeorge/lambdah"
+
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/stretchr/testify/assert"
 )
@@ -93,6 +96,77 @@ func TestSNSHandler_Error(t *testing.T) {
 	assert.Equal(t, 1, callCount)
 }
 
+func TestSNSConcurrentHandler_Success(t *testing.T) {
+	var callCount int32
+	h := func(c *Context) error {
+		atomic.AddInt32(&callCount, 1)
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).Concurrent(2).ToLambdaHandler()
+	err := awsHandler(
+		context.Background(),
+		events.SNSEvent{Records: []events.SNSEventRecord{
+			{SNS: events.SNSEntity{MessageID: "message-1"}},
+			{SNS: events.SNSEntity{MessageID: "message-2"}},
+			{SNS: events.SNSEntity{MessageID: "message-3"}},
+		}},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), callCount)
+}
+
+func TestSNSConcurrentHandler_Error(t *testing.T) {
+	var callCount int32
+	h := func(c *Context) error {
+		atomic.AddInt32(&callCount, 1)
+		return assert.AnError
+	}
+
+	awsHandler := HandlerFunc(h).Concurrent(2).ToLambdaHandler()
+	err := awsHandler(
+		context.Background(),
+		events.SNSEvent{Records: []events.SNSEventRecord{
+			{SNS: events.SNSEntity{MessageID: "message-1"}},
+			{SNS: events.SNSEntity{MessageID: "message-2"}},
+			{SNS: events.SNSEntity{MessageID: "message-3"}},
+		}},
+	)
+
+	var batchErr lambdah.BatchError
+	assert.True(t, errors.As(err, &batchErr))
+	assert.Len(t, batchErr.Errors, 3)
+	assert.True(t, errors.Is(err, assert.AnError))
+	assert.Equal(t, int32(3), callCount)
+}
+
+func TestSNSConcurrentHandler_DeadlineExceeded(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var callCount int32
+	h := func(c *Context) error {
+		atomic.AddInt32(&callCount, 1)
+		cancel()
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).Concurrent(1).ToLambdaHandler()
+	err := awsHandler(
+		ctx,
+		events.SNSEvent{Records: []events.SNSEventRecord{
+			{SNS: events.SNSEntity{MessageID: "message-1"}},
+			{SNS: events.SNSEntity{MessageID: "message-2"}},
+			{SNS: events.SNSEntity{MessageID: "message-3"}},
+		}},
+	)
+
+	var batchErr lambdah.BatchError
+	assert.True(t, errors.As(err, &batchErr))
+	assert.Len(t, batchErr.Errors, 2)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, int32(1), callCount)
+}
+
 func TestSNSContext_Bind_Success(t *testing.T) {
 	c := &Context{
 		EventRecord: events.SNSEventRecord{
@@ -1,9 +1,18 @@
 package sns
 
 import (
+	"context"
 	"io"
+	"sync/atomic"
+	"time"
 
+	"github.com/webbgeorge/lambdah"
+	"github.com/webbgeorge/lambdah/internal/handlerlog"
+	"github.com/webbgeorge/lambdah/internal/timeout"
 	"github.com/webbgeorge/lambdah/log"
+	"github.com/webbgeorge/lambdah/metrics"
+
+	"github.com/aws/aws-lambda-go/events"
 )
 
 type Middleware func(h HandlerFunc) HandlerFunc
@@ -16,7 +25,11 @@ type Middleware func(h HandlerFunc) HandlerFunc
 // First looks for a Correlation ID provided in the SNS message attribute `correlation_id`.
 // If not present a new correlation ID will be created.
 //
-// If used with the LoggerMiddleware, Correlation IDs are logged in each message.
+// SNS has no X-Ray attribute, so the trace context is read from the W3C
+// `traceparent` and `tracestate` message attributes, and attached to c.Context.
+//
+// If used with the LoggerMiddleware, Correlation IDs and trace IDs are logged in
+// each message.
 // To ensure logs have correlation ID field, this middleware should be called first.
 func CorrelationIDMiddleware() Middleware {
 	return func(h HandlerFunc) HandlerFunc {
@@ -27,6 +40,15 @@ func CorrelationIDMiddleware() Middleware {
 			}
 
 			c.Context = log.WithCorrelationID(c.Context, cid)
+
+			tc, ok := log.ExtractTraceContext(
+				"",
+				messageAttribute(c.EventRecord.SNS, log.TraceparentHeader),
+				messageAttribute(c.EventRecord.SNS, log.TracestateHeader),
+			)
+			if ok {
+				c.Context = log.WithTraceContext(c.Context, tc)
+			}
 			return h(c)
 		}
 	}
@@ -44,11 +66,9 @@ func CorrelationIDMiddleware() Middleware {
 func LoggerMiddleware(w io.Writer, fields map[string]string) Middleware {
 	return func(h HandlerFunc) HandlerFunc {
 		return func(c *Context) error {
-			fields["handler_type"] = "sns"
-			fields["correlation_id"] = log.CorrelationIDFromContext(c.Context)
-			fields["topic_arn"] = c.EventRecord.SNS.TopicArn
-
-			logger := log.NewLogger(w, fields)
+			logger := handlerlog.New(c.Context, w, "sns", fields, map[string]string{
+				"topic_arn": c.EventRecord.SNS.TopicArn,
+			})
 			c.Context = log.WithLogger(c.Context, logger)
 			logger.Info().Msgf("Processing SNS event for topic '%s'", c.EventRecord.SNS.TopicArn)
 			err := h(c)
@@ -60,3 +80,105 @@ func LoggerMiddleware(w io.Writer, fields map[string]string) Middleware {
 		}
 	}
 }
+
+// Middleware to recover from panics in the handler, which would otherwise crash
+// the Lambda runtime process, see lambdah.RecoverAndLog.
+//
+// This middleware should be called after the logger middleware, so that the panic
+// is logged. The recovered error is returned from the handler, so the event is
+// treated as failed and may be retried.
+func RecoverMiddleware() Middleware {
+	return func(h HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			return lambdah.RecoverAndLog(c.Context, func() error {
+				return h(c)
+			})
+		}
+	}
+}
+
+// Middleware to stop the handler gracefully before the Lambda deadline.
+//
+// The handler is given a c.Context which is cancelled buffer before the Lambda
+// deadline. If the handler has not returned by then, a lambdah.TimeoutError is
+// returned and the handler is left to finish in the background, without changing
+// the Context seen by earlier middleware.
+//
+// The timeout error fails the event, so it may be retried by Lambda. This
+// middleware should be called after the logger middleware.
+func TimeoutMiddleware(buffer time.Duration) Middleware {
+	return func(h HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			tc := c.clone()
+			returned, err := timeout.Run(c.Context, buffer, func(ctx context.Context) error {
+				tc.Context = ctx
+				return h(&tc)
+			})
+			if returned {
+				ctx := c.Context
+				*c = tc
+				c.Context = ctx
+			}
+			return err
+		}
+	}
+}
+
+// Middleware to record CloudWatch metrics for each record, written to w in the
+// CloudWatch Embedded Metric Format (EMF). No calls are made to the CloudWatch API,
+// CloudWatch extracts the metrics when w is the Lambda function's stdout.
+//
+// The `Invocations`, `Duration`, `Errors` and `ColdStart` metrics are recorded.
+// You can add your own metrics in your handlers/middleware by calling
+// metrics.MetricsFromContext(c.Context), which are flushed after the handler returns.
+//
+// w io.Writer                   is the metrics output, for example os.Stdout
+// namespace string              is the CloudWatch metric namespace
+// dimensions map[string]string  is a list of dimensions to add to each metric
+//
+// To ensure logs and metrics share a correlation ID, this middleware should be
+// called after the correlation ID middleware.
+func MetricsMiddleware(w io.Writer, namespace string, dimensions map[string]string) Middleware {
+	var warm int32
+	return func(h HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			m := metrics.New(w, namespace)
+			for k, v := range dimensions {
+				m.AddDimension(k, v)
+			}
+			if cid := log.CorrelationIDFromContext(c.Context); cid != "" {
+				m.SetProperty("correlation_id", cid)
+			}
+			c.Context = metrics.WithMetrics(c.Context, m)
+
+			start := time.Now()
+			err := h(c)
+
+			m.RecordInvocation(time.Since(start), err, atomic.CompareAndSwapInt32(&warm, 0, 1))
+			flushErr := m.Flush()
+			if flushErr != nil {
+				logger := log.LoggerFromContext(c.Context)
+				if logger != nil {
+					logger.Error().
+						Str("error", flushErr.Error()).
+						Msg("Error flushing metrics")
+				}
+			}
+			return err
+		}
+	}
+}
+
+// messageAttribute returns the string value of the named message attribute, which
+// is either a string or an object with a `Value` field.
+func messageAttribute(entity events.SNSEntity, name string) string {
+	switch attr := entity.MessageAttributes[name].(type) {
+	case string:
+		return attr
+	case map[string]interface{}:
+		value, _ := attr["Value"].(string)
+		return value
+	default:
+		return ""
+	}
+}
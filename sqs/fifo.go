@@ -0,0 +1,92 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+
+	"github.com/webbgeorge/lambdah/internal/batch"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ErrPreviousMessageInGroupFailed is the error given to messages in a FIFO message
+// group which are not processed because an earlier message in the same group failed.
+var ErrPreviousMessageInGroupFailed = errors.New("previous message in message group failed")
+
+// Process the messages of each event concurrently while preserving the ordering
+// of FIFO queues, using a pool of at most concurrency workers.
+//
+// Messages are split into groups by their `MessageGroupId` attribute. Groups are
+// processed concurrently, but messages within a group are processed one at a time,
+// in order. If a message fails, it and all later messages in the same group fail,
+// the later messages with ErrPreviousMessageInGroupFailed, so that ordering is kept
+// when they are redelivered. Messages without a group ID are processed independently.
+func (hf HandlerFunc) ConcurrentFIFO(concurrency int) ConcurrentHandler {
+	return ConcurrentHandler{
+		handler: batch.Handler[Context]{HandlerFunc: hf, Concurrency: concurrency},
+		fifo:    true,
+	}
+}
+
+func (ch ConcurrentHandler) processFIFO(ctx context.Context, event events.SQSEvent) []error {
+	errs := make([]error, len(event.Records))
+	groups := messageGroups(event)
+	started := make([]bool, len(groups))
+
+	groupErrs := batch.Process(ctx, ch.handler.Concurrency, len(groups), func(g int) error {
+		started[g] = true
+		var groupErr error
+		for _, i := range groups[g] {
+			if groupErr != nil {
+				errs[i] = ErrPreviousMessageInGroupFailed
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				groupErr = err
+				errs[i] = err
+				continue
+			}
+			c := &Context{
+				Context: ctx,
+				Message: event.Records[i],
+			}
+			groupErr = ch.handler.HandlerFunc(c)
+			errs[i] = groupErr
+		}
+		return groupErr
+	})
+
+	// groups which were not started before the context was done
+	for g, group := range groups {
+		if started[g] {
+			continue
+		}
+		for _, i := range group {
+			errs[i] = groupErrs[g]
+		}
+	}
+
+	return errs
+}
+
+// messageGroups splits the indexes of the messages in the event by message group,
+// keeping the order of the messages within each group.
+func messageGroups(event events.SQSEvent) [][]int {
+	groups := make([][]int, 0)
+	groupIndexes := make(map[string]int)
+	for i, message := range event.Records {
+		groupID := message.Attributes["MessageGroupId"]
+		if groupID == "" {
+			groups = append(groups, []int{i})
+			continue
+		}
+		g, ok := groupIndexes[groupID]
+		if !ok {
+			g = len(groups)
+			groupIndexes[groupID] = g
+			groups = append(groups, []int{})
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
@@ -0,0 +1,155 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQSConcurrentFIFOHandler_PreservesGroupOrder(t *testing.T) {
+	mu := sync.Mutex{}
+	callOrder := make(map[string][]string)
+	h := func(c *Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		groupID := c.Message.Attributes["MessageGroupId"]
+		callOrder[groupID] = append(callOrder[groupID], c.Message.MessageId)
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).ConcurrentFIFO(2).ToLambdaHandler()
+	err := awsHandler(
+		context.Background(),
+		events.SQSEvent{Records: []events.SQSMessage{
+			fifoMessage("a-1", "a"),
+			fifoMessage("b-1", "b"),
+			fifoMessage("a-2", "a"),
+			fifoMessage("c-1", "c"),
+			fifoMessage("b-2", "b"),
+			fifoMessage("a-3", "a"),
+		}},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]string{
+		"a": {"a-1", "a-2", "a-3"},
+		"b": {"b-1", "b-2"},
+		"c": {"c-1"},
+	}, callOrder)
+}
+
+func TestSQSConcurrentFIFOHandler_FailureSkipsRestOfGroup(t *testing.T) {
+	mu := sync.Mutex{}
+	called := make([]string, 0)
+	h := func(c *Context) error {
+		mu.Lock()
+		called = append(called, c.Message.MessageId)
+		mu.Unlock()
+		if c.Message.MessageId == "a-2" {
+			return assert.AnError
+		}
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).ConcurrentFIFO(2).ToLambdaPartialBatchHandler()
+	res, err := awsHandler(
+		context.Background(),
+		events.SQSEvent{Records: []events.SQSMessage{
+			fifoMessage("a-1", "a"),
+			fifoMessage("b-1", "b"),
+			fifoMessage("a-2", "a"),
+			fifoMessage("b-2", "b"),
+			fifoMessage("a-3", "a"),
+			fifoMessage("a-4", "a"),
+		}},
+	)
+
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"a-1", "b-1", "a-2", "b-2"}, called)
+	assert.Equal(t, []events.SQSBatchItemFailure{
+		{ItemIdentifier: "a-2"},
+		{ItemIdentifier: "a-3"},
+		{ItemIdentifier: "a-4"},
+	}, res.BatchItemFailures)
+}
+
+func TestSQSConcurrentFIFOHandler_Error(t *testing.T) {
+	h := func(c *Context) error {
+		return assert.AnError
+	}
+
+	awsHandler := HandlerFunc(h).ConcurrentFIFO(2).ToLambdaHandler()
+	err := awsHandler(
+		context.Background(),
+		events.SQSEvent{Records: []events.SQSMessage{
+			fifoMessage("a-1", "a"),
+			fifoMessage("a-2", "a"),
+		}},
+	)
+
+	assert.True(t, errors.Is(err, assert.AnError))
+	assert.True(t, errors.Is(err, ErrPreviousMessageInGroupFailed))
+}
+
+func TestSQSConcurrentFIFOHandler_DeadlineExceeded(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := func(c *Context) error {
+		cancel()
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).ConcurrentFIFO(1).ToLambdaPartialBatchHandler()
+	res, err := awsHandler(
+		ctx,
+		events.SQSEvent{Records: []events.SQSMessage{
+			fifoMessage("a-1", "a"),
+			fifoMessage("a-2", "a"),
+			fifoMessage("b-1", "b"),
+		}},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []events.SQSBatchItemFailure{
+		{ItemIdentifier: "a-2"},
+		{ItemIdentifier: "b-1"},
+	}, res.BatchItemFailures)
+}
+
+func TestSQSPartialBatchHandler_FIFOFailureSkipsRestOfGroup(t *testing.T) {
+	called := make([]string, 0)
+	h := func(c *Context) error {
+		called = append(called, c.Message.MessageId)
+		if c.Message.MessageId == "a-1" {
+			return assert.AnError
+		}
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).ToLambdaPartialBatchHandler()
+	res, err := awsHandler(
+		context.Background(),
+		events.SQSEvent{Records: []events.SQSMessage{
+			fifoMessage("a-1", "a"),
+			fifoMessage("b-1", "b"),
+			fifoMessage("a-2", "a"),
+		}},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a-1", "b-1"}, called)
+	assert.Equal(t, []events.SQSBatchItemFailure{
+		{ItemIdentifier: "a-1"},
+		{ItemIdentifier: "a-2"},
+	}, res.BatchItemFailures)
+}
+
+func fifoMessage(messageID string, groupID string) events.SQSMessage {
+	return events.SQSMessage{
+		MessageId:  messageID,
+		Attributes: map[string]string{"MessageGroupId": groupID},
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 
 	"github.com/webbgeorge/lambdah"
+	"github.com/webbgeorge/lambdah/internal/batch"
+	"github.com/webbgeorge/lambdah/internal/clone"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -21,11 +23,7 @@ func (c *Context) Bind(v interface{}) error {
 		return err
 	}
 
-	if validatable, ok := v.(lambdah.Validatable); ok {
-		return validatable.Validate()
-	}
-
-	return nil
+	return lambdah.Validate(v)
 }
 
 type HandlerFunc func(c *Context) error
@@ -64,3 +62,135 @@ func (hf HandlerFunc) ToLambdaHandler() func(ctx context.Context, event events.S
 		return nil
 	}
 }
+
+// Start the handler in partial batch response mode, see ToLambdaPartialBatchHandler.
+//
+// The Lambda event source mapping must have `ReportBatchItemFailures` enabled in
+// its FunctionResponseTypes for SQS to act on the returned failures.
+func (hf HandlerFunc) StartPartialBatch() {
+	lambda.Start(hf.ToLambdaPartialBatchHandler())
+}
+
+// Get the AWS Lambda handler of the handler func in partial batch response mode.
+//
+// Unlike ToLambdaHandler, every message in the batch is processed, even if an
+// earlier message fails. The message IDs of failed messages are returned as
+// batch item failures, so that only those messages are returned to the queue.
+//
+// For FIFO queues, once a message fails, later messages in the same message group
+// are not processed and are also returned as failures, to keep the group's ordering.
+func (hf HandlerFunc) ToLambdaPartialBatchHandler() func(
+	ctx context.Context,
+	event events.SQSEvent,
+) (events.SQSEventResponse, error) {
+	return func(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+		errs := make([]error, len(event.Records))
+		failedGroups := make(map[string]bool)
+		for i, message := range event.Records {
+			groupID := message.Attributes["MessageGroupId"]
+			if groupID != "" && failedGroups[groupID] {
+				errs[i] = ErrPreviousMessageInGroupFailed
+				continue
+			}
+			c := &Context{
+				Context: ctx,
+				Message: message,
+			}
+			errs[i] = hf(c)
+			if errs[i] != nil && groupID != "" {
+				failedGroups[groupID] = true
+			}
+		}
+		return batchItemFailures(event, errs), nil
+	}
+}
+
+// ConcurrentHandler processes the records of each event concurrently, see
+// HandlerFunc.Concurrent and HandlerFunc.ConcurrentFIFO.
+type ConcurrentHandler struct {
+	handler batch.Handler[Context]
+	fifo    bool
+}
+
+// Process the records of each event concurrently, using a pool of at most
+// concurrency workers. Each record is given its own *Context.
+//
+// All records are processed even if some fail, and the errors are returned
+// together as a lambdah.BatchError. If the Lambda deadline is reached, no further
+// records are started and each remaining record fails with the context's error.
+func (hf HandlerFunc) Concurrent(concurrency int) ConcurrentHandler {
+	return ConcurrentHandler{
+		handler: batch.Handler[Context]{HandlerFunc: hf, Concurrency: concurrency},
+	}
+}
+
+func (ch ConcurrentHandler) Start() {
+	lambda.Start(ch.ToLambdaHandler())
+}
+
+// Get the AWS Lambda handler of the concurrent handler.
+//
+// Useful if you need to call AWS lambda.Start(...) directly,
+// not required in most cases.
+func (ch ConcurrentHandler) ToLambdaHandler() func(ctx context.Context, event events.SQSEvent) error {
+	return func(ctx context.Context, event events.SQSEvent) error {
+		errs := ch.process(ctx, event)
+		return lambdah.NewBatchError(errs)
+	}
+}
+
+func (ch ConcurrentHandler) process(ctx context.Context, event events.SQSEvent) []error {
+	if ch.fifo {
+		return ch.processFIFO(ctx, event)
+	}
+	return ch.handler.Process(ctx, len(event.Records), func(i int) *Context {
+		return &Context{
+			Context: ctx,
+			Message: event.Records[i],
+		}
+	})
+}
+
+// Start the concurrent handler in partial batch response mode, see
+// HandlerFunc.StartPartialBatch.
+func (ch ConcurrentHandler) StartPartialBatch() {
+	lambda.Start(ch.ToLambdaPartialBatchHandler())
+}
+
+// Get the AWS Lambda handler of the concurrent handler in partial batch response mode.
+//
+// The message IDs of failed messages, including any not started before the Lambda
+// deadline, are returned as batch item failures.
+func (ch ConcurrentHandler) ToLambdaPartialBatchHandler() func(
+	ctx context.Context,
+	event events.SQSEvent,
+) (events.SQSEventResponse, error) {
+	return func(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+		errs := ch.process(ctx, event)
+		return batchItemFailures(event, errs), nil
+	}
+}
+
+func batchItemFailures(event events.SQSEvent, errs []error) events.SQSEventResponse {
+	res := events.SQSEventResponse{
+		BatchItemFailures: make([]events.SQSBatchItemFailure, 0),
+	}
+	for i, err := range errs {
+		if err != nil {
+			res.BatchItemFailures = append(res.BatchItemFailures, events.SQSBatchItemFailure{
+				ItemIdentifier: event.Records[i].MessageId,
+			})
+		}
+	}
+	return res
+}
+
+// clone returns a copy of the context which shares no maps or slices with c, so
+// that a handler left running in the background after a timeout does not race
+// with earlier middleware.
+func (c *Context) clone() Context {
+	tc := *c
+	tc.Message.Attributes = clone.Map(c.Message.Attributes)
+	tc.Message.MessageAttributes = clone.Map(c.Message.MessageAttributes)
+	return tc
+}
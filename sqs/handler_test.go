@@ -3,7 +3,11 @@ package sqs
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/webbgeorge/lambdah"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/stretchr/testify/assert"
@@ -74,6 +78,177 @@ func TestSQSHandler_Error(t *testing.T) {
 	assert.Equal(t, 1, callCount)
 }
 
+func TestSQSConcurrentHandler_Success(t *testing.T) {
+	var callCount int32
+	h := func(c *Context) error {
+		atomic.AddInt32(&callCount, 1)
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).Concurrent(2).ToLambdaHandler()
+	err := awsHandler(
+		context.Background(),
+		events.SQSEvent{Records: []events.SQSMessage{
+			{MessageId: "message-1"},
+			{MessageId: "message-2"},
+			{MessageId: "message-3"},
+		}},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), callCount)
+}
+
+func TestSQSConcurrentHandler_Error(t *testing.T) {
+	var callCount int32
+	h := func(c *Context) error {
+		atomic.AddInt32(&callCount, 1)
+		return assert.AnError
+	}
+
+	awsHandler := HandlerFunc(h).Concurrent(2).ToLambdaHandler()
+	err := awsHandler(
+		context.Background(),
+		events.SQSEvent{Records: []events.SQSMessage{
+			{MessageId: "message-1"},
+			{MessageId: "message-2"},
+			{MessageId: "message-3"},
+		}},
+	)
+
+	var batchErr lambdah.BatchError
+	assert.True(t, errors.As(err, &batchErr))
+	assert.Len(t, batchErr.Errors, 3)
+	assert.True(t, errors.Is(err, assert.AnError))
+	assert.Equal(t, int32(3), callCount)
+}
+
+func TestSQSConcurrentHandler_DeadlineExceeded(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var callCount int32
+	h := func(c *Context) error {
+		atomic.AddInt32(&callCount, 1)
+		cancel()
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).Concurrent(1).ToLambdaHandler()
+	err := awsHandler(
+		ctx,
+		events.SQSEvent{Records: []events.SQSMessage{
+			{MessageId: "message-1"},
+			{MessageId: "message-2"},
+			{MessageId: "message-3"},
+		}},
+	)
+
+	var batchErr lambdah.BatchError
+	assert.True(t, errors.As(err, &batchErr))
+	assert.Len(t, batchErr.Errors, 2)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, int32(1), callCount)
+}
+
+func TestSQSPartialBatchHandler_AllSuccess(t *testing.T) {
+	callCount := 0
+	h := func(c *Context) error {
+		callCount++
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).ToLambdaPartialBatchHandler()
+	res, err := awsHandler(
+		context.Background(),
+		events.SQSEvent{Records: []events.SQSMessage{
+			{MessageId: "message-1", Body: "test message 1"},
+			{MessageId: "message-2", Body: "test message 2"},
+		}},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, callCount)
+	assert.Empty(t, res.BatchItemFailures)
+}
+
+func TestSQSPartialBatchHandler_MixedSuccessAndFailure(t *testing.T) {
+	callCount := 0
+	h := func(c *Context) error {
+		callCount++
+		if c.Message.Body == "fail" {
+			return assert.AnError
+		}
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).ToLambdaPartialBatchHandler()
+	res, err := awsHandler(
+		context.Background(),
+		events.SQSEvent{Records: []events.SQSMessage{
+			{MessageId: "message-1", Body: "fail"},
+			{MessageId: "message-2", Body: "succeed"},
+			{MessageId: "message-3", Body: "fail"},
+			{MessageId: "message-4", Body: "succeed"},
+		}},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 4, callCount)
+	assert.Equal(t, []events.SQSBatchItemFailure{
+		{ItemIdentifier: "message-1"},
+		{ItemIdentifier: "message-3"},
+	}, res.BatchItemFailures)
+}
+
+func TestSQSPartialBatchHandler_AllFailure(t *testing.T) {
+	h := func(c *Context) error {
+		return assert.AnError
+	}
+
+	awsHandler := HandlerFunc(h).ToLambdaPartialBatchHandler()
+	res, err := awsHandler(
+		context.Background(),
+		events.SQSEvent{Records: []events.SQSMessage{
+			{MessageId: "message-1", Body: "test message 1"},
+			{MessageId: "message-2", Body: "test message 2"},
+		}},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []events.SQSBatchItemFailure{
+		{ItemIdentifier: "message-1"},
+		{ItemIdentifier: "message-2"},
+	}, res.BatchItemFailures)
+}
+
+func TestSQSConcurrentPartialBatchHandler_MixedSuccessAndFailure(t *testing.T) {
+	var callCount int32
+	h := func(c *Context) error {
+		atomic.AddInt32(&callCount, 1)
+		if c.Message.Body == "fail" {
+			return assert.AnError
+		}
+		return nil
+	}
+
+	awsHandler := HandlerFunc(h).Concurrent(2).ToLambdaPartialBatchHandler()
+	res, err := awsHandler(
+		context.Background(),
+		events.SQSEvent{Records: []events.SQSMessage{
+			{MessageId: "message-1", Body: "fail"},
+			{MessageId: "message-2", Body: "succeed"},
+			{MessageId: "message-3", Body: "fail"},
+			{MessageId: "message-4", Body: "succeed"},
+		}},
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int32(4), callCount)
+	assert.Equal(t, []events.SQSBatchItemFailure{
+		{ItemIdentifier: "message-1"},
+		{ItemIdentifier: "message-3"},
+	}, res.BatchItemFailures)
+}
+
 func TestSQSContext_Bind_Success(t *testing.T) {
 	c := &Context{
 		Message: events.SQSMessage{Body: `{"message": "hello"}`},
@@ -145,3 +320,24 @@ func (d *messageData) Validate() error {
 	}
 	return nil
 }
+
+func TestHandlerFunc_ToLambdaPartialBatchHandler_Timeout(t *testing.T) {
+	h := HandlerFunc(func(c *Context) error {
+		if c.Message.MessageId == "slow" {
+			<-c.Context.Done()
+		}
+		return nil
+	}).Middleware(TimeoutMiddleware(150 * time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	res, err := h.ToLambdaPartialBatchHandler()(ctx, events.SQSEvent{
+		Records: []events.SQSMessage{
+			{MessageId: "fast"},
+			{MessageId: "slow"},
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []events.SQSBatchItemFailure{{ItemIdentifier: "slow"}}, res.BatchItemFailures)
+}
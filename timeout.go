@@ -0,0 +1,62 @@
+package lambdah
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TimeoutError is returned by TimeoutMiddleware when a handler has not returned
+// by the buffer before the Lambda deadline. Deadline is when the handler timed out.
+type TimeoutError struct {
+	Deadline time.Time
+}
+
+func (err TimeoutError) Error() string {
+	return fmt.Sprintf("handler timed out at %s, before the Lambda deadline", err.Deadline.Format(time.RFC3339Nano))
+}
+
+// Is reports that a TimeoutError is a context.DeadlineExceeded error.
+func (err TimeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+// WithTimeout calls fn with a context which is cancelled buffer before the deadline
+// of ctx, which for Lambda handlers is the Lambda deadline. If fn has not returned
+// by then a TimeoutError is returned, and fn is left to finish in the background.
+// If ctx has no deadline fn is called with ctx.
+//
+// fn is called in a new goroutine, so panics in fn are returned as a PanicError
+// rather than crashing the process. It is used by the TimeoutMiddleware of each
+// handler package.
+func WithTimeout(ctx context.Context, buffer time.Duration, fn func(ctx context.Context) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return fn(ctx)
+	}
+
+	timeoutCtx, cancel := context.WithDeadline(ctx, deadline.Add(-buffer))
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- Recover(func() error {
+			return fn(timeoutCtx)
+		})
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timeoutCtx.Done():
+		if errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+			timeoutAt, _ := timeoutCtx.Deadline()
+			return TimeoutError{Deadline: timeoutAt}
+		}
+		return timeoutCtx.Err()
+	}
+}
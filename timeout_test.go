@@ -0,0 +1,67 @@
+package lambdah
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTimeout_ReturnsBeforeDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := WithTimeout(ctx, 100*time.Millisecond, func(ctx context.Context) error {
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(900*time.Millisecond), deadline, 50*time.Millisecond)
+		return assert.AnError
+	})
+
+	assert.Equal(t, assert.AnError, err)
+}
+
+func TestWithTimeout_TimesOut(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	lambdaDeadline, _ := ctx.Deadline()
+
+	handlerCtxDone := make(chan struct{})
+	err := WithTimeout(ctx, 150*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		close(handlerCtxDone)
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	})
+
+	var timeoutErr TimeoutError
+	assert.True(t, errors.As(err, &timeoutErr))
+	assert.Equal(t, lambdaDeadline.Add(-150*time.Millisecond), timeoutErr.Deadline)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, time.Now().Before(lambdaDeadline))
+	<-handlerCtxDone
+}
+
+func TestWithTimeout_NoDeadline(t *testing.T) {
+	ctx := context.Background()
+
+	err := WithTimeout(ctx, time.Second, func(handlerCtx context.Context) error {
+		assert.Equal(t, ctx, handlerCtx)
+		return nil
+	})
+
+	assert.Nil(t, err)
+}
+
+func TestWithTimeout_Panic(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := WithTimeout(ctx, 100*time.Millisecond, func(ctx context.Context) error {
+		panic("oh no")
+	})
+
+	assert.IsType(t, PanicError{}, err)
+}
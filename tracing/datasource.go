@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"strings"
+
+	"github.com/webbgeorge/lambdah/dynamodb"
+	"github.com/webbgeorge/lambdah/s3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// S3Middleware starts a span for each S3 event record, with the FaaS datasource
+// semantic conventions.
+//
+// Use with WrapHandler to make the spans children of an invocation span.
+func S3Middleware(opts ...Option) s3.Middleware {
+	cfg := newConfig(opts)
+	return func(h s3.HandlerFunc) s3.HandlerFunc {
+		return func(c *s3.Context) error {
+			attrs := append(invocationAttributes(c.Context, TriggerDatasource),
+				attribute.String("faas.document.collection", c.EventRecord.S3.Bucket.Name),
+				attribute.String("faas.document.operation", c.EventRecord.EventName),
+				attribute.String("faas.document.name", c.EventRecord.S3.Object.Key),
+				attribute.String("faas.document.time", c.EventRecord.EventTime.UTC().Format("2006-01-02T15:04:05.000Z07:00")),
+			)
+
+			ctx, span := cfg.start(envParent(c.Context), c.EventRecord.S3.Bucket.Name+" "+c.EventRecord.EventName, trace.SpanKindConsumer, attrs, nil)
+			c.Context = ctx
+
+			err := h(c)
+			end(span, err)
+			return err
+		}
+	}
+}
+
+// DynamoDBMiddleware starts a span for each DynamoDB stream record, with the FaaS
+// datasource semantic conventions.
+//
+// Use with WrapHandler to make the spans children of an invocation span.
+func DynamoDBMiddleware(opts ...Option) dynamodb.Middleware {
+	cfg := newConfig(opts)
+	return func(h dynamodb.HandlerFunc) dynamodb.HandlerFunc {
+		return func(c *dynamodb.Context) error {
+			table := tableName(c.EventRecord.EventSourceArn)
+			attrs := append(invocationAttributes(c.Context, TriggerDatasource),
+				attribute.String("faas.document.collection", table),
+				attribute.String("faas.document.operation", c.EventRecord.EventName),
+				attribute.String("aws.dynamodb.table_names", table),
+			)
+			if !c.EventRecord.Change.ApproximateCreationDateTime.IsZero() {
+				attrs = append(attrs, attribute.String(
+					"faas.document.time",
+					c.EventRecord.Change.ApproximateCreationDateTime.UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+				))
+			}
+
+			ctx, span := cfg.start(envParent(c.Context), table+" "+c.EventRecord.EventName, trace.SpanKindConsumer, attrs, nil)
+			c.Context = ctx
+
+			err := h(c)
+			end(span, err)
+			return err
+		}
+	}
+}
+
+// tableName returns the table name from a DynamoDB stream ARN, for example
+// `arn:aws:dynamodb:us-east-1:123456789012:table/books/stream/2020-01-01T00:00:00.000`
+func tableName(streamARN string) string {
+	i := strings.Index(streamARN, ":table/")
+	if i < 0 {
+		return streamARN
+	}
+	return strings.SplitN(streamARN[i+len(":table/"):], "/", 2)[0]
+}
@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"github.com/webbgeorge/lambdah/cloudwatch_events"
+	"github.com/webbgeorge/lambdah/generic"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CloudWatchEventsMiddleware starts a span for each CloudWatch event. Scheduled
+// events have the `timer` trigger, and other events the `pubsub` trigger.
+func CloudWatchEventsMiddleware(opts ...Option) cloudwatch_events.Middleware {
+	cfg := newConfig(opts)
+	return func(h cloudwatch_events.HandlerFunc) cloudwatch_events.HandlerFunc {
+		return func(c *cloudwatch_events.Context) error {
+			trigger := TriggerPubSub
+			if c.Event.DetailType == "Scheduled Event" {
+				trigger = TriggerTimer
+			}
+			attrs := append(invocationAttributes(c.Context, trigger),
+				attribute.String("aws.eventbridge.source", c.Event.Source),
+				attribute.String("aws.eventbridge.detail_type", c.Event.DetailType),
+				attribute.String("aws.eventbridge.event_id", c.Event.ID),
+			)
+			if trigger == TriggerTimer {
+				attrs = append(attrs, attribute.String("faas.time", c.Event.Time.UTC().Format("2006-01-02T15:04:05Z07:00")))
+			}
+
+			ctx, span := cfg.start(envParent(c.Context), invocationSpanName(c.Event.DetailType), trace.SpanKindServer, attrs, nil)
+			c.Context = ctx
+
+			err := h(c)
+			end(span, err)
+			return err
+		}
+	}
+}
+
+// GenericMiddleware starts a span for each invocation of a generic handler, with
+// the `other` trigger.
+func GenericMiddleware(opts ...Option) generic.Middleware {
+	cfg := newConfig(opts)
+	return func(h generic.HandlerFunc) generic.HandlerFunc {
+		return func(c *generic.Context) error {
+			attrs := invocationAttributes(c.Context, TriggerOther)
+
+			ctx, span := cfg.start(envParent(c.Context), invocationSpanName("invoke"), trace.SpanKindServer, attrs, nil)
+			c.Context = ctx
+
+			err := h(c)
+			end(span, err)
+			return err
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/webbgeorge/lambdah/alb"
+	"github.com/webbgeorge/lambdah/api_gateway_proxy"
+	"github.com/webbgeorge/lambdah/api_gateway_v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type httpRequest struct {
+	method    string
+	route     string
+	path      string
+	query     string
+	scheme    string
+	userAgent string
+	clientIP  string
+	headers   map[string]string
+}
+
+// startHTTP starts a server span for an HTTP request, named `METHOD route`.
+func (cfg config) startHTTP(ctx context.Context, r httpRequest) (context.Context, trace.Span) {
+	ctx = cfg.extract(ctx, r.headers, r.headers["x-amzn-trace-id"])
+
+	attrs := append(invocationAttributes(ctx, TriggerHTTP),
+		attribute.String("http.request.method", r.method),
+		attribute.String("url.path", r.path),
+	)
+	if r.route != "" {
+		attrs = append(attrs, attribute.String("http.route", r.route))
+	}
+	if r.query != "" {
+		attrs = append(attrs, attribute.String("url.query", r.query))
+	}
+	if r.scheme != "" {
+		attrs = append(attrs, attribute.String("url.scheme", r.scheme))
+	}
+	if r.userAgent != "" {
+		attrs = append(attrs, attribute.String("user_agent.original", r.userAgent))
+	}
+	if r.clientIP != "" {
+		attrs = append(attrs, attribute.String("client.address", r.clientIP))
+	}
+
+	name := r.method
+	if r.route != "" {
+		name += " " + r.route
+	}
+	return cfg.start(ctx, name, trace.SpanKindServer, attrs, nil)
+}
+
+// endHTTP records the response status code and ends the span. 5xx responses set
+// the span status to error.
+func endHTTP(span trace.Span, statusCode int, err error) {
+	span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+	if statusCode >= 500 {
+		span.SetStatus(codes.Error, "")
+	}
+	end(span, err)
+}
+
+func queryString(params map[string]string) string {
+	pairs := make([]string, 0, len(params))
+	for k, v := range params {
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, "&")
+}
+
+// APIGatewayProxyMiddleware starts a span for each API Gateway proxy request, with
+// the HTTP semantic conventions. Incoming trace context is extracted from the
+// request headers.
+//
+// To ensure the response status code is correctly reported, this middleware
+// should be called before the error handler middleware.
+func APIGatewayProxyMiddleware(opts ...Option) api_gateway_proxy.Middleware {
+	cfg := newConfig(opts)
+	return func(h api_gateway_proxy.HandlerFunc) api_gateway_proxy.HandlerFunc {
+		return func(c *api_gateway_proxy.Context) error {
+			headers := lowerKeys(c.Request.Headers)
+			for k, vs := range c.Request.MultiValueHeaders {
+				if len(vs) > 0 {
+					headers[strings.ToLower(k)] = vs[0]
+				}
+			}
+
+			ctx, span := cfg.startHTTP(c.Context, httpRequest{
+				method:    c.Request.HTTPMethod,
+				route:     c.Request.Resource,
+				path:      c.Request.Path,
+				query:     queryString(c.Request.QueryStringParameters),
+				scheme:    headers["x-forwarded-proto"],
+				userAgent: c.Request.RequestContext.Identity.UserAgent,
+				clientIP:  c.Request.RequestContext.Identity.SourceIP,
+				headers:   headers,
+			})
+			c.Context = ctx
+
+			err := h(c)
+			endHTTP(span, c.Response.StatusCode, err)
+			return err
+		}
+	}
+}
+
+// APIGatewayV2Middleware starts a span for each API Gateway HTTP API request, with
+// the HTTP semantic conventions. Incoming trace context is extracted from the
+// request headers.
+//
+// To ensure the response status code is correctly reported, this middleware
+// should be called before the error handler middleware.
+func APIGatewayV2Middleware(opts ...Option) api_gateway_v2.Middleware {
+	cfg := newConfig(opts)
+	return func(h api_gateway_v2.HandlerFunc) api_gateway_v2.HandlerFunc {
+		return func(c *api_gateway_v2.Context) error {
+			route := c.Request.RouteKey
+			if i := strings.Index(route, " "); i >= 0 {
+				route = route[i+1:]
+			}
+			if route == "$default" {
+				route = ""
+			}
+
+			headers := lowerKeys(c.Request.Headers)
+			ctx, span := cfg.startHTTP(c.Context, httpRequest{
+				method:    c.Request.RequestContext.HTTP.Method,
+				route:     route,
+				path:      c.Request.RawPath,
+				query:     c.Request.RawQueryString,
+				scheme:    headers["x-forwarded-proto"],
+				userAgent: c.Request.RequestContext.HTTP.UserAgent,
+				clientIP:  c.Request.RequestContext.HTTP.SourceIP,
+				headers:   headers,
+			})
+			c.Context = ctx
+
+			err := h(c)
+			endHTTP(span, c.Response.StatusCode, err)
+			return err
+		}
+	}
+}
+
+// ALBMiddleware starts a span for each Application Load Balancer request, with the
+// HTTP semantic conventions. Incoming trace context is extracted from the request
+// headers.
+//
+// To ensure the response status code is correctly reported, this middleware
+// should be called before the error handler middleware.
+func ALBMiddleware(opts ...Option) alb.Middleware {
+	cfg := newConfig(opts)
+	return func(h alb.HandlerFunc) alb.HandlerFunc {
+		return func(c *alb.Context) error {
+			headers := lowerKeys(c.Request.Headers)
+			for k, vs := range c.Request.MultiValueHeaders {
+				if len(vs) > 0 {
+					headers[strings.ToLower(k)] = vs[0]
+				}
+			}
+
+			ctx, span := cfg.startHTTP(c.Context, httpRequest{
+				method:    c.Request.HTTPMethod,
+				path:      c.Request.Path,
+				query:     queryString(c.Request.QueryStringParameters),
+				scheme:    headers["x-forwarded-proto"],
+				userAgent: headers["user-agent"],
+				clientIP:  strings.TrimSpace(strings.Split(c.Header("X-Forwarded-For"), ",")[0]),
+				headers:   headers,
+			})
+			c.Context = ctx
+
+			err := h(c)
+			endHTTP(span, c.Response.StatusCode, err)
+			return err
+		}
+	}
+}
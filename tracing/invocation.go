@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WrapHandler wraps a Lambda handler to start a span for each invocation. It is
+// used with batch handlers, so that the spans of each record started by their
+// middleware are children of the invocation span, for example:
+//
+//	lambda.Start(tracing.WrapHandler(
+//		sqs.HandlerFunc(handler).Middleware(tracing.SQSMiddleware()).ToLambdaHandler(),
+//	))
+//
+// The trigger is set from the type of the event, and for batch events the number
+// of records is recorded.
+func WrapHandler[E any](handler func(ctx context.Context, event E) error, opts ...Option) func(ctx context.Context, event E) error {
+	wrapped := WrapHandlerWithResponse(func(ctx context.Context, event E) (struct{}, error) {
+		return struct{}{}, handler(ctx, event)
+	}, opts...)
+	return func(ctx context.Context, event E) error {
+		_, err := wrapped(ctx, event)
+		return err
+	}
+}
+
+// WrapHandlerWithResponse wraps a Lambda handler which returns a response, such as
+// a partial batch handler, to start a span for each invocation, see WrapHandler.
+func WrapHandlerWithResponse[E any, R any](handler func(ctx context.Context, event E) (R, error), opts ...Option) func(ctx context.Context, event E) (R, error) {
+	cfg := newConfig(opts)
+	return func(ctx context.Context, event E) (R, error) {
+		trigger, eventAttrs := eventAttributes(event)
+		attrs := append(invocationAttributes(ctx, trigger), eventAttrs...)
+
+		ctx, span := cfg.start(envParent(ctx), invocationSpanName("invoke"), trace.SpanKindServer, attrs, nil)
+		res, err := handler(ctx, event)
+
+		switch res := interface{}(res).(type) {
+		case events.SQSEventResponse:
+			span.SetAttributes(attribute.Int("lambdah.batch.item_failures", len(res.BatchItemFailures)))
+		case events.KinesisEventResponse:
+			span.SetAttributes(attribute.Int("lambdah.batch.item_failures", len(res.BatchItemFailures)))
+		case events.KinesisFirehoseResponse:
+			failures := 0
+			for _, record := range res.Records {
+				if record.Result == events.KinesisFirehoseTransformedStateProcessingFailed {
+					failures++
+				}
+			}
+			span.SetAttributes(attribute.Int("lambdah.batch.item_failures", failures))
+		}
+		end(span, err)
+		return res, err
+	}
+}
+
+func eventAttributes(event interface{}) (string, []attribute.KeyValue) {
+	switch event := event.(type) {
+	case events.SQSEvent:
+		return TriggerPubSub, []attribute.KeyValue{
+			attribute.String("messaging.system", "aws_sqs"),
+			attribute.Int("messaging.batch.message_count", len(event.Records)),
+		}
+	case events.SNSEvent:
+		return TriggerPubSub, []attribute.KeyValue{
+			attribute.String("messaging.system", "aws_sns"),
+			attribute.Int("messaging.batch.message_count", len(event.Records)),
+		}
+	case events.KinesisEvent:
+		return TriggerPubSub, []attribute.KeyValue{
+			attribute.String("messaging.system", "aws_kinesis"),
+			attribute.Int("messaging.batch.message_count", len(event.Records)),
+		}
+	case events.KinesisFirehoseEvent:
+		return TriggerPubSub, []attribute.KeyValue{
+			attribute.String("messaging.system", "aws_firehose"),
+			attribute.Int("messaging.batch.message_count", len(event.Records)),
+		}
+	case events.S3Event:
+		return TriggerDatasource, []attribute.KeyValue{
+			attribute.Int("lambdah.batch.record_count", len(event.Records)),
+		}
+	case events.DynamoDBEvent:
+		return TriggerDatasource, []attribute.KeyValue{
+			attribute.Int("lambdah.batch.record_count", len(event.Records)),
+		}
+	default:
+		return TriggerOther, nil
+	}
+}
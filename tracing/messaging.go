@@ -0,0 +1,156 @@
+package tracing
+
+import (
+	"strings"
+
+	"github.com/webbgeorge/lambdah/firehose"
+	"github.com/webbgeorge/lambdah/kinesis"
+	"github.com/webbgeorge/lambdah/log"
+	"github.com/webbgeorge/lambdah/sns"
+	"github.com/webbgeorge/lambdah/sqs"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SQSMiddleware starts a span for each SQS message, with the messaging semantic
+// conventions. The span is linked to the trace context of the producer, which is
+// extracted from the `traceparent` message attribute, or the `AWSTraceHeader`
+// system attribute.
+//
+// Use with WrapHandler to make the spans children of an invocation span.
+func SQSMiddleware(opts ...Option) sqs.Middleware {
+	cfg := newConfig(opts)
+	return func(h sqs.HandlerFunc) sqs.HandlerFunc {
+		return func(c *sqs.Context) error {
+			carrier := make(map[string]string)
+			for k, attr := range c.Message.MessageAttributes {
+				if attr.StringValue != nil {
+					carrier[k] = *attr.StringValue
+				}
+			}
+			links := cfg.link(carrier, c.Message.Attributes[log.SQSTraceAttribute])
+
+			queue := arnResource(c.Message.EventSourceARN)
+			attrs := append(invocationAttributes(c.Context, TriggerPubSub),
+				attribute.String("messaging.system", "aws_sqs"),
+				attribute.String("messaging.operation", "process"),
+				attribute.String("messaging.destination.name", queue),
+				attribute.String("messaging.message.id", c.Message.MessageId),
+			)
+			if groupID := c.Message.Attributes["MessageGroupId"]; groupID != "" {
+				attrs = append(attrs, attribute.String("messaging.aws_sqs.message_group_id", groupID))
+			}
+
+			ctx, span := cfg.start(envParent(c.Context), queue+" process", trace.SpanKindConsumer, attrs, links)
+			c.Context = ctx
+
+			err := h(c)
+			end(span, err)
+			return err
+		}
+	}
+}
+
+// SNSMiddleware starts a span for each SNS record, with the messaging semantic
+// conventions. The span is linked to the trace context of the producer, which is
+// extracted from the `traceparent` message attribute.
+//
+// Use with WrapHandler to make the spans children of an invocation span.
+func SNSMiddleware(opts ...Option) sns.Middleware {
+	cfg := newConfig(opts)
+	return func(h sns.HandlerFunc) sns.HandlerFunc {
+		return func(c *sns.Context) error {
+			carrier := make(map[string]string)
+			for k, attr := range c.EventRecord.SNS.MessageAttributes {
+				switch attr := attr.(type) {
+				case string:
+					carrier[k] = attr
+				case map[string]interface{}:
+					if value, ok := attr["Value"].(string); ok {
+						carrier[k] = value
+					}
+				}
+			}
+			links := cfg.link(carrier, "")
+
+			topic := arnResource(c.EventRecord.SNS.TopicArn)
+			attrs := append(invocationAttributes(c.Context, TriggerPubSub),
+				attribute.String("messaging.system", "aws_sns"),
+				attribute.String("messaging.operation", "process"),
+				attribute.String("messaging.destination.name", topic),
+				attribute.String("messaging.message.id", c.EventRecord.SNS.MessageID),
+			)
+
+			ctx, span := cfg.start(envParent(c.Context), topic+" process", trace.SpanKindConsumer, attrs, links)
+			c.Context = ctx
+
+			err := h(c)
+			end(span, err)
+			return err
+		}
+	}
+}
+
+// KinesisMiddleware starts a span for each Kinesis record, with the messaging
+// semantic conventions. Kinesis records have no attributes to carry the trace
+// context of the producer, so the span is not linked.
+//
+// Use with WrapHandler to make the spans children of an invocation span.
+func KinesisMiddleware(opts ...Option) kinesis.Middleware {
+	cfg := newConfig(opts)
+	return func(h kinesis.HandlerFunc) kinesis.HandlerFunc {
+		return func(c *kinesis.Context) error {
+			stream := streamName(c.EventRecord.EventSourceArn)
+			attrs := append(invocationAttributes(c.Context, TriggerPubSub),
+				attribute.String("messaging.system", "aws_kinesis"),
+				attribute.String("messaging.operation", "process"),
+				attribute.String("messaging.destination.name", stream),
+				attribute.String("messaging.destination.partition.id", c.ShardID()),
+				attribute.String("messaging.message.id", c.EventRecord.Kinesis.SequenceNumber),
+			)
+
+			ctx, span := cfg.start(envParent(c.Context), stream+" process", trace.SpanKindConsumer, attrs, nil)
+			c.Context = ctx
+
+			err := h(c)
+			end(span, err)
+			return err
+		}
+	}
+}
+
+// FirehoseMiddleware starts a span for each record of a Firehose transformation,
+// with the messaging semantic conventions. The span is not linked, as Firehose
+// records have no attributes to carry the trace context of the producer.
+//
+// Use with WrapHandlerWithResponse to make the spans children of an invocation span.
+func FirehoseMiddleware(opts ...Option) firehose.Middleware {
+	cfg := newConfig(opts)
+	return func(h firehose.HandlerFunc) firehose.HandlerFunc {
+		return func(c *firehose.Context) error {
+			deliveryStream := strings.TrimPrefix(arnResource(c.DeliveryStreamArn), "deliverystream/")
+			attrs := append(invocationAttributes(c.Context, TriggerPubSub),
+				attribute.String("messaging.system", "aws_firehose"),
+				attribute.String("messaging.operation", "process"),
+				attribute.String("messaging.destination.name", deliveryStream),
+				attribute.String("messaging.message.id", c.Record.RecordID),
+			)
+
+			ctx, span := cfg.start(envParent(c.Context), deliveryStream+" process", trace.SpanKindConsumer, attrs, nil)
+			c.Context = ctx
+
+			err := h(c)
+			if err == nil {
+				span.SetAttributes(attribute.String("lambdah.firehose.result", c.Result()))
+			}
+			end(span, err)
+			return err
+		}
+	}
+}
+
+// streamName returns the stream name from a Kinesis stream ARN, for example
+// `arn:aws:kinesis:us-east-1:123456789012:stream/orders`
+func streamName(streamARN string) string {
+	return strings.TrimPrefix(arnResource(streamARN), "stream/")
+}
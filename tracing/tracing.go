@@ -0,0 +1,208 @@
+// Package tracing provides OpenTelemetry tracing middleware for each Lambdah
+// handler type, following the OpenTelemetry FaaS semantic conventions.
+//
+// Middleware starts a span for each invocation of HTTP and event handlers, and
+// for each record of batch handlers. To also trace each invocation of a batch
+// handler, wrap its Lambda handler with WrapHandler or WrapHandlerWithResponse.
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/webbgeorge/lambdah/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/webbgeorge/lambdah/tracing"
+
+// Trigger types of the `faas.trigger` attribute
+const (
+	TriggerHTTP       = "http"
+	TriggerPubSub     = "pubsub"
+	TriggerDatasource = "datasource"
+	TriggerTimer      = "timer"
+	TriggerOther      = "other"
+)
+
+type config struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+type Option func(cfg *config)
+
+// WithTracerProvider sets the TracerProvider used to create spans. By default, the
+// global TracerProvider from otel.GetTracerProvider() is used.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(cfg *config) {
+		cfg.tracer = tp.Tracer(instrumentationName)
+	}
+}
+
+// WithPropagator sets the propagator used to extract incoming trace context from
+// headers and message attributes. By default, W3C trace context and baggage are
+// extracted. AWS X-Ray trace headers are always used if no other trace context
+// is found.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(cfg *config) {
+		cfg.propagator = propagator
+	}
+}
+
+func newConfig(opts []Option) config {
+	cfg := config{
+		tracer:     otel.GetTracerProvider().Tracer(instrumentationName),
+		propagator: propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// extract returns ctx with the remote span context found in carrier, or in
+// xrayHeader, as its parent.
+func (cfg config) extract(ctx context.Context, carrier map[string]string, xrayHeader string) context.Context {
+	ctx = cfg.propagator.Extract(ctx, propagation.MapCarrier(carrier))
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() && sc.IsRemote() {
+		return ctx
+	}
+	if sc, ok := xraySpanContext(xrayHeader); ok {
+		return trace.ContextWithRemoteSpanContext(ctx, sc)
+	}
+	return ctx
+}
+
+// link returns a link to the remote span context found in carrier, or in xrayHeader.
+func (cfg config) link(carrier map[string]string, xrayHeader string) []trace.Link {
+	sc := trace.SpanContextFromContext(cfg.extract(context.Background(), carrier, xrayHeader))
+	if !sc.IsValid() {
+		return nil
+	}
+	return []trace.Link{{SpanContext: sc}}
+}
+
+// start starts a span, and attaches its trace context to the context for logging
+// and propagation with the log package.
+func (cfg config) start(ctx context.Context, name string, kind trace.SpanKind, attrs []attribute.KeyValue, links []trace.Link) (context.Context, trace.Span) {
+	ctx, span := cfg.tracer.Start(
+		ctx,
+		name,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(attrs...),
+		trace.WithLinks(links...),
+	)
+
+	sc := span.SpanContext()
+	if sc.IsValid() {
+		tc, _ := log.TraceContextFromContext(ctx)
+		ctx = log.WithTraceContext(ctx, log.TraceContext{
+			TraceID:    sc.TraceID().String(),
+			SpanID:     sc.SpanID().String(),
+			Sampled:    sc.IsSampled(),
+			TraceState: tc.TraceState,
+		})
+	}
+	return ctx, span
+}
+
+// end records err on the span, if set, and ends the span.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func xraySpanContext(header string) (trace.SpanContext, bool) {
+	tc, ok := log.ParseXRayTraceHeader(header)
+	if !ok {
+		return trace.SpanContext{}, false
+	}
+	traceID, err := trace.TraceIDFromHex(tc.TraceID)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	spanID, err := trace.SpanIDFromHex(tc.SpanID)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	var flags trace.TraceFlags
+	if tc.Sampled {
+		flags = trace.FlagsSampled
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+		Remote:     true,
+	}), true
+}
+
+// invocationAttributes returns the FaaS attributes of the current invocation.
+func invocationAttributes(ctx context.Context, trigger string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("faas.trigger", trigger),
+		attribute.String("cloud.provider", "aws"),
+		attribute.String("cloud.platform", "aws_lambda"),
+	}
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		attrs = append(attrs, attribute.String("faas.invocation_id", lc.AwsRequestID))
+		if lc.InvokedFunctionArn != "" {
+			attrs = append(attrs, attribute.String("cloud.resource_id", lc.InvokedFunctionArn))
+		}
+	}
+	if lambdacontext.FunctionName != "" {
+		attrs = append(attrs, attribute.String("faas.name", lambdacontext.FunctionName))
+	}
+	if lambdacontext.FunctionVersion != "" {
+		attrs = append(attrs, attribute.String("faas.version", lambdacontext.FunctionVersion))
+	}
+	return attrs
+}
+
+// invocationSpanName returns the name of the Lambda function, if known.
+func invocationSpanName(fallback string) string {
+	if lambdacontext.FunctionName != "" {
+		return lambdacontext.FunctionName
+	}
+	return fallback
+}
+
+// envParent returns ctx with the X-Ray trace of the invocation from the environment
+// as its parent, if ctx has no span.
+func envParent(ctx context.Context) context.Context {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	tc, ok := log.TraceContextFromEnv()
+	if !ok {
+		return ctx
+	}
+	if sc, ok := xraySpanContext(tc.XRayTraceHeader()); ok {
+		return trace.ContextWithRemoteSpanContext(ctx, sc)
+	}
+	return ctx
+}
+
+// lowerKeys returns a copy of m with lower case keys, as propagators expect lower
+// case header names.
+func lowerKeys(m map[string]string) map[string]string {
+	lower := make(map[string]string, len(m))
+	for k, v := range m {
+		lower[strings.ToLower(k)] = v
+	}
+	return lower
+}
+
+// arnResource returns the resource name at the end of an ARN, such as a queue name.
+func arnResource(arn string) string {
+	return arn[strings.LastIndex(arn, ":")+1:]
+}
@@ -0,0 +1,268 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/webbgeorge/lambdah/api_gateway_proxy"
+	"github.com/webbgeorge/lambdah/dynamodb"
+	"github.com/webbgeorge/lambdah/firehose"
+	"github.com/webbgeorge/lambdah/kinesis"
+	"github.com/webbgeorge/lambdah/log"
+	"github.com/webbgeorge/lambdah/sqs"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestTracerProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	return tp, exporter
+}
+
+func attributeMap(attrs []attribute.KeyValue) map[string]interface{} {
+	m := make(map[string]interface{})
+	for _, attr := range attrs {
+		m[string(attr.Key)] = attr.Value.AsInterface()
+	}
+	return m
+}
+
+func TestAPIGatewayProxyMiddleware(t *testing.T) {
+	tp, exporter := newTestTracerProvider()
+	var handlerTraceContext log.TraceContext
+	h := api_gateway_proxy.HandlerFunc(func(c *api_gateway_proxy.Context) error {
+		handlerTraceContext, _ = log.TraceContextFromContext(c.Context)
+		return c.String(http.StatusOK, "ok")
+	}).Middleware(APIGatewayProxyMiddleware(WithTracerProvider(tp)))
+
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{
+		AwsRequestID:       "request-1",
+		InvokedFunctionArn: "arn:aws:lambda:eu-west-1:123456789012:function:books",
+	})
+	res, err := h.ToLambdaHandler()(ctx, events.APIGatewayProxyRequest{
+		Resource:   "/books/{bookID}",
+		Path:       "/books/123",
+		HTTPMethod: http.MethodGet,
+		Headers: map[string]string{
+			"Traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Identity: events.APIGatewayRequestIdentity{SourceIP: "1.2.3.4", UserAgent: "test-agent"},
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /books/{bookID}", span.Name)
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+	assert.Equal(t, codes.Unset, span.Status.Code)
+	attrs := attributeMap(span.Attributes)
+	assert.Equal(t, "http", attrs["faas.trigger"])
+	assert.Equal(t, "request-1", attrs["faas.invocation_id"])
+	assert.Equal(t, "arn:aws:lambda:eu-west-1:123456789012:function:books", attrs["cloud.resource_id"])
+	assert.Equal(t, "GET", attrs["http.request.method"])
+	assert.Equal(t, "/books/{bookID}", attrs["http.route"])
+	assert.Equal(t, "/books/123", attrs["url.path"])
+	assert.Equal(t, "1.2.3.4", attrs["client.address"])
+	assert.Equal(t, "test-agent", attrs["user_agent.original"])
+	assert.Equal(t, int64(200), attrs["http.response.status_code"])
+	assert.Equal(t, span.SpanContext.SpanID().String(), handlerTraceContext.SpanID)
+}
+
+func TestAPIGatewayProxyMiddleware_ServerError(t *testing.T) {
+	tp, exporter := newTestTracerProvider()
+	h := api_gateway_proxy.HandlerFunc(func(c *api_gateway_proxy.Context) error {
+		return assert.AnError
+	}).Middleware(
+		APIGatewayProxyMiddleware(WithTracerProvider(tp)),
+		api_gateway_proxy.ErrorHandlerMiddleware(),
+	)
+
+	res, err := h.ToLambdaHandler()(context.Background(), events.APIGatewayProxyRequest{
+		Path:       "/books",
+		HTTPMethod: http.MethodPost,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 500, res.StatusCode)
+	span := exporter.GetSpans()[0]
+	assert.Equal(t, "POST", span.Name)
+	assert.False(t, span.Parent.IsValid())
+	assert.Equal(t, codes.Error, span.Status.Code)
+	assert.Equal(t, int64(500), attributeMap(span.Attributes)["http.response.status_code"])
+}
+
+func TestSQSMiddleware_WithWrapHandler(t *testing.T) {
+	tp, exporter := newTestTracerProvider()
+	h := sqs.HandlerFunc(func(c *sqs.Context) error {
+		if c.Message.MessageId == "message-2" {
+			return assert.AnError
+		}
+		return nil
+	}).Middleware(SQSMiddleware(WithTracerProvider(tp)))
+
+	res, err := WrapHandlerWithResponse(h.ToLambdaPartialBatchHandler(), WithTracerProvider(tp))(
+		context.Background(),
+		events.SQSEvent{Records: []events.SQSMessage{
+			{
+				MessageId:      "message-1",
+				EventSourceARN: "arn:aws:sqs:eu-west-1:123456789012:books",
+				Attributes: map[string]string{
+					"AWSTraceHeader": "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1",
+				},
+			},
+			{
+				MessageId:      "message-2",
+				EventSourceARN: "arn:aws:sqs:eu-west-1:123456789012:books",
+				MessageAttributes: map[string]events.SQSMessageAttribute{
+					"traceparent": {StringValue: aws.String("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")},
+				},
+			},
+		}},
+	)
+
+	assert.Nil(t, err)
+	assert.Len(t, res.BatchItemFailures, 1)
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 3)
+	message1, message2, invocation := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, trace.SpanKindServer, invocation.SpanKind)
+	invocationAttrs := attributeMap(invocation.Attributes)
+	assert.Equal(t, "pubsub", invocationAttrs["faas.trigger"])
+	assert.Equal(t, int64(2), invocationAttrs["messaging.batch.message_count"])
+	assert.Equal(t, int64(1), invocationAttrs["lambdah.batch.item_failures"])
+
+	assert.Equal(t, "books process", message1.Name)
+	assert.Equal(t, trace.SpanKindConsumer, message1.SpanKind)
+	assert.Equal(t, invocation.SpanContext.SpanID(), message1.Parent.SpanID())
+	assert.Equal(t, "5759e988bd862e3fe1be46a994272793", message1.Links[0].SpanContext.TraceID().String())
+	message1Attrs := attributeMap(message1.Attributes)
+	assert.Equal(t, "aws_sqs", message1Attrs["messaging.system"])
+	assert.Equal(t, "process", message1Attrs["messaging.operation"])
+	assert.Equal(t, "books", message1Attrs["messaging.destination.name"])
+	assert.Equal(t, "message-1", message1Attrs["messaging.message.id"])
+
+	assert.Equal(t, invocation.SpanContext.SpanID(), message2.Parent.SpanID())
+	assert.Equal(t, "00f067aa0ba902b7", message2.Links[0].SpanContext.SpanID().String())
+	assert.Equal(t, codes.Error, message2.Status.Code)
+	assert.Len(t, message2.Events, 1)
+}
+
+func TestDynamoDBMiddleware(t *testing.T) {
+	tp, exporter := newTestTracerProvider()
+	h := dynamodb.HandlerFunc(func(c *dynamodb.Context) error {
+		return nil
+	}).Middleware(DynamoDBMiddleware(WithTracerProvider(tp)))
+
+	err := h.ToLambdaHandler()(context.Background(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{{
+		EventName:      "INSERT",
+		EventSourceArn: "arn:aws:dynamodb:eu-west-1:123456789012:table/books/stream/2020-01-01T00:00:00.000",
+	}}})
+
+	assert.Nil(t, err)
+	span := exporter.GetSpans()[0]
+	assert.Equal(t, "books INSERT", span.Name)
+	attrs := attributeMap(span.Attributes)
+	assert.Equal(t, "datasource", attrs["faas.trigger"])
+	assert.Equal(t, "books", attrs["faas.document.collection"])
+	assert.Equal(t, "INSERT", attrs["faas.document.operation"])
+}
+
+func TestKinesisMiddleware_WithWrapHandler(t *testing.T) {
+	tp, exporter := newTestTracerProvider()
+	h := kinesis.HandlerFunc(func(c *kinesis.Context) error {
+		return assert.AnError
+	}).Middleware(KinesisMiddleware(WithTracerProvider(tp)))
+
+	res, err := WrapHandlerWithResponse(h.ToLambdaPartialBatchHandler(), WithTracerProvider(tp))(
+		context.Background(),
+		events.KinesisEvent{Records: []events.KinesisEventRecord{{
+			EventID:        "shardId-000000000006:49590338271490256608559692538361571095921575989136588898",
+			EventSourceArn: "arn:aws:kinesis:eu-west-1:123456789012:stream/orders",
+			Kinesis: events.KinesisRecord{
+				SequenceNumber: "49590338271490256608559692538361571095921575989136588898",
+			},
+		}}},
+	)
+
+	assert.Nil(t, err)
+	assert.Len(t, res.BatchItemFailures, 1)
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	record, invocation := spans[0], spans[1]
+
+	invocationAttrs := attributeMap(invocation.Attributes)
+	assert.Equal(t, "pubsub", invocationAttrs["faas.trigger"])
+	assert.Equal(t, "aws_kinesis", invocationAttrs["messaging.system"])
+	assert.Equal(t, int64(1), invocationAttrs["lambdah.batch.item_failures"])
+
+	assert.Equal(t, "orders process", record.Name)
+	assert.Equal(t, trace.SpanKindConsumer, record.SpanKind)
+	assert.Equal(t, invocation.SpanContext.SpanID(), record.Parent.SpanID())
+	assert.Equal(t, codes.Error, record.Status.Code)
+	recordAttrs := attributeMap(record.Attributes)
+	assert.Equal(t, "aws_kinesis", recordAttrs["messaging.system"])
+	assert.Equal(t, "orders", recordAttrs["messaging.destination.name"])
+	assert.Equal(t, "shardId-000000000006", recordAttrs["messaging.destination.partition.id"])
+	assert.Equal(t, "49590338271490256608559692538361571095921575989136588898", recordAttrs["messaging.message.id"])
+}
+
+func TestFirehoseMiddleware_WithWrapHandler(t *testing.T) {
+	tp, exporter := newTestTracerProvider()
+	h := firehose.HandlerFunc(func(c *firehose.Context) error {
+		if string(c.Record.Data) == "fail" {
+			return assert.AnError
+		}
+		c.Drop()
+		return nil
+	}).Middleware(FirehoseMiddleware(WithTracerProvider(tp)))
+
+	res, err := WrapHandlerWithResponse(h.ToLambdaHandler(), WithTracerProvider(tp))(
+		context.Background(),
+		events.KinesisFirehoseEvent{
+			DeliveryStreamArn: "arn:aws:firehose:eu-west-1:123456789012:deliverystream/orders",
+			Records: []events.KinesisFirehoseEventRecord{
+				{RecordID: "record-1", Data: []byte("drop")},
+				{RecordID: "record-2", Data: []byte("fail")},
+			},
+		},
+	)
+
+	assert.Nil(t, err)
+	assert.Len(t, res.Records, 2)
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 3)
+	dropped, failed, invocation := spans[0], spans[1], spans[2]
+
+	invocationAttrs := attributeMap(invocation.Attributes)
+	assert.Equal(t, "pubsub", invocationAttrs["faas.trigger"])
+	assert.Equal(t, "aws_firehose", invocationAttrs["messaging.system"])
+	assert.Equal(t, int64(2), invocationAttrs["messaging.batch.message_count"])
+	assert.Equal(t, int64(1), invocationAttrs["lambdah.batch.item_failures"])
+
+	assert.Equal(t, "orders process", dropped.Name)
+	assert.Equal(t, trace.SpanKindConsumer, dropped.SpanKind)
+	assert.Equal(t, invocation.SpanContext.SpanID(), dropped.Parent.SpanID())
+	droppedAttrs := attributeMap(dropped.Attributes)
+	assert.Equal(t, "orders", droppedAttrs["messaging.destination.name"])
+	assert.Equal(t, "record-1", droppedAttrs["messaging.message.id"])
+	assert.Equal(t, "Dropped", droppedAttrs["lambdah.firehose.result"])
+
+	assert.Equal(t, codes.Error, failed.Status.Code)
+	assert.NotContains(t, attributeMap(failed.Attributes), "lambdah.firehose.result")
+}
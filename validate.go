@@ -0,0 +1,255 @@
+package lambdah
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FieldError describes a field which failed validation.
+type FieldError struct {
+	// Field is the path to the field, using JSON names where set, e.g. `items[0].name`
+	Field string `json:"field"`
+	// Rule is the validation rule which failed, e.g. `required` or `max`
+	Rule string `json:"rule"`
+	// Param is the parameter of the rule, e.g. `100` for `max=100`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+func (err FieldError) Error() string {
+	return fmt.Sprintf("%s %s", err.Field, err.Message)
+}
+
+// ValidationErrors is returned by Validate when one or more fields fail validation.
+type ValidationErrors []FieldError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return "validation failed: " + strings.Join(messages, ", ")
+}
+
+// Validate v using its `validate` struct tags, then using its Validate method if
+// it implements Validatable. This is called by Bind in all handler packages.
+//
+// Rules are comma separated, for example `validate:"required,min=1,max=100"`.
+// Supported rules are:
+//
+//	required   the value must not be the zero value, or must not be empty for strings, slices and maps
+//	omitempty  skip the other rules if the value is the zero value
+//	min=n      the minimum length of strings, slices and maps, or the minimum of numbers
+//	max=n      the maximum length of strings, slices and maps, or the maximum of numbers
+//	len=n      the exact length of strings, slices and maps
+//	email      the value must be an email address
+//	oneof=a b  the value must be one of the space separated values
+//
+// Nested structs, including structs within slices, maps and pointers, are also
+// validated. All failing fields are returned together as ValidationErrors.
+func Validate(v interface{}) error {
+	errs := make(ValidationErrors, 0)
+	validateValue(reflect.ValueOf(v), "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if validatable, ok := v.(Validatable); ok {
+		return validatable.Validate()
+	}
+
+	return nil
+}
+
+func validateValue(v reflect.Value, path string, errs *ValidationErrors) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		validateStruct(v, path, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			validateValue(iter.Value(), fmt.Sprintf("%s[%s]", path, valueString(iter.Key())), errs)
+		}
+	}
+}
+
+func validateStruct(v reflect.Value, path string, errs *ValidationErrors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			// unexported field
+			continue
+		}
+
+		fieldPath := path
+		if !field.Anonymous {
+			fieldPath = joinPath(path, fieldName(field))
+		}
+
+		fieldValue := v.Field(i)
+		if tag, ok := field.Tag.Lookup("validate"); ok && tag != "-" {
+			validateField(fieldValue, fieldPath, tag, errs)
+		}
+		validateValue(fieldValue, fieldPath, errs)
+	}
+}
+
+func validateField(v reflect.Value, path string, tag string, errs *ValidationErrors) {
+	rules := strings.Split(tag, ",")
+	for _, rule := range rules {
+		if strings.TrimSpace(rule) == "omitempty" && isEmpty(v) {
+			return
+		}
+	}
+
+	for _, rule := range rules {
+		name, param := strings.TrimSpace(rule), ""
+		if i := strings.Index(name, "="); i >= 0 {
+			name, param = name[:i], name[i+1:]
+		}
+
+		message, ok := checkRule(v, name, param)
+		if !ok {
+			*errs = append(*errs, FieldError{
+				Field:   path,
+				Rule:    name,
+				Param:   param,
+				Message: message,
+			})
+		}
+	}
+}
+
+// checkRule returns a message and false if v fails the rule.
+func checkRule(v reflect.Value, rule string, param string) (string, bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "is required", rule != "required"
+		}
+		v = v.Elem()
+	}
+
+	switch rule {
+	case "", "omitempty":
+		return "", true
+	case "required":
+		return "is required", !isEmpty(v)
+	case "min":
+		if isLengthKind(v) {
+			n, _ := strconv.Atoi(param)
+			return fmt.Sprintf("must have a length of at least %s", param), v.Len() >= n
+		}
+		min, _ := strconv.ParseFloat(param, 64)
+		f, ok := toFloat(v)
+		return fmt.Sprintf("must be at least %s", param), ok && f >= min
+	case "max":
+		if isLengthKind(v) {
+			n, _ := strconv.Atoi(param)
+			return fmt.Sprintf("must have a length of at most %s", param), v.Len() <= n
+		}
+		max, _ := strconv.ParseFloat(param, 64)
+		f, ok := toFloat(v)
+		return fmt.Sprintf("must be at most %s", param), ok && f <= max
+	case "len":
+		n, _ := strconv.Atoi(param)
+		return fmt.Sprintf("must have a length of %s", param), isLengthKind(v) && v.Len() == n
+	case "email":
+		if v.Kind() != reflect.String {
+			return "must be an email address", false
+		}
+		address, err := mail.ParseAddress(v.String())
+		return "must be an email address", err == nil && address.Address == v.String()
+	case "oneof":
+		value := valueString(v)
+		for _, allowed := range strings.Fields(param) {
+			if value == allowed {
+				return "", true
+			}
+		}
+		return fmt.Sprintf("must be one of [%s]", param), false
+	default:
+		return fmt.Sprintf("has unknown validation rule '%s'", rule), false
+	}
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+func isLengthKind(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return true
+	default:
+		return false
+	}
+}
+
+func toFloat(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// valueString formats v without calling Interface(), which panics for values
+// reached through unexported embedded structs.
+func valueString(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	default:
+		return v.String()
+	}
+}
+
+func fieldName(field reflect.StructField) string {
+	if tag, ok := field.Tag.Lookup("json"); ok {
+		name := strings.Split(tag, ",")[0]
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,133 @@
+package lambdah
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type validateAddress struct {
+	Line1    string `json:"line1" validate:"required"`
+	Postcode string `json:"postcode" validate:"omitempty,len=6"`
+}
+
+type validateItem struct {
+	Name     string `json:"name" validate:"required,max=5"`
+	Quantity int    `json:"quantity" validate:"min=1,max=100"`
+}
+
+type validateOrder struct {
+	Email    string            `json:"email" validate:"required,email"`
+	Status   string            `json:"status" validate:"oneof=new paid shipped"`
+	Items    []validateItem    `json:"items" validate:"required,min=1"`
+	Address  *validateAddress  `json:"address"`
+	Notes    map[string]string `json:"notes" validate:"max=2"`
+	Priority *int              `json:"priority" validate:"omitempty,min=1"`
+	Internal string            `json:"-" validate:"-"`
+}
+
+func TestValidate_Valid(t *testing.T) {
+	order := validateOrder{
+		Email:   "dog@example.com",
+		Status:  "paid",
+		Items:   []validateItem{{Name: "bone", Quantity: 2}},
+		Address: &validateAddress{Line1: "1 Kennel Road"},
+	}
+
+	assert.Nil(t, Validate(&order))
+}
+
+func TestValidate_Invalid(t *testing.T) {
+	priority := 0
+	order := validateOrder{
+		Email:  "not an email",
+		Status: "lost",
+		Items: []validateItem{
+			{Name: "bone", Quantity: 2},
+			{Name: "squeaky toy", Quantity: 0},
+		},
+		Address:  &validateAddress{Postcode: "AB1"},
+		Notes:    map[string]string{"a": "", "b": "", "c": ""},
+		Priority: &priority,
+	}
+
+	err := Validate(&order)
+
+	assert.Equal(t, ValidationErrors{
+		{Field: "email", Rule: "email", Message: "must be an email address"},
+		{Field: "status", Rule: "oneof", Param: "new paid shipped", Message: "must be one of [new paid shipped]"},
+		{Field: "items[1].name", Rule: "max", Param: "5", Message: "must have a length of at most 5"},
+		{Field: "items[1].quantity", Rule: "min", Param: "1", Message: "must be at least 1"},
+		{Field: "address.line1", Rule: "required", Message: "is required"},
+		{Field: "address.postcode", Rule: "len", Param: "6", Message: "must have a length of 6"},
+		{Field: "notes", Rule: "max", Param: "2", Message: "must have a length of at most 2"},
+		{Field: "priority", Rule: "min", Param: "1", Message: "must be at least 1"},
+	}, err)
+}
+
+func TestValidate_Required(t *testing.T) {
+	var order validateOrder
+
+	err := Validate(&order)
+
+	assert.Equal(t, ValidationErrors{
+		{Field: "email", Rule: "required", Message: "is required"},
+		{Field: "email", Rule: "email", Message: "must be an email address"},
+		{Field: "status", Rule: "oneof", Param: "new paid shipped", Message: "must be one of [new paid shipped]"},
+		{Field: "items", Rule: "required", Message: "is required"},
+		{Field: "items", Rule: "min", Param: "1", Message: "must have a length of at least 1"},
+	}, err)
+	assert.EqualError(t, err, "validation failed: email is required, email must be an email address, "+
+		"status must be one of [new paid shipped], items is required, items must have a length of at least 1")
+}
+
+type validateEmbedded struct {
+	ID string `json:"id" validate:"required"`
+}
+
+type validateWithEmbedded struct {
+	validateEmbedded
+	Kind string `json:"kind" validate:"oneof=a b"`
+}
+
+func TestValidate_EmbeddedStruct(t *testing.T) {
+	err := Validate(validateWithEmbedded{Kind: "c"})
+
+	assert.Equal(t, ValidationErrors{
+		{Field: "id", Rule: "required", Message: "is required"},
+		{Field: "kind", Rule: "oneof", Param: "a b", Message: "must be one of [a b]"},
+	}, err)
+}
+
+func TestValidate_UnknownRule(t *testing.T) {
+	err := Validate(&struct {
+		Name string `validate:"uppercase"`
+	}{Name: "dog"})
+
+	assert.Equal(t, ValidationErrors{
+		{Field: "Name", Rule: "uppercase", Message: "has unknown validation rule 'uppercase'"},
+	}, err)
+}
+
+type validateWithMethod struct {
+	Name string `validate:"required"`
+}
+
+func (v validateWithMethod) Validate() error {
+	if v.Name == "cat" {
+		return assert.AnError
+	}
+	return nil
+}
+
+func TestValidate_CallsValidatableAfterTags(t *testing.T) {
+	assert.Nil(t, Validate(validateWithMethod{Name: "dog"}))
+	assert.Equal(t, assert.AnError, Validate(validateWithMethod{Name: "cat"}))
+	assert.IsType(t, ValidationErrors{}, Validate(validateWithMethod{}))
+}
+
+func TestValidate_NonStruct(t *testing.T) {
+	assert.Nil(t, Validate(nil))
+	assert.Nil(t, Validate("dog"))
+	assert.Nil(t, Validate(map[string]int{"a": 1}))
+}